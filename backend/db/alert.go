@@ -0,0 +1,161 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/patiee/backend/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// alertLockNamespace keys the per-user advisory lock serialising queue advances
+const alertLockNamespace = 4101
+
+var activeAlertStatuses = []string{model.AlertStatusQueued, model.AlertStatusApproved, model.AlertStatusPlaying}
+
+// CreateAlert queues an alert, doing nothing if the tip already has one
+func (d *Database) CreateAlert(alert *model.Alert) error {
+	return d.conn.Clauses(clause.OnConflict{DoNothing: true}).Create(alert).Error
+}
+
+func (d *Database) GetAlert(userID, alertID uint) (*model.Alert, error) {
+	alert := &model.Alert{}
+	if err := d.conn.Where("id = ? AND user_id = ?", alertID, userID).First(alert).Error; err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+// GetActiveAlerts returns the user's queued, approved and playing alerts in play order
+func (d *Database) GetActiveAlerts(userID uint, limit int) ([]model.Alert, error) {
+	var alerts []model.Alert
+	err := d.conn.Where("user_id = ? AND status IN ?", userID, activeAlertStatuses).
+		Order("id asc").
+		Limit(limit).
+		Find(&alerts).Error
+	return alerts, err
+}
+
+// AlertQueueSummary is the state of a user's alert queue
+type AlertQueueSummary struct {
+	Waiting          int64 // Queued or approved
+	AwaitingApproval int64 // Queued, needing approval
+	PlayingID        uint
+}
+
+func (d *Database) GetAlertQueueSummary(userID uint) (*AlertQueueSummary, error) {
+	summary := &AlertQueueSummary{}
+	err := d.conn.Model(&model.Alert{}).
+		Select(`COUNT(*) FILTER (WHERE status IN ?) AS waiting,
+			COUNT(*) FILTER (WHERE status = ? AND requires_approval) AS awaiting_approval,
+			COALESCE(MAX(id) FILTER (WHERE status = ?), 0) AS playing_id`,
+			[]string{model.AlertStatusQueued, model.AlertStatusApproved}, model.AlertStatusQueued, model.AlertStatusPlaying).
+		Where("user_id = ? AND status IN ?", userID, activeAlertStatuses).
+		Scan(summary).Error
+	return summary, err
+}
+
+// GetUsersWithActiveAlerts returns the IDs of users whose queue has work left
+func (d *Database) GetUsersWithActiveAlerts() ([]uint, error) {
+	var userIDs []uint
+	err := d.conn.Model(&model.Alert{}).
+		Distinct("user_id").
+		Where("status IN ?", activeAlertStatuses).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// TransitionAlert moves an alert to status if it currently is in one of from.
+// It reports whether the alert was updated.
+func (d *Database) TransitionAlert(userID, alertID uint, from []string, status string) (bool, error) {
+	result := d.conn.Model(&model.Alert{}).
+		Where("id = ? AND user_id = ? AND status IN ?", alertID, userID, from).
+		Update("status", status)
+	return result.RowsAffected > 0, result.Error
+}
+
+// StartNextAlert finishes a playing alert older than maxPlay and, unless the
+// queue is paused or an alert is still playing, marks the next playable
+// alert as playing and returns it. It returns nil when nothing started.
+func (d *Database) StartNextAlert(userID uint, maxPlay time.Duration) (*model.Alert, error) {
+	var started *model.Alert
+	err := d.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", alertLockNamespace, userID).Error; err != nil {
+			return err
+		}
+
+		// 1. Current alert, expired if the overlay never reported it done
+		playing := &model.Alert{}
+		err := tx.Where("user_id = ? AND status = ?", userID, model.AlertStatusPlaying).First(playing).Error
+		if err == nil {
+			if playing.StartedAt != nil && time.Since(*playing.StartedAt) < maxPlay {
+				return nil
+			}
+			if err := tx.Model(playing).Update("status", model.AlertStatusPlayed).Error; err != nil {
+				return err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// 2. Paused queues keep their alerts
+		user := &model.User{}
+		if err := tx.Select("alerts_paused", "alerts_require_approval").First(user, userID).Error; err != nil {
+			return err
+		}
+		if user.AlertsPaused {
+			return nil
+		}
+
+		// 3. Next alert that needs no (more) approval. Alerts queued in
+		// approval mode play once it's off.
+		next := &model.Alert{}
+		err = tx.Where("user_id = ? AND (status = ? OR (status = ? AND (requires_approval = ? OR ?)))",
+			userID, model.AlertStatusApproved, model.AlertStatusQueued, false, !user.AlertsRequireApproval).
+			Order("id asc").
+			First(next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(next).Updates(map[string]interface{}{"status": model.AlertStatusPlaying, "started_at": now}).Error; err != nil {
+			return err
+		}
+		next.Status = model.AlertStatusPlaying
+		next.StartedAt = &now
+		started = next
+		return nil
+	})
+	return started, err
+}
+
+func (d *Database) SetAlertsPaused(userID uint, paused bool) error {
+	return d.conn.Model(&model.User{}).Where("id = ?", userID).Update("alerts_paused", paused).Error
+}
+
+// SetAlertsRequireApproval switches approval mode. Switching it off releases
+// the alerts still waiting for approval.
+func (d *Database) SetAlertsRequireApproval(userID uint, required bool) error {
+	return d.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).Update("alerts_require_approval", required).Error; err != nil {
+			return err
+		}
+		if required {
+			return nil
+		}
+		return tx.Model(&model.Alert{}).
+			Where("user_id = ? AND status = ? AND requires_approval = ?", userID, model.AlertStatusQueued, true).
+			Update("requires_approval", false).Error
+	})
+}
+
+func (d *Database) GetTipsByIDs(ids []uint) ([]model.Tip, error) {
+	var tips []model.Tip
+	err := d.conn.Where("id IN ?", ids).Find(&tips).Error
+	return tips, err
+}
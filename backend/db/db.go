@@ -1,19 +1,21 @@
 package db
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/patiee/backend/db/model"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Database struct {
 	logger *log.Logger
 	conn   *gorm.DB
+	dsn    string // Kept for dedicated LISTEN connections
 }
 
 func New(logger *log.Logger) *Database {
@@ -21,11 +23,12 @@ func New(logger *log.Logger) *Database {
 }
 
 func (d *Database) Init(dsn string) (err error) {
-	d.conn, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	d.dsn = dsn
+	d.conn, err = gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		d.logger.Printf("Failed to connect to database: %v. Retrying in 5s...", err)
 		time.Sleep(5 * time.Second)
-		d.conn, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		d.conn, err = gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 		if err != nil {
 			return fmt.Errorf("could not connect to database: %w", err)
 		}
@@ -33,8 +36,48 @@ func (d *Database) Init(dsn string) (err error) {
 
 	d.logger.Println("Database connected successfully")
 
+	if err := d.dedupeTipTxs(); err != nil {
+		return err
+	}
+
 	// Migrate the schema
-	return d.conn.AutoMigrate(&model.User{}, &model.Tip{}, &model.UsedSignature{}, &model.WalletSession{}, &model.UserSession{})
+	return d.conn.AutoMigrate(&model.User{}, &model.Tip{}, &model.UsedSignature{}, &model.WalletSession{}, &model.UserSession{}, &model.UsedRefreshToken{}, &model.OAuthState{}, &model.AuthCode{}, &model.WalletNonce{}, &model.VerificationJob{}, &model.WidgetEvent{}, &model.Alert{}, &model.Goal{}, &model.Widget{}, &model.Media{}, &model.MediaTier{}, &model.Webhook{}, &model.WebhookDelivery{})
+}
+
+// dedupeTipTxs fails every tip but the first of a tx paying several, which
+// predate the unique tx indexes and would stop them from being created
+func (d *Database) dedupeTipTxs() error {
+	migrator := d.conn.Migrator()
+	if !migrator.HasTable(&model.Tip{}) {
+		return nil
+	}
+
+	duplicates := map[string]string{
+		"idx_tips_chain_tx": "chain_id, tx_hash",
+		"idx_tips_dest_tx":  "dest_chain, dest_tx_hash",
+	}
+	for index, columns := range duplicates {
+		if migrator.HasIndex(&model.Tip{}, index) {
+			continue
+		}
+		if index == "idx_tips_dest_tx" && !migrator.HasColumn(&model.Tip{}, "DestTxHash") {
+			continue
+		}
+		claimed := "status <> 'failed'"
+		if index == "idx_tips_dest_tx" {
+			claimed += " AND dest_tx_hash <> ''"
+		}
+		res := d.conn.Exec(fmt.Sprintf(
+			"UPDATE tips SET status = 'failed' WHERE %[2]s AND id NOT IN (SELECT MIN(id) FROM tips WHERE %[2]s GROUP BY %[1]s)",
+			columns, claimed))
+		if res.Error != nil {
+			return fmt.Errorf("failed to dedupe tips for %s: %w", index, res.Error)
+		}
+		if res.RowsAffected > 0 {
+			d.logger.Printf("Failed %d tips paid by an already tipped tx (%s)", res.RowsAffected, index)
+		}
+	}
+	return nil
 }
 
 func (d *Database) GetUserByID(id uint) (user *model.User, err error) {
@@ -103,9 +146,6 @@ func (d *Database) LinkProvider(userID uint, provider, providerID, providerUsern
 }
 
 func (d *Database) CreateUser(user *model.User) error {
-	if user.WidgetToken == "" {
-		user.WidgetToken = uuid.New().String()
-	}
 	return d.conn.Create(user).Error
 }
 
@@ -117,14 +157,6 @@ func (d *Database) GetUserByWalletAddress(address string) (*model.User, error) {
 	return &user, nil
 }
 
-func (d *Database) GetUserByWidgetToken(token string) (*model.User, error) {
-	var user model.User
-	if err := d.conn.Where("widget_token = ?", token).First(&user).Error; err != nil {
-		return nil, err
-	}
-	return &user, nil
-}
-
 func (d *Database) UpdateUserWallet(userID uint, wallet string, chainID int64, asset string) error {
 	updates := map[string]interface{}{
 		"eth_address":        wallet,
@@ -156,13 +188,28 @@ func (d *Database) CheckUsernameTaken(username string, excludeUserID uint) bool
 	return count > 0
 }
 
-func (d *Database) UpdateWidgetConfig(userID uint, tts bool, bg, userColor, amountColor, msgColor string) error {
+func (d *Database) UpdateMessageFilter(userID uint, filter *model.User) error {
+	return d.conn.Model(&model.User{}).Where("id = ?", userID).
+		Select("filter_banned_words", "filter_strip_links", "filter_max_length", "filter_collapse_repeats", "filter_action").
+		Updates(filter).Error
+}
+
+func (d *Database) UpdateTipRules(userID uint, rules *model.User) error {
+	return d.conn.Model(&model.User{}).Where("id = ?", userID).
+		Select("tip_min_amounts", "tip_min_usd", "message_min_usd", "tts_min_usd", "media_min_usd").
+		Updates(rules).Error
+}
+
+func (d *Database) UpdateTTSSettings(userID uint, settings *model.User) error {
+	return d.conn.Model(&model.User{}).Where("id = ?", userID).
+		Select("tts_voice", "tts_language", "tts_rate").
+		Updates(settings).Error
+}
+
+func (d *Database) UpdateChatSettings(userID uint, enabled bool, template string) error {
 	return d.conn.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
-		"widget_tts":           tts,
-		"widget_bg_color":      bg,
-		"widget_user_color":    userColor,
-		"widget_amount_color":  amountColor,
-		"widget_message_color": msgColor,
+		"chat_announce_enabled":  enabled,
+		"chat_announce_template": template,
 	}).Error
 }
 
@@ -170,6 +217,26 @@ func (d *Database) CreateTip(tip *model.Tip) error {
 	return d.conn.Create(tip).Error
 }
 
+// IsTipTxClaimed reports whether txHash on chainID already paid a tip, directly
+// or as a bridge delivery, other than excludeTipID. Failed tips don't hold
+// their tx, it can be submitted again.
+func (d *Database) IsTipTxClaimed(chainID, txHash string, excludeTipID uint) (bool, error) {
+	var count int64
+	err := d.conn.Unscoped().Model(&model.Tip{}).
+		Where("id <> ? AND status <> ?", excludeTipID, model.TipStatusFailed).
+		Where(d.conn.Where("chain_id = ? AND tx_hash = ?", chainID, txHash).Or("dest_chain = ? AND dest_tx_hash = ?", chainID, txHash)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (d *Database) GetTipByID(id uint) (*model.Tip, error) {
+	var tip model.Tip
+	if err := d.conn.First(&tip, id).Error; err != nil {
+		return nil, err
+	}
+	return &tip, nil
+}
+
 func (d *Database) GetTipsPaginated(streamerID string, limit int, cursor uint) ([]model.Tip, error) {
 	var tips []model.Tip
 	query := d.conn.Where("streamer_id = ?", streamerID).Order("id desc").Limit(limit)
@@ -218,6 +285,152 @@ func (d *Database) GetUserSession(token string) (*model.UserSession, error) {
 	}
 	return &session, nil
 }
+
+func (d *Database) GetUserSessionByID(sessionID uint) (*model.UserSession, error) {
+	var session model.UserSession
+	if err := d.conn.First(&session, sessionID).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RotateUserSession replaces the session's refresh token hash oldToken with
+// newToken, remembering oldToken for reuse detection. It returns false if
+// oldToken was rotated concurrently.
+func (d *Database) RotateUserSession(sessionID uint, oldToken, newToken string, expiresAt time.Time) (bool, error) {
+	var rotated bool
+	err := d.conn.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.UserSession{}).Where("id = ? AND token = ?", sessionID, oldToken).Updates(map[string]interface{}{
+			"token":        newToken,
+			"expires_at":   expiresAt,
+			"last_seen_at": time.Now(),
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if rotated = res.RowsAffected == 1; !rotated {
+			return nil
+		}
+		return tx.Create(&model.UsedRefreshToken{TokenHash: oldToken, SessionID: sessionID}).Error
+	})
+	return rotated, err
+}
+
+func (d *Database) GetUsedRefreshToken(tokenHash string) (*model.UsedRefreshToken, error) {
+	var used model.UsedRefreshToken
+	if err := d.conn.Where("token_hash = ?", tokenHash).First(&used).Error; err != nil {
+		return nil, err
+	}
+	return &used, nil
+}
+
+// GetUserSessions returns the user's signed in sessions, most recently seen first
+func (d *Database) GetUserSessions(userID uint) ([]model.UserSession, error) {
+	var sessions []model.UserSession
+	err := d.conn.Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").Order("id DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// TouchUserSession sets the session's last seen time unless it was already
+// seen after staleBefore
+func (d *Database) TouchUserSession(sessionID uint, seenAt, staleBefore time.Time) error {
+	return d.conn.Model(&model.UserSession{}).
+		Where("id = ? AND (last_seen_at IS NULL OR last_seen_at < ?)", sessionID, staleBefore).
+		Update("last_seen_at", seenAt).Error
+}
+
+// DeleteUserSession signs the session out, with its refresh token history
+func (d *Database) DeleteUserSession(sessionID uint) error {
+	return d.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", sessionID).Delete(&model.UsedRefreshToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.UserSession{}, sessionID).Error
+	})
+}
+
+// DeleteOtherUserSessions signs the user out everywhere but keepSessionID,
+// returning how many sessions were deleted
+func (d *Database) DeleteOtherUserSessions(userID, keepSessionID uint) (int64, error) {
+	var deleted int64
+	err := d.conn.Transaction(func(tx *gorm.DB) error {
+		others := tx.Model(&model.UserSession{}).Select("id").Where("user_id = ? AND id <> ?", userID, keepSessionID)
+		if err := tx.Where("session_id IN (?)", others).Delete(&model.UsedRefreshToken{}).Error; err != nil {
+			return err
+		}
+		res := tx.Where("user_id = ? AND id <> ?", userID, keepSessionID).Delete(&model.UserSession{})
+		deleted = res.RowsAffected
+		return res.Error
+	})
+	return deleted, err
+}
+
+// CreateWalletNonce stores a wallet login nonce, dropping expired ones
+func (d *Database) CreateWalletNonce(nonce *model.WalletNonce) error {
+	if err := d.conn.Where("expires_at < ?", time.Now()).Delete(&model.WalletNonce{}).Error; err != nil {
+		d.logger.Printf("Error cleaning wallet nonces: %v", err)
+	}
+	return d.conn.Create(nonce).Error
+}
+
+// ConsumeWalletNonce deletes and returns the nonce, so it can only be used once
+func (d *Database) ConsumeWalletNonce(nonce string) (*model.WalletNonce, error) {
+	var record model.WalletNonce
+	res := d.conn.Clauses(clause.Returning{}).Where("nonce = ?", nonce).Delete(&record)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &record, nil
+}
+
+// CreateOAuthState stores a pending OAuth flow, dropping expired ones
+func (d *Database) CreateOAuthState(state *model.OAuthState) error {
+	if err := d.conn.Where("expires_at < ?", time.Now()).Delete(&model.OAuthState{}).Error; err != nil {
+		d.logger.Printf("Error cleaning oauth states: %v", err)
+	}
+	return d.conn.Create(state).Error
+}
+
+// ConsumeOAuthState deletes and returns the state with stateHash, so it can
+// only be used once
+func (d *Database) ConsumeOAuthState(stateHash string) (*model.OAuthState, error) {
+	var state model.OAuthState
+	res := d.conn.Clauses(clause.Returning{}).Where("state_hash = ?", stateHash).Delete(&state)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &state, nil
+}
+
+// CreateAuthCode stores a one-time auth code, dropping expired ones
+func (d *Database) CreateAuthCode(code *model.AuthCode) error {
+	if err := d.conn.Where("expires_at < ?", time.Now()).Delete(&model.AuthCode{}).Error; err != nil {
+		d.logger.Printf("Error cleaning auth codes: %v", err)
+	}
+	return d.conn.Create(code).Error
+}
+
+// ConsumeAuthCode deletes and returns the code with codeHash, so it can only
+// be exchanged once
+func (d *Database) ConsumeAuthCode(codeHash string) (*model.AuthCode, error) {
+	var code model.AuthCode
+	res := d.conn.Clauses(clause.Returning{}).Where("code_hash = ?", codeHash).Delete(&code)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &code, nil
+}
+
 func (d *Database) RevokeWalletSessions(walletAddress string) error {
 	return d.conn.Where("wallet_address = ?", walletAddress).Delete(&model.WalletSession{}).Error
 }
@@ -249,6 +462,27 @@ func (d *Database) CleanupExpiredSessions() error {
 	if err := d.conn.Where("expires_at < ?", now).Delete(&model.UserSession{}).Error; err != nil {
 		d.logger.Printf("Error cleaning user sessions: %v", err)
 	}
+	// Clean Refresh Tokens of sessions that are gone
+	if err := d.conn.Where("session_id NOT IN (?)", d.conn.Model(&model.UserSession{}).Select("id")).Delete(&model.UsedRefreshToken{}).Error; err != nil {
+		d.logger.Printf("Error cleaning refresh tokens: %v", err)
+	}
+	// Clean OAuth States left by abandoned logins
+	if err := d.conn.Where("expires_at < ?", now).Delete(&model.OAuthState{}).Error; err != nil {
+		d.logger.Printf("Error cleaning oauth states: %v", err)
+	}
+	// Clean Auth Codes that were never exchanged
+	if err := d.conn.Where("expires_at < ?", now).Delete(&model.AuthCode{}).Error; err != nil {
+		d.logger.Printf("Error cleaning auth codes: %v", err)
+	}
+	// Clean Wallet Nonces that were never signed
+	if err := d.conn.Where("expires_at < ?", now).Delete(&model.WalletNonce{}).Error; err != nil {
+		d.logger.Printf("Error cleaning wallet nonces: %v", err)
+	}
+	// Clean Widget Events past the replay window, keeping each user's last tip alert
+	lastTips := d.conn.Model(&model.WidgetEvent{}).Select("MAX(id)").Where("type = ?", "TIP").Group("user_id")
+	if err := d.conn.Where("created_at < ? AND id NOT IN (?)", now.Add(-widgetEventRetention), lastTips).Delete(&model.WidgetEvent{}).Error; err != nil {
+		d.logger.Printf("Error cleaning widget events: %v", err)
+	}
 	// Clean Blacklist (Expired bans)
 	if err := d.conn.Where("expires_at < ?", now).Delete(&model.WalletBlacklist{}).Error; err != nil {
 		d.logger.Printf("Error cleaning blacklist: %v", err)
@@ -256,29 +490,93 @@ func (d *Database) CleanupExpiredSessions() error {
 	return nil
 }
 
-func (d *Database) EnsureWidgetTokens() error {
-	var users []model.User
-	if err := d.conn.Where("widget_token = '' OR widget_token IS NULL").Find(&users).Error; err != nil {
-		return err
-	}
+func (d *Database) UpdateTipStatus(tipID uint, status string) error {
+	return d.conn.Model(&model.Tip{}).Where("id = ?", tipID).Update("status", status).Error
+}
+
+func (d *Database) UpdateTipAmount(tipID uint, amount, asset string) error {
+	return d.conn.Model(&model.Tip{}).Where("id = ?", tipID).Updates(map[string]interface{}{
+		"amount": amount,
+		"asset":  asset,
+	}).Error
+}
 
-	for _, user := range users {
-		user.WidgetToken = uuid.New().String()
-		if err := d.conn.Save(&user).Error; err != nil {
-			d.logger.Printf("Failed to generate widget token for user %s: %v", user.Username, err)
+func (d *Database) CreateVerificationJob(tipID uint, runAt time.Time) error {
+	job := &model.VerificationJob{TipID: tipID, NextRunAt: runAt}
+	return d.conn.Clauses(clause.OnConflict{DoNothing: true}).Create(job).Error
+}
+
+// EnqueuePendingTips creates jobs for unsettled tips that have none,
+// e.g. tips that were being monitored in-process before a restart
+func (d *Database) EnqueuePendingTips() (int64, error) {
+	res := d.conn.Exec(`
+		INSERT INTO verification_jobs (tip_id, attempts, next_run_at, created_at, updated_at)
+		SELECT t.id, 0, NOW(), t.created_at, NOW()
+		FROM tips t
+		WHERE t.status IN ('pending', 'seen', 'bridging', 'delivered') AND t.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM verification_jobs j WHERE j.tip_id = t.id)`)
+	return res.RowsAffected, res.Error
+}
+
+// LeaseVerificationJob claims the next due job for owner until the lease expires.
+// Returns nil if no job is due.
+func (d *Database) LeaseVerificationJob(owner string, lease time.Duration) (*model.VerificationJob, error) {
+	var job model.VerificationJob
+	err := d.conn.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("next_run_at <= ? AND (leased_until IS NULL OR leased_until < ?)", now, now).
+			Order("next_run_at").
+			First(&job).Error
+		if err != nil {
+			return err
 		}
+
+		leasedUntil := now.Add(lease)
+		job.LeaseOwner = owner
+		job.LeasedUntil = &leasedUntil
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"lease_owner":  owner,
+			"leased_until": leasedUntil,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
 	}
-	return nil
-}
-func (d *Database) RefreshWidgetToken(userID uint) (string, error) {
-	newToken := uuid.New().String()
-	err := d.conn.Model(&model.User{}).Where("id = ?", userID).Update("widget_token", newToken).Error
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return newToken, nil
+	return &job, nil
 }
 
-func (d *Database) UpdateTipStatus(tipID uint, status string) error {
-	return d.conn.Model(&model.Tip{}).Where("id = ?", tipID).Update("status", status).Error
+// RescheduleVerificationJob releases the lease and schedules the next attempt
+func (d *Database) RescheduleVerificationJob(id uint, attempts int, nextRunAt time.Time, lastError string) error {
+	return d.conn.Model(&model.VerificationJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":     attempts,
+		"next_run_at":  nextRunAt,
+		"last_error":   lastError,
+		"lease_owner":  "",
+		"leased_until": nil,
+	}).Error
+}
+
+func (d *Database) DeleteVerificationJob(id uint) error {
+	return d.conn.Delete(&model.VerificationJob{}, id).Error
+}
+
+func (d *Database) UpdateTipUSDValue(tipID uint, usdValue string) error {
+	return d.conn.Model(&model.Tip{}).Where("id = ?", tipID).Update("usd_value", usdValue).Error
+}
+
+func (d *Database) UpdateTipTTSAudio(tipID uint, url string) error {
+	return d.conn.Model(&model.Tip{}).Where("id = ?", tipID).Update("tts_audio_url", url).Error
+}
+
+// UpdateTipDelivery stores what a bridge delivered for a cross-chain tip
+func (d *Database) UpdateTipDelivery(tipID uint, destTxHash, destAmount, destAsset string) error {
+	return d.conn.Model(&model.Tip{}).Where("id = ?", tipID).Updates(map[string]interface{}{
+		"dest_tx_hash": destTxHash,
+		"dest_amount":  destAmount,
+		"dest_asset":   destAsset,
+	}).Error
 }
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"time"
+
+	"github.com/patiee/backend/db/model"
+)
+
+func (d *Database) CreateGoal(goal *model.Goal) error {
+	return d.conn.Create(goal).Error
+}
+
+func (d *Database) GetGoal(userID, goalID uint) (*model.Goal, error) {
+	goal := &model.Goal{}
+	if err := d.conn.Where("id = ? AND user_id = ?", goalID, userID).First(goal).Error; err != nil {
+		return nil, err
+	}
+	return goal, nil
+}
+
+// GetGoals returns the user's goals, newest first
+func (d *Database) GetGoals(userID uint) ([]model.Goal, error) {
+	var goals []model.Goal
+	err := d.conn.Where("user_id = ?", userID).Order("id desc").Find(&goals).Error
+	return goals, err
+}
+
+// GetActiveGoals returns the user's active goals running at now, newest first
+func (d *Database) GetActiveGoals(userID uint, now time.Time) ([]model.Goal, error) {
+	var goals []model.Goal
+	err := d.conn.Where("user_id = ? AND active AND start_at <= ? AND (end_at IS NULL OR end_at > ?)", userID, now, now).
+		Order("id desc").
+		Find(&goals).Error
+	return goals, err
+}
+
+func (d *Database) UpdateGoal(goal *model.Goal) error {
+	return d.conn.Model(goal).
+		Select("title", "target_amount", "target_asset", "start_at", "end_at", "active").
+		Updates(goal).Error
+}
+
+func (d *Database) DeleteGoal(userID, goalID uint) (bool, error) {
+	res := d.conn.Where("id = ? AND user_id = ?", goalID, userID).Delete(&model.Goal{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// GoalProgressRow is the sum of the tips counting towards a goal
+type GoalProgressRow struct {
+	Total    string
+	TipCount int64
+}
+
+// GetGoalProgress sums the streamer's confirmed tips within the goal's window,
+// in USD value or in the goal's asset
+func (d *Database) GetGoalProgress(streamerID string, goal *model.Goal) (*GoalProgressRow, error) {
+	query := d.confirmedTips(StatsFilter{StreamerID: streamerID, From: &goal.StartAt, To: goal.EndAt})
+
+	sum := tipUSDValueSQL
+	if goal.TargetAsset != model.GoalAssetUSD {
+		sum = tipAmountSQL
+		query = query.Where("UPPER(asset) = ?", goal.TargetAsset)
+	}
+
+	row := &GoalProgressRow{}
+	err := query.Select("COALESCE(SUM(" + sum + "), 0)::text AS total, COUNT(*) AS tip_count").Scan(row).Error
+	return row, err
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"github.com/patiee/backend/db/model"
+	"gorm.io/gorm"
+)
+
+func (d *Database) CreateMedia(media *model.Media) error {
+	return d.conn.Create(media).Error
+}
+
+// GetMedia returns the user's uploads, newest first
+func (d *Database) GetMedia(userID uint) ([]model.Media, error) {
+	var media []model.Media
+	err := d.conn.Where("user_id = ?", userID).Order("id desc").Find(&media).Error
+	return media, err
+}
+
+// GetMediaTiers returns the user's tiers in the order they were saved
+func (d *Database) GetMediaTiers(userID uint) ([]model.MediaTier, error) {
+	var tiers []model.MediaTier
+	err := d.conn.Where("user_id = ?", userID).Order("id asc").Find(&tiers).Error
+	return tiers, err
+}
+
+// ReplaceMediaTiers swaps all of the user's tiers for tiers
+func (d *Database) ReplaceMediaTiers(userID uint, tiers []model.MediaTier) error {
+	return d.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.MediaTier{}).Error; err != nil {
+			return err
+		}
+		if len(tiers) == 0 {
+			return nil
+		}
+		return tx.Create(&tiers).Error
+	})
+}
+
+func (d *Database) CountMedia(userID uint) (int64, error) {
+	var count int64
+	err := d.conn.Model(&model.Media{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// DeleteMedia removes the user's upload and drops it from their tiers. Tiers
+// left without a sound or media are deleted.
+func (d *Database) DeleteMedia(userID, mediaID uint) (*model.Media, error) {
+	media := &model.Media{}
+	err := d.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", mediaID, userID).First(media).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(media).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.MediaTier{}).Where("user_id = ? AND sound_url = ?", userID, media.URL).Update("sound_url", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.MediaTier{}).Where("user_id = ? AND media_url = ?", userID, media.URL).Update("media_url", "").Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND sound_url = '' AND media_url = ''", userID).Delete(&model.MediaTier{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return media, nil
+}
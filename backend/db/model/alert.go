@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Alert statuses
+const (
+	AlertStatusQueued   = "queued"   // Waiting to play, or for approval when RequiresApproval
+	AlertStatusApproved = "approved" // Approved by the streamer, waiting to play
+	AlertStatusPlaying  = "playing"  // Shown on the overlay
+	AlertStatusPlayed   = "played"
+	AlertStatusSkipped  = "skipped"
+	AlertStatusRejected = "rejected"
+)
+
+// Alert is a confirmed tip in the streamer's overlay queue. Alerts play one
+// at a time in ID order.
+type Alert struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	UserID           uint       `gorm:"index:idx_alerts_user_status,priority:1;not null" json:"user_id"`
+	TipID            uint       `gorm:"uniqueIndex;not null" json:"tip_id"`
+	Status           string     `gorm:"index:idx_alerts_user_status,priority:2;default:'queued'" json:"status"` // See AlertStatus* constants
+	RequiresApproval bool       `json:"requires_approval"`                                                      // Approval mode was on when queued
+	StartedAt        *time.Time `json:"started_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
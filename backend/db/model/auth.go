@@ -13,7 +13,7 @@ type UsedSignature struct {
 
 // Migrate adds the model to the database
 func AutoMigrateAuth(db *gorm.DB) error {
-	return db.AutoMigrate(&UsedSignature{}, &WalletSession{}, &UserSession{}, &WalletBlacklist{})
+	return db.AutoMigrate(&UsedSignature{}, &WalletSession{}, &UserSession{}, &WalletBlacklist{}, &UsedRefreshToken{}, &OAuthState{}, &AuthCode{}, &WalletNonce{})
 }
 
 type WalletBlacklist struct {
@@ -31,10 +31,58 @@ type WalletSession struct {
 	CreatedAt     time.Time `json:"created_at"`
 }
 
-type UserSession struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	UserID    uint      `gorm:"index;not null" json:"user_id"`
-	Token     string    `gorm:"uniqueIndex;not null" json:"token"`
+// WalletNonce is a server issued nonce for a Sign-In With Ethereum message.
+// Each is single use and short lived.
+type WalletNonce struct {
+	Nonce     string    `gorm:"primaryKey" json:"nonce"`
 	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// OAuthState is a pending provider login or link. Each state is random,
+// single use and short lived.
+type OAuthState struct {
+	StateHash    string    `gorm:"primaryKey" json:"-"` // SHA-256 of the state sent to the provider
+	Provider     string    `gorm:"not null" json:"provider"`
+	CodeVerifier string    `json:"-"`             // PKCE, empty for providers without it
+	LinkUserID   uint      `json:"link_user_id"`  // Links the provider to this user instead of logging in
+	RedirectPath string    `json:"redirect_path"` // Frontend path to return to
+	ExpiresAt    time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AuthCode is a one-time code the OAuth callback hands to the frontend in
+// place of a token. It is exchanged for a session token when UserID is set,
+// otherwise for a signup token of the Signup* provider account.
+type AuthCode struct {
+	CodeHash         string    `gorm:"primaryKey" json:"-"` // SHA-256 of the code
+	UserID           uint      `json:"user_id"`
+	SignupProvider   string    `json:"signup_provider"`
+	SignupProviderID string    `json:"signup_provider_id"`
+	SignupEmail      string    `json:"signup_email"`
+	SignupAvatarURL  string    `json:"signup_avatar_url"`
+	ExpiresAt        time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// UserSession is a signed in device. Its refresh token rotates on every use,
+// the access tokens issued for it carry its ID and stop working once it's
+// deleted.
+type UserSession struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"index;not null" json:"user_id"`
+	Token      string    `gorm:"uniqueIndex;not null" json:"-"`    // SHA-256 of the current refresh token
+	IP         string    `json:"ip"`                               // At sign in
+	UserAgent  string    `json:"user_agent"`                       // At sign in
+	LastSeenAt time.Time `json:"last_seen_at"`                     // Updated at most every few minutes
+	ExpiresAt  time.Time `gorm:"index;not null" json:"expires_at"` // Extended on refresh
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UsedRefreshToken is a refresh token that was already rotated. Presenting
+// it again means it leaked, and its session is revoked.
+type UsedRefreshToken struct {
+	TokenHash string    `gorm:"primaryKey" json:"-"`
+	SessionID uint      `gorm:"index;not null" json:"session_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// GoalAssetUSD is the TargetAsset of goals counted in fiat, from the tips' USD value
+const GoalAssetUSD = "USD"
+
+// Goal is a streamer's donation goal. Progress is the sum of confirmed tips
+// in TargetAsset received between StartAt and EndAt.
+type Goal struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"index;not null" json:"user_id"`
+	Title        string     `json:"title"`
+	TargetAmount string     `json:"target_amount"`                     // Decimal string
+	TargetAsset  string     `gorm:"default:'USD'" json:"target_asset"` // GoalAssetUSD or an asset symbol
+	StartAt      time.Time  `json:"start_at"`
+	EndAt        *time.Time `json:"end_at"` // Open ended if nil
+	Active       bool       `json:"active"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// Media kinds
+const (
+	MediaKindAudio     = "audio"     // Alert sound: mp3, ogg or wav
+	MediaKindAnimation = "animation" // Shown in the alert: gif or webp
+)
+
+// Media is a file a streamer uploaded for their alerts
+type Media struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"index;not null" json:"user_id"`
+	Kind        string    `gorm:"not null" json:"kind"` // See MediaKind* constants
+	Name        string    `json:"name"`                 // Original filename
+	URL         string    `gorm:"not null" json:"url"`
+	ContentType string    `json:"content_type"` // Sniffed from the content
+	Size        int64     `json:"size"`
+	DurationMs  int       `json:"duration_ms"` // 0 for still images
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// MediaTier picks the alert sound and media of tips whose USD value is in
+// [MinUSD, MaxUSD). An empty MaxUSD has no upper bound.
+type MediaTier struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	MinUSD    string    `json:"min_usd"` // Decimal string
+	MaxUSD    string    `json:"max_usd"`
+	SoundURL  string    `json:"sound_url"`
+	MediaURL  string    `json:"media_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
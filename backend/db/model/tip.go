@@ -1,24 +1,49 @@
 package model
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// Tip statuses
+const (
+	TipStatusPending   = "pending"   // Submitted, tx not seen on-chain yet
+	TipStatusSeen      = "seen"      // Tx included and verified, waiting for finality
+	TipStatusConfirmed = "confirmed" // Final per the chain's confirmation policy
+	TipStatusFailed    = "failed"
+
+	// Cross-chain tips
+	TipStatusBridging  = "bridging"  // Source tx verified, bridge transfer in flight
+	TipStatusDelivered = "delivered" // Bridge delivered on DestChain, verifying the receipt
+	TipStatusRefunded  = "refunded"  // Bridge refunded the sender
+)
+
 type Tip struct {
-	gorm.Model
-	StreamerID    string `json:"streamer_id" gorm:"index"` // The username of the streamer receiving the tip
-	Sender        string `json:"sender"`
-	Message       string `json:"message"`
-	Amount        string `json:"amount"`
-	Asset         string `json:"asset"`        // e.g. "ETH", "USDC"
-	TxHash        string `json:"tx_hash"`      // Chain tx hash
-	ChainID       string `json:"chain_id"`     // Chain ID where tip happened
-	SourceChain   string `json:"source_chain"` // Human readable or ChainID
-	DestChain     string `json:"dest_chain"`
-	SourceAddress string `json:"source_address"` // Sender wallet
-	DestAddress   string `json:"dest_address"`   // Streamer wallet (on that chain)
-	AvatarURL     string `json:"avatar_url"`     // ENS Avatar or other source
-	BackgroundURL string `json:"background_url"` // ENS Background or other source
-	TwitterHandle string `json:"twitter_handle"`
-	Status        string `json:"status" gorm:"default:'pending'"` // pending, confirmed, failed
+	ID              uint      `gorm:"primarykey"`
+	CreatedAt       time.Time `gorm:"index:idx_tips_streamer_status_created,priority:3"` // Stats filter on (streamer_id, status, created_at)
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+	StreamerID      string         `json:"streamer_id" gorm:"index;index:idx_tips_streamer_status_created,priority:1"` // The username of the streamer receiving the tip
+	Sender          string         `json:"sender"`
+	Message         string         `json:"message"`          // As shown on stream, after the streamer's message filter
+	OriginalMessage string         `json:"original_message"` // As sent, only set when the filter changed it
+	Amount          string         `json:"amount"`
+	Asset           string         `json:"asset"`                                                                             // e.g. "ETH", "USDC"
+	USDValue        string         `json:"usd_value"`                                                                         // USD value at confirmation time, empty if unpriced
+	TxHash          string         `json:"tx_hash" gorm:"uniqueIndex:idx_tips_chain_tx,priority:2,where:status <> 'failed'"`  // Chain tx hash, a tx can only be tipped once unless that tip failed
+	ChainID         string         `json:"chain_id" gorm:"uniqueIndex:idx_tips_chain_tx,priority:1,where:status <> 'failed'"` // Chain ID where tip happened
+	SourceChain     string         `json:"source_chain"`                                                                      // Human readable or ChainID
+	DestChain       string         `json:"dest_chain" gorm:"uniqueIndex:idx_tips_dest_tx,priority:1,where:dest_tx_hash <> '' AND status <> 'failed'"`
+	SourceAddress   string         `json:"source_address"`                                                                                              // Sender wallet
+	DestAddress     string         `json:"dest_address"`                                                                                                // Streamer wallet (on that chain)
+	DestTxHash      string         `json:"dest_tx_hash" gorm:"uniqueIndex:idx_tips_dest_tx,priority:2,where:dest_tx_hash <> '' AND status <> 'failed'"` // Bridge delivery tx on DestChain
+	DestAmount      string         `json:"dest_amount"`                                                                                                 // Received on DestChain after bridge fees, Amount / Asset stay what the sender paid
+	DestAsset       string         `json:"dest_asset"`                                                                                                  // Received on DestChain, by symbol or token address
+	AvatarURL       string         `json:"avatar_url"`                                                                                                  // ENS Avatar or other source
+	BackgroundURL   string         `json:"background_url"`                                                                                              // ENS Background or other source
+	TwitterHandle   string         `json:"twitter_handle"`
+	Status          string         `json:"status" gorm:"default:'pending';index:idx_tips_streamer_status_created,priority:2"` // See TipStatus* constants
+
+	TTSAudioURL string `json:"tts_audio_url"` // Message rendered by the server's TTS engine, empty if not rendered
 }
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// VerificationJob is a durable work item for checking a pending tip on-chain.
+// Workers lease jobs so a crashed or redeployed instance doesn't lose them.
+type VerificationJob struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	TipID       uint       `gorm:"uniqueIndex;not null" json:"tip_id"`
+	Attempts    int        `gorm:"default:0" json:"attempts"`
+	NextRunAt   time.Time  `gorm:"index;not null" json:"next_run_at"`
+	LeaseOwner  string     `json:"lease_owner"`
+	LeasedUntil *time.Time `gorm:"index" json:"leased_until"`
+	LastError   string     `json:"last_error"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed" // Gave up after the last attempt
+)
+
+// Webhook is an endpoint a streamer registered to receive tip events
+type Webhook struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	URL       string    `gorm:"not null" json:"url"`
+	Secret    string    `gorm:"not null" json:"secret"` // HMAC key of the payload signatures
+	Events    string    `json:"events"`                 // Comma separated event types
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is one event sent to one webhook. It is both the durable
+// retry queue and the delivery log; workers lease due pending rows.
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	WebhookID      uint       `gorm:"index;not null" json:"webhook_id"`
+	EventID        string     `gorm:"index;not null" json:"event_id"` // Shared by the deliveries of one event
+	Event          string     `gorm:"not null" json:"event"`
+	Payload        string     `gorm:"type:text" json:"payload"`
+	Status         string     `gorm:"index;not null" json:"status"` // See WebhookDelivery* constants
+	Attempts       int        `gorm:"default:0" json:"attempts"`
+	NextRunAt      time.Time  `gorm:"index;not null" json:"next_run_at"`
+	LeaseOwner     string     `json:"lease_owner"`
+	LeasedUntil    *time.Time `gorm:"index" json:"leased_until"`
+	ResponseStatus int        `json:"response_status"` // HTTP status of the last attempt, 0 if there was no response
+	LastError      string     `json:"last_error"`
+	DurationMs     int64      `json:"duration_ms"` // Of the last attempt
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
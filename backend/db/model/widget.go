@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Widget types
+const (
+	WidgetTypeAlert = "alert" // Tip alert box
+	WidgetTypeGoal  = "goal"  // Donation goal bar
+)
+
+// Widget is one of a streamer's overlays. Its private token authenticates the
+// overlay's config request and WebSocket.
+type Widget struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        uint      `gorm:"index;not null" json:"user_id"`
+	Type          string    `gorm:"not null" json:"type"` // See WidgetType* constants
+	Name          string    `json:"name"`
+	Token         string    `gorm:"uniqueIndex;not null" json:"token"`
+	Config        string    `gorm:"type:text" json:"config"` // JSON, see server/model.WidgetConfig
+	Enabled       bool      `json:"enabled"`
+	Subscriptions string    `json:"subscriptions"` // Comma separated event types, empty for the type's defaults
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
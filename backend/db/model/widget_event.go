@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// WidgetEvent is a notification sent to a streamer's widgets. The ID is
+// monotonic so reconnecting widgets can ask for everything after the last
+// event they saw.
+type WidgetEvent struct {
+	ID        uint64    `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index:idx_widget_events_user_type,priority:1;not null" json:"user_id"`
+	Type      string    `gorm:"index:idx_widget_events_user_type,priority:2" json:"type"` // e.g. TIP, TIP_SEEN
+	TipID     uint      `gorm:"index" json:"tip_id"`
+	Payload   string    `gorm:"type:text" json:"payload"` // JSON notification as sent
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
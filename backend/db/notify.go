@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Notify sends a Postgres NOTIFY with payload on channel
+func (d *Database) Notify(channel, payload string) error {
+	return d.conn.Exec("SELECT pg_notify(?, ?)", channel, payload).Error
+}
+
+// Listen blocks on a dedicated connection, passing every notification on
+// channel to handle. It returns when ctx is done or the connection fails.
+func (d *Database) Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	conn, err := pgx.Connect(ctx, d.dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open listen connection: %v", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", channel, err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle(notification.Payload)
+	}
+}
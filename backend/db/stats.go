@@ -0,0 +1,107 @@
+package db
+
+import (
+	"time"
+
+	"github.com/patiee/backend/db/model"
+	"gorm.io/gorm"
+)
+
+// tipAmountSQL and tipUSDValueSQL cast the decimal string columns to numeric,
+// treating empty or malformed values as 0.
+// They must not contain '?' as they are used in queries with bind arguments.
+const (
+	tipAmountSQL   = `(CASE WHEN amount ~ '^[0-9]+(\.[0-9]+){0,1}$' THEN amount::numeric ELSE 0 END)`
+	tipUSDValueSQL = `(CASE WHEN usd_value ~ '^[0-9]+(\.[0-9]+){0,1}$' THEN usd_value::numeric ELSE 0 END)`
+)
+
+// StatsFilter limits stats to a streamer's confirmed tips within [From, To)
+type StatsFilter struct {
+	StreamerID string
+	From       *time.Time
+	To         *time.Time
+}
+
+type AssetTotalRow struct {
+	Asset    string
+	ChainID  string
+	Count    int64
+	Total    string
+	Average  string
+	TotalUSD string
+}
+
+type TimeSeriesRow struct {
+	Bucket   time.Time
+	Asset    string
+	Count    int64
+	Total    string
+	TotalUSD string
+}
+
+type TopSenderRow struct {
+	SourceAddress string
+	Sender        string
+	Asset         string
+	Count         int64
+	Total         string
+}
+
+func (d *Database) confirmedTips(f StatsFilter) *gorm.DB {
+	query := d.conn.Model(&model.Tip{}).
+		Where("streamer_id = ? AND status = ?", f.StreamerID, model.TipStatusConfirmed)
+	if f.From != nil {
+		query = query.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("created_at < ?", *f.To)
+	}
+	return query
+}
+
+// GetTipTotals sums confirmed tips per asset and chain
+func (d *Database) GetTipTotals(f StatsFilter) ([]AssetTotalRow, error) {
+	var rows []AssetTotalRow
+	err := d.confirmedTips(f).
+		Select("asset, chain_id, COUNT(*) AS count, SUM(" + tipAmountSQL + ")::text AS total, ROUND(AVG(" + tipAmountSQL + "), 18)::text AS average, SUM(" + tipUSDValueSQL + ")::text AS total_usd").
+		Group("asset, chain_id").
+		Order("count DESC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GetTipTimeSeries sums confirmed tips per asset in "hour" or "day" buckets
+func (d *Database) GetTipTimeSeries(f StatsFilter, interval string) ([]TimeSeriesRow, error) {
+	var rows []TimeSeriesRow
+	err := d.confirmedTips(f).
+		Select("date_trunc(?, created_at) AS bucket, asset, COUNT(*) AS count, SUM("+tipAmountSQL+")::text AS total, SUM("+tipUSDValueSQL+")::text AS total_usd", interval).
+		Group("bucket, asset").
+		Order("bucket, asset").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GetTopSendersByAmount ranks sender wallets by the total sent in asset
+func (d *Database) GetTopSendersByAmount(f StatsFilter, asset string, limit int) ([]TopSenderRow, error) {
+	var rows []TopSenderRow
+	err := d.confirmedTips(f).
+		Where("asset = ?", asset).
+		Select("source_address, MAX(sender) AS sender, asset, COUNT(*) AS count, SUM(" + tipAmountSQL + ")::text AS total").
+		Group("source_address, asset").
+		Order("SUM(" + tipAmountSQL + ") DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GetTopSendersByCount ranks sender wallets by number of tips across all assets
+func (d *Database) GetTopSendersByCount(f StatsFilter, limit int) ([]TopSenderRow, error) {
+	var rows []TopSenderRow
+	err := d.confirmedTips(f).
+		Select("source_address, MAX(sender) AS sender, COUNT(*) AS count").
+		Group("source_address").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/patiee/backend/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (d *Database) CreateWebhook(webhook *model.Webhook) error {
+	return d.conn.Create(webhook).Error
+}
+
+func (d *Database) GetWebhook(userID, webhookID uint) (*model.Webhook, error) {
+	webhook := &model.Webhook{}
+	if err := d.conn.Where("id = ? AND user_id = ?", webhookID, userID).First(webhook).Error; err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (d *Database) GetWebhookByID(webhookID uint) (*model.Webhook, error) {
+	webhook := &model.Webhook{}
+	if err := d.conn.First(webhook, webhookID).Error; err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// GetWebhooks returns the user's webhooks, oldest first
+func (d *Database) GetWebhooks(userID uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	err := d.conn.Where("user_id = ?", userID).Order("id asc").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (d *Database) GetEnabledWebhooks(userID uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	err := d.conn.Where("user_id = ? AND enabled = ?", userID, true).Order("id asc").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (d *Database) CountWebhooks(userID uint) (int64, error) {
+	var count int64
+	err := d.conn.Model(&model.Webhook{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (d *Database) UpdateWebhook(webhook *model.Webhook) error {
+	return d.conn.Model(webhook).
+		Select("url", "secret", "events", "enabled").
+		Updates(webhook).Error
+}
+
+// DeleteWebhook removes the webhook and its delivery log
+func (d *Database) DeleteWebhook(userID, webhookID uint) (bool, error) {
+	var deleted bool
+	err := d.conn.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND user_id = ?", webhookID, userID).Delete(&model.Webhook{})
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted = res.RowsAffected > 0
+		if !deleted {
+			return nil
+		}
+		return tx.Where("webhook_id = ?", webhookID).Delete(&model.WebhookDelivery{}).Error
+	})
+	return deleted, err
+}
+
+func (d *Database) CreateWebhookDeliveries(deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return d.conn.Create(&deliveries).Error
+}
+
+func (d *Database) CreateWebhookDelivery(delivery *model.WebhookDelivery) error {
+	return d.conn.Create(delivery).Error
+}
+
+func (d *Database) GetWebhookDelivery(webhookID, deliveryID uint) (*model.WebhookDelivery, error) {
+	delivery := &model.WebhookDelivery{}
+	if err := d.conn.Where("id = ? AND webhook_id = ?", deliveryID, webhookID).First(delivery).Error; err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// GetWebhookDeliveries returns the webhook's latest deliveries, newest first
+func (d *Database) GetWebhookDeliveries(webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := d.conn.Where("webhook_id = ?", webhookID).Order("id desc").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// LeaseWebhookDelivery claims the next due pending delivery for owner until
+// the lease expires. Returns nil if none is due.
+func (d *Database) LeaseWebhookDelivery(owner string, lease time.Duration) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := d.conn.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_run_at <= ? AND (leased_until IS NULL OR leased_until < ?)", model.WebhookDeliveryPending, now, now).
+			Order("next_run_at").
+			First(&delivery).Error
+		if err != nil {
+			return err
+		}
+
+		leasedUntil := now.Add(lease)
+		delivery.LeaseOwner = owner
+		delivery.LeasedUntil = &leasedUntil
+		return tx.Model(&delivery).Updates(map[string]interface{}{
+			"lease_owner":  owner,
+			"leased_until": leasedUntil,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FinishWebhookAttempt records the outcome of an attempt and releases the lease
+func (d *Database) FinishWebhookAttempt(delivery *model.WebhookDelivery) error {
+	return d.conn.Model(delivery).Updates(map[string]interface{}{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_run_at":     delivery.NextRunAt,
+		"response_status": delivery.ResponseStatus,
+		"last_error":      delivery.LastError,
+		"duration_ms":     delivery.DurationMs,
+		"delivered_at":    delivery.DeliveredAt,
+		"lease_owner":     "",
+		"leased_until":    nil,
+	}).Error
+}
+
+// RequeueWebhookDelivery schedules a finished delivery to be sent again
+func (d *Database) RequeueWebhookDelivery(deliveryID uint) error {
+	return d.conn.Model(&model.WebhookDelivery{}).Where("id = ?", deliveryID).Updates(map[string]interface{}{
+		"status":       model.WebhookDeliveryPending,
+		"attempts":     0,
+		"next_run_at":  time.Now(),
+		"lease_owner":  "",
+		"leased_until": nil,
+	}).Error
+}
+
+// DeleteWebhookDeliveriesBefore trims the delivery log of finished
+// deliveries created before t
+func (d *Database) DeleteWebhookDeliveriesBefore(t time.Time) (int64, error) {
+	res := d.conn.Where("status <> ? AND created_at < ?", model.WebhookDeliveryPending, t).Delete(&model.WebhookDelivery{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"github.com/google/uuid"
+	"github.com/patiee/backend/db/model"
+	"gorm.io/gorm"
+)
+
+// CreateWidget stores the widget with a new private token
+func (d *Database) CreateWidget(widget *model.Widget) error {
+	widget.Token = uuid.New().String()
+	return d.conn.Create(widget).Error
+}
+
+func (d *Database) GetWidget(userID, widgetID uint) (*model.Widget, error) {
+	widget := &model.Widget{}
+	if err := d.conn.Where("id = ? AND user_id = ?", widgetID, userID).First(widget).Error; err != nil {
+		return nil, err
+	}
+	return widget, nil
+}
+
+func (d *Database) GetWidgetByToken(token string) (*model.Widget, error) {
+	widget := &model.Widget{}
+	if err := d.conn.Where("token = ?", token).First(widget).Error; err != nil {
+		return nil, err
+	}
+	return widget, nil
+}
+
+// GetWidgets returns the user's widgets, oldest first
+func (d *Database) GetWidgets(userID uint) ([]model.Widget, error) {
+	var widgets []model.Widget
+	err := d.conn.Where("user_id = ?", userID).Order("id asc").Find(&widgets).Error
+	return widgets, err
+}
+
+// GetFirstWidget returns the user's oldest widget of widgetType
+func (d *Database) GetFirstWidget(userID uint, widgetType string) (*model.Widget, error) {
+	widget := &model.Widget{}
+	if err := d.conn.Where("user_id = ? AND type = ?", userID, widgetType).Order("id asc").First(widget).Error; err != nil {
+		return nil, err
+	}
+	return widget, nil
+}
+
+func (d *Database) UpdateWidget(widget *model.Widget) error {
+	return d.conn.Model(widget).
+		Select("name", "config", "enabled", "subscriptions").
+		Updates(widget).Error
+}
+
+func (d *Database) DeleteWidget(userID, widgetID uint) (bool, error) {
+	res := d.conn.Where("id = ? AND user_id = ?", widgetID, userID).Delete(&model.Widget{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (d *Database) RefreshWidgetToken(userID, widgetID uint) (string, error) {
+	newToken := uuid.New().String()
+	res := d.conn.Model(&model.Widget{}).Where("id = ? AND user_id = ?", widgetID, userID).Update("token", newToken)
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if res.RowsAffected == 0 {
+		return "", gorm.ErrRecordNotFound
+	}
+	return newToken, nil
+}
+
+// LegacyWidgetSettings are the widget columns users had before the widgets table
+type LegacyWidgetSettings struct {
+	UserID             uint
+	WidgetToken        string
+	WidgetTTS          bool
+	WidgetBgColor      string
+	WidgetUserColor    string
+	WidgetAmountColor  string
+	WidgetMessageColor string
+}
+
+// GetLegacyWidgetSettings returns the old widget columns of users that have
+// no widget yet, or nothing if the columns don't exist
+func (d *Database) GetLegacyWidgetSettings() ([]LegacyWidgetSettings, error) {
+	if !d.conn.Migrator().HasColumn(&model.User{}, "widget_token") {
+		return nil, nil
+	}
+
+	var rows []LegacyWidgetSettings
+	err := d.conn.Raw(`SELECT u.id AS user_id, COALESCE(u.widget_token, '') AS widget_token, COALESCE(u.widget_tts, false) AS widget_tts,
+			COALESCE(u.widget_bg_color, '') AS widget_bg_color, COALESCE(u.widget_user_color, '') AS widget_user_color,
+			COALESCE(u.widget_amount_color, '') AS widget_amount_color, COALESCE(u.widget_message_color, '') AS widget_message_color
+		FROM users u
+		WHERE NOT EXISTS (SELECT 1 FROM widgets w WHERE w.user_id = u.id)`).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// CreateWidgetWithToken stores a widget keeping its existing token, so
+// migrated overlay URLs keep working
+func (d *Database) CreateWidgetWithToken(widget *model.Widget) error {
+	if widget.Token == "" {
+		widget.Token = uuid.New().String()
+	}
+	return d.conn.Create(widget).Error
+}
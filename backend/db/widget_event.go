@@ -0,0 +1,45 @@
+package db
+
+import (
+	"time"
+
+	"github.com/patiee/backend/db/model"
+)
+
+// Widget events are kept this long, except each user's last TIP which
+// "replay last alert" shows again
+const widgetEventRetention = 7 * 24 * time.Hour
+
+func (d *Database) CreateWidgetEvent(event *model.WidgetEvent) error {
+	return d.conn.Create(event).Error
+}
+
+func (d *Database) GetWidgetEvent(id uint64) (*model.WidgetEvent, error) {
+	event := &model.WidgetEvent{}
+	if err := d.conn.First(event, id).Error; err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// GetWidgetEventsSince returns userID's events of type with ID > sinceID
+// created after notBefore, oldest first
+func (d *Database) GetWidgetEventsSince(userID uint, eventType string, sinceID uint64, notBefore time.Time, limit int) ([]model.WidgetEvent, error) {
+	var events []model.WidgetEvent
+	err := d.conn.
+		Where("user_id = ? AND type = ? AND id > ? AND created_at >= ?", userID, eventType, sinceID, notBefore).
+		Order("id asc").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// GetLastWidgetEvent returns userID's most recent event of type
+func (d *Database) GetLastWidgetEvent(userID uint, eventType string) (*model.WidgetEvent, error) {
+	event := &model.WidgetEvent{}
+	err := d.conn.Where("user_id = ? AND type = ?", userID, eventType).Order("id desc").First(event).Error
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
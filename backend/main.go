@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"log"
+	"math/big"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/patiee/backend/db"
@@ -53,6 +55,29 @@ func main() {
 		backendURL = "https://localhost:8080"
 	}
 
+	// Confirmation depth overrides, e.g. "1=finalized,8453=20,bitcoin=6"
+	confirmationPolicies, err := server.ParseConfirmationPolicies(os.Getenv("CONFIRMATION_POLICIES"))
+	if err != nil {
+		logger.Fatalf("Invalid CONFIRMATION_POLICIES: %v", err)
+	}
+
+	// Reverse proxies allowed to set the client IP, e.g. "10.0.0.0/8,127.0.0.1"
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+
+	// Fixed USD prices for offline use, otherwise prices come from PRICE_API_URL
+	var staticPrices map[string]*big.Rat
+	if priceFile := os.Getenv("PRICE_FILE"); priceFile != "" {
+		staticPrices, err = server.LoadPriceFile(priceFile)
+		if err != nil {
+			logger.Fatalf("Invalid PRICE_FILE: %v", err)
+		}
+	}
+
 	config := server.Config{
 		GoogleClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
 		GoogleClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
@@ -64,6 +89,7 @@ func main() {
 		CertFile:           os.Getenv("CERT_FILE"),
 		KeyFile:            os.Getenv("KEY_FILE"),
 		CORSEnabled:        os.Getenv("CORS_ENABLED") == "true",
+		TrustedProxies:     trustedProxies,
 		MinIOEndpoint:      os.Getenv("MINIO_ENDPOINT"),
 		MinIOAccessKeyID:   os.Getenv("MINIO_ACCESS_KEY"),
 		MinIOSecretKey:     os.Getenv("MINIO_SECRET_KEY"),
@@ -71,6 +97,20 @@ func main() {
 		EthRPCURL:          os.Getenv("ETH_RPC_URL"),
 		FrontendURL:        frontendURL,
 		BackendURL:         backendURL,
+
+		LifiAPIURL:           os.Getenv("LIFI_API_URL"),
+		LifiAPIKey:           os.Getenv("LIFI_API_KEY"),
+		ConfirmationPolicies: confirmationPolicies,
+		Broadcaster:          os.Getenv("BROADCASTER"),
+		PriceAPIURL:          os.Getenv("PRICE_API_URL"),
+		PriceAPIKey:          os.Getenv("PRICE_API_KEY"),
+		StaticPrices:         staticPrices,
+		TTSCommand:           os.Getenv("TTS_COMMAND"),
+		TwitchBotUsername:    os.Getenv("TWITCH_BOT_USERNAME"),
+		TwitchBotToken:       os.Getenv("TWITCH_BOT_TOKEN"),
+		TwitchIRCURL:         os.Getenv("TWITCH_IRC_URL"),
+
+		WebhookAllowPrivateNetworks: os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true",
 	}
 
 	// Init and Start Server
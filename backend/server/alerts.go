@@ -0,0 +1,349 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+)
+
+const (
+	alertMaxPlayTime = 90 * time.Second // Fallback when the overlay never reports ALERT_DONE
+	alertQueuePoll   = 2 * time.Second
+	alertQueueLimit  = 100
+)
+
+var ErrAlertNotFound = errors.New("alert not found or already handled")
+
+// EnqueueAlert adds a confirmed tip to the streamer's alert queue
+func (s *Service) EnqueueAlert(tip *dbmodel.Tip) {
+	user, err := s.db.GetUserByUsername(tip.StreamerID)
+	if err != nil {
+		s.logger.Printf("Failed to find streamer %s: %v", tip.StreamerID, err)
+		return
+	}
+
+	alert := &dbmodel.Alert{
+		UserID:           user.ID,
+		TipID:            tip.ID,
+		Status:           dbmodel.AlertStatusQueued,
+		RequiresApproval: user.AlertsRequireApproval,
+	}
+	if err := s.db.CreateAlert(alert); err != nil {
+		s.logger.Printf("Failed to queue alert for tip %d: %v", tip.ID, err)
+		return
+	}
+
+	s.advanceAlertQueue(user.ID)
+}
+
+// StartAlertQueue periodically plays queued alerts and expires stuck ones.
+// Queue changes also advance immediately, the poll covers other replicas.
+func (s *Service) StartAlertQueue(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(alertQueuePoll)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				userIDs, err := s.db.GetUsersWithActiveAlerts()
+				if err != nil {
+					s.logger.Printf("Failed to list alert queues: %v", err)
+					continue
+				}
+				for _, userID := range userIDs {
+					s.advanceAlertQueue(userID)
+				}
+			}
+		}
+	}()
+}
+
+// advanceAlertQueue starts the next alert if the overlay is free, then
+// broadcasts the queue state
+func (s *Service) advanceAlertQueue(userID uint) {
+	alert, err := s.db.StartNextAlert(userID, alertMaxPlayTime)
+	if err != nil {
+		s.logger.Printf("Failed to advance alert queue of user %d: %v", userID, err)
+		return
+	}
+	if alert == nil {
+		return
+	}
+
+	tip, err := s.db.GetTipByID(alert.TipID)
+	if err != nil {
+		s.logger.Printf("Failed to load tip %d for alert %d: %v", alert.TipID, alert.ID, err)
+		return
+	}
+	s.notifyTipAlert(tip, "TIP", alert.ID)
+	s.broadcastAlertQueue(userID)
+}
+
+// broadcastAlertQueue tells widgets and dashboards the queue changed
+func (s *Service) broadcastAlertQueue(userID uint) {
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		s.logger.Printf("Failed to find user %d: %v", userID, err)
+		return
+	}
+	summary, err := s.db.GetAlertQueueSummary(userID)
+	if err != nil {
+		s.logger.Printf("Failed to summarise alert queue of user %d: %v", userID, err)
+		return
+	}
+
+	s.publishWidgetEvent(userID, model.AlertQueueNotification{
+		Type:             "ALERT_QUEUE",
+		Paused:           user.AlertsPaused,
+		RequireApproval:  user.AlertsRequireApproval,
+		PlayingAlertID:   summary.PlayingID,
+		Queued:           summary.Waiting,
+		AwaitingApproval: summary.AwaitingApproval,
+	})
+}
+
+func (s *Service) GetAlertQueue(userID uint) (*model.AlertQueueResponse, error) {
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := s.db.GetActiveAlerts(userID, alertQueueLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	tipIDs := make([]uint, 0, len(alerts))
+	for _, a := range alerts {
+		tipIDs = append(tipIDs, a.TipID)
+	}
+	tips, err := s.db.GetTipsByIDs(tipIDs)
+	if err != nil {
+		return nil, err
+	}
+	tipsByID := make(map[uint]dbmodel.Tip, len(tips))
+	for _, t := range tips {
+		tipsByID[t.ID] = t
+	}
+
+	resp := &model.AlertQueueResponse{
+		Paused:          user.AlertsPaused,
+		RequireApproval: user.AlertsRequireApproval,
+		Alerts:          make([]model.AlertItem, 0, len(alerts)),
+	}
+	for _, a := range alerts {
+		t := tipsByID[a.TipID]
+		resp.Alerts = append(resp.Alerts, model.AlertItem{
+			ID:               a.ID,
+			TipID:            a.TipID,
+			Status:           a.Status,
+			RequiresApproval: a.RequiresApproval,
+			Sender:           t.Sender,
+			Message:          t.Message,
+			OriginalMessage:  t.OriginalMessage,
+			Amount:           t.Amount,
+			Asset:            t.Asset,
+			USDValue:         t.USDValue,
+			CreatedAt:        a.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return resp, nil
+}
+
+func (s *Service) SetAlertsPaused(userID uint, paused bool) error {
+	if err := s.db.SetAlertsPaused(userID, paused); err != nil {
+		return err
+	}
+	s.broadcastAlertQueue(userID)
+	if !paused {
+		s.advanceAlertQueue(userID)
+	}
+	return nil
+}
+
+func (s *Service) SetAlertsRequireApproval(userID uint, required bool) error {
+	if err := s.db.SetAlertsRequireApproval(userID, required); err != nil {
+		return err
+	}
+	s.broadcastAlertQueue(userID)
+	if !required {
+		s.advanceAlertQueue(userID)
+	}
+	return nil
+}
+
+// SkipAlert skips a waiting alert, or stops it on the overlay if it is
+// playing. alertID 0 skips the playing alert.
+func (s *Service) SkipAlert(userID, alertID uint) error {
+	if alertID == 0 {
+		summary, err := s.db.GetAlertQueueSummary(userID)
+		if err != nil {
+			return err
+		}
+		alertID = summary.PlayingID
+	}
+
+	ok, err := s.db.TransitionAlert(userID, alertID,
+		[]string{dbmodel.AlertStatusQueued, dbmodel.AlertStatusApproved, dbmodel.AlertStatusPlaying}, dbmodel.AlertStatusSkipped)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAlertNotFound
+	}
+
+	s.publishWidgetEvent(userID, model.AlertSkipNotification{Type: "ALERT_SKIP", AlertID: alertID})
+	s.broadcastAlertQueue(userID)
+	s.advanceAlertQueue(userID)
+	return nil
+}
+
+func (s *Service) ApproveAlert(userID, alertID uint) error {
+	return s.moderateAlert(userID, alertID, dbmodel.AlertStatusApproved)
+}
+
+func (s *Service) RejectAlert(userID, alertID uint) error {
+	return s.moderateAlert(userID, alertID, dbmodel.AlertStatusRejected)
+}
+
+func (s *Service) moderateAlert(userID, alertID uint, status string) error {
+	ok, err := s.db.TransitionAlert(userID, alertID, []string{dbmodel.AlertStatusQueued}, status)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAlertNotFound
+	}
+
+	s.broadcastAlertQueue(userID)
+	s.advanceAlertQueue(userID)
+	return nil
+}
+
+// FinishAlert marks the playing alert as played once the overlay showed it
+func (s *Service) FinishAlert(userID, alertID uint) error {
+	ok, err := s.db.TransitionAlert(userID, alertID, []string{dbmodel.AlertStatusPlaying}, dbmodel.AlertStatusPlayed)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// Another overlay of the same streamer already reported it
+		return nil
+	}
+
+	s.broadcastAlertQueue(userID)
+	s.advanceAlertQueue(userID)
+	return nil
+}
+
+// handleWidgetCommand applies a command sent over a widget WebSocket.
+// Widget tokens end up in OBS scenes and stream tooling, so overlays can
+// only report alerts as played; moderating the queue takes a session.
+func (s *Service) handleWidgetCommand(c *wsClient, data []byte) {
+	var cmd model.WidgetCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return
+	}
+	if cmd.Type != "ALERT_DONE" {
+		return
+	}
+
+	if err := s.FinishAlert(c.userID, cmd.AlertID); err != nil {
+		s.logger.Printf("Failed to handle %s from user %d: %v", cmd.Type, c.userID, err)
+	}
+}
+
+func (s *Server) HandleGetAlertQueue(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	queue, err := s.service.GetAlertQueue(claims.UserID)
+	if err != nil {
+		s.logger.Printf("Failed to fetch alert queue: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alert queue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+func (s *Server) HandleUpdateAlertSettings(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	var req model.AlertSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := s.service.SetAlertsRequireApproval(claims.UserID, req.RequireApproval); err != nil {
+		s.logger.Printf("Failed to update alert settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update alert settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert settings updated"})
+}
+
+func (s *Server) HandlePauseAlerts(c *gin.Context) {
+	s.handleAlertAction(c, func(userID, _ uint) error { return s.service.SetAlertsPaused(userID, true) })
+}
+
+func (s *Server) HandleResumeAlerts(c *gin.Context) {
+	s.handleAlertAction(c, func(userID, _ uint) error { return s.service.SetAlertsPaused(userID, false) })
+}
+
+// HandleSkipAlert skips /alerts/:id/skip, or the playing alert for /alerts/skip
+func (s *Server) HandleSkipAlert(c *gin.Context) {
+	s.handleAlertAction(c, s.service.SkipAlert)
+}
+
+func (s *Server) HandleApproveAlert(c *gin.Context) {
+	s.handleAlertAction(c, s.service.ApproveAlert)
+}
+
+func (s *Server) HandleRejectAlert(c *gin.Context) {
+	s.handleAlertAction(c, s.service.RejectAlert)
+}
+
+// handleAlertAction authenticates the streamer and applies action to the :id alert (0 if absent)
+func (s *Server) handleAlertAction(c *gin.Context, action func(userID, alertID uint) error) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	var alertID uint64
+	if id := c.Param("id"); id != "" {
+		var err error
+		if alertID, err = strconv.ParseUint(id, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+			return
+		}
+	}
+
+	if err := action(claims.UserID, uint(alertID)); err != nil {
+		if errors.Is(err, ErrAlertNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.Printf("Failed to update alert queue: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update alert queue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert queue updated"})
+}
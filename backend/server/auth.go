@@ -5,237 +5,188 @@ import (
 	"crypto/ed25519"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gin-gonic/gin"
 	"github.com/mr-tron/base58"
+	dbmodel "github.com/patiee/backend/db/model"
 	"github.com/patiee/backend/server/model"
 	"golang.org/x/oauth2"
 )
 
-func (s *Service) HandleOAuthLogin(c *gin.Context, provider string) {
-	var config *oauth2.Config
-
+// oauthConfigFor returns the OAuth client of provider, nil if unknown
+func oauthConfigFor(provider string) *oauth2.Config {
 	switch provider {
 	case "google":
-		config = googleConfig
+		return googleConfig
 	case "twitch":
-		config = twitchConfig
+		return twitchConfig
 	case "tiktok":
-		config = tiktokConfig
-	default:
+		return tiktokConfig
+	}
+	return nil
+}
+
+// HandleOAuthLogin starts a provider login. ?redirect= is the frontend path
+// to return to afterwards.
+func (s *Service) HandleOAuthLogin(c *gin.Context, provider string) {
+	if oauthConfigFor(provider) == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid provider"})
 		return
 	}
+	s.startOAuthFlow(c, provider, 0, safeRedirectPath(c.Query("redirect"), ""))
+}
 
-	// Check for "link" query param
-	linkMode := c.Query("link") == "true"
-	var state string = oauthState
-
-	if linkMode {
-		authHeader := c.GetHeader("Authorization")
-		if len(authHeader) < 8 || authHeader[:7] != "Bearer " {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing token for linking"})
-			return
-		}
-		tokenString := authHeader[7:]
-		// Validate Token to get UserID
-		claims, err := s.ValidateSessionToken(tokenString) // Ensure ValidateSessionToken is available or replicate logic?
-		// ValidateSessionToken is in auth.go? No, likely in server.go or helpers.
-		// Wait, ValidateSessionToken is likely a method of Service if used elsewhere?
-		// I see `s.service.ValidateSessionToken` in `server.go` handlers. So it IS a method of `Service`.
-		// BUT `HandleOAuthLogin` IS a method of `Service` receiver. So `s.ValidateSessionToken` should work IF it's defined on Service.
-		// Let's assume it is.
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			return
-		}
-
-		// Generate Signed State
-		state, err = s.GenerateLinkState(claims.UserID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate state"})
-			return
-		}
+// HandleOAuthLink starts linking the provider to the user whose session token
+// is in the posted form. The frontend submits the form as a top level
+// navigation so the state cookie is set in the user's browser.
+func (s *Service) HandleOAuthLink(c *gin.Context, provider string) {
+	if oauthConfigFor(provider) == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid provider"})
+		return
 	}
 
-	var authURLOptions []oauth2.AuthCodeOption
-	if provider == "google" {
-		authURLOptions = append(authURLOptions, oauth2.SetAuthURLParam("prompt", "select_account"))
+	claims, err := s.ValidateSessionToken(c.PostForm("token"))
+	if err != nil {
+		c.Redirect(http.StatusSeeOther, s.frontendURL("/auth", url.Values{"error": {"session_expired"}}))
+		return
 	}
+	s.startOAuthFlow(c, provider, claims.UserID, safeRedirectPath(c.PostForm("redirect"), "/me/settings"))
+}
 
-	url := config.AuthCodeURL(state, authURLOptions...)
-	// Return JSON URL if linking (for frontend redirect), or Redirect if standard login?
-	// Frontend "Connect" button currently redirects window.location.
-	// But if we need headers, frontend should fetch JSON then redirect.
-	// OR: Frontend uses `window.location = /auth/google/login?link=true&token=...` (Bad practice to put token in URL).
-	// BEST: Frontend calls `GET /auth/google/link` -> Returns `{url: ...}` -> Frontend redirects.
-	// Logic to separate "Login" (Redirect) and "Link" (JSON URL)?
-	if linkMode {
-		c.JSON(http.StatusOK, gin.H{"url": url})
+// startOAuthFlow redirects to the provider with a new state, bound to this
+// browser by a cookie so a callback URL started by someone else is rejected.
+// Otherwise an attacker could log the user into the attacker's account, or
+// link the user's provider account to it.
+func (s *Service) startOAuthFlow(c *gin.Context, provider string, linkUserID uint, redirectPath string) {
+	state, authURLOptions, err := s.newOAuthState(provider, linkUserID, redirectPath)
+	if err != nil {
+		s.logger.Printf("Failed to start %s login: %v", provider, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate state"})
 		return
 	}
+	if provider == "google" {
+		authURLOptions = append(authURLOptions, oauth2.SetAuthURLParam("prompt", "select_account"))
+	}
 
-	c.Redirect(http.StatusTemporaryRedirect, url)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, state, int(oauthStateTTL.Seconds()), "/auth", "", s.secureCookies(), true)
+	// See Other turns the link form's POST into a GET
+	c.Redirect(http.StatusSeeOther, oauthConfigFor(provider).AuthCodeURL(state, authURLOptions...))
 }
 
-func (s *Service) GenerateLinkState(userID uint) (string, error) {
-	// Simple JWT generation for State
-	// Import "github.com/golang-jwt/jwt/v5" needed if not already.
-	// Assuming jwt is available or using the same method as Session Token.
-	// Let's reuse GenerateSessionToken logic style?
-	// But state needs to be decipherable.
-	// I'll assume standard JWT signing.
-	// Since I don't see imports, I might need to add them or rely on existing.
-	// Let's use a simpler "action:userID:timestamp:signature" string if imports allow.
-	// Or just use the `jwt` package if already imported.
-	// `auth.go` has `golang.org/x/oauth2`. It does NOT have `jwt`.
-	// `server.go` probably has it.
-	// I should check `server.go` imports or just use HMAC here.
-
-	// Implementation using HMAC SHA256
-	payload := fmt.Sprintf("link:%d:%d", userID, time.Now().Unix())
-	h := crypto.Keccak256Hash([]byte(payload + s.config.JWTSecret)) // Use Keccak as we have checks for valid imports
-	return fmt.Sprintf("%s:%s", payload, h.Hex()), nil
+// secureCookies reports whether cookies should be limited to HTTPS
+func (s *Service) secureCookies() bool {
+	return strings.HasPrefix(s.config.BackendURL, "https://")
 }
 
-func (s *Service) ValidateLinkState(state string) (uint, error) {
-	parts := strings.Split(state, ":")
-	if len(parts) != 4 || parts[0] != "link" {
-		return 0, fmt.Errorf("invalid state format")
-	}
-
-	userIDStr := parts[1]
-	timestampStr := parts[2]
-	signature := parts[3]
+func (s *Service) HandleOAuthCallback(c *gin.Context, provider string) {
+	state := c.Query("state")
+	code := c.Query("code")
 
-	payload := fmt.Sprintf("link:%s:%s", userIDStr, timestampStr)
-	h := crypto.Keccak256Hash([]byte(payload + s.config.JWTSecret))
-	if h.Hex() != signature {
-		return 0, fmt.Errorf("invalid state signature")
+	config := oauthConfigFor(provider)
+	if config == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid provider"})
+		return
 	}
 
-	// Check timestamp (15 mins expiration)
-	var timestamp int64
-	if _, err := fmt.Sscanf(timestampStr, "%d", &timestamp); err != nil {
-		return 0, fmt.Errorf("invalid timestamp format")
+	// The state must have been started in this browser
+	cookie, _ := c.Cookie(oauthStateCookie)
+	if cookie != "" {
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(oauthStateCookie, "", -1, "/auth", "", s.secureCookies(), true)
 	}
 
-	if time.Now().Unix() > timestamp+900 {
-		return 0, fmt.Errorf("link request expired")
+	oauthState, err := s.consumeOAuthState(provider, state)
+	if err == nil && cookie != state {
+		err = fmt.Errorf("%w: state was started in another browser", ErrInvalidOAuthState)
 	}
-
-	var userID uint
-	if _, err := fmt.Sscanf(userIDStr, "%d", &userID); err != nil {
-		return 0, fmt.Errorf("invalid user id format")
+	if err != nil {
+		s.logger.Printf("Rejected %s callback: %v", provider, err)
+		c.Redirect(http.StatusTemporaryRedirect, s.frontendURL("/auth", url.Values{"error": {"invalid_state"}}))
+		return
 	}
-	return userID, nil
-}
 
-func (s *Service) HandleOAuthCallback(c *gin.Context, provider string) {
-	state := c.Query("state")
-	code := c.Query("code")
-
-	s.logger.Printf("Debug OAuth: Received state='%s', Expected oauthState='%s'", state, oauthState)
-
-	if !strings.HasPrefix(state, "link:") && state != oauthState {
-		s.logger.Printf("Debug OAuth: State mismatch. Prefix check='%v', Equality check='%v'", strings.HasPrefix(state, "link:"), state == oauthState)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid state"})
-		return
+	// Errors go back to the page the flow started from
+	errorPath := "/auth"
+	if oauthState.LinkUserID != 0 {
+		errorPath = oauthState.RedirectPath
+	}
+	fail := func(reason string) {
+		c.Redirect(http.StatusTemporaryRedirect, s.frontendURL(errorPath, url.Values{"error": {reason}}))
 	}
 
-	var config *oauth2.Config
-	switch provider {
-	case "google":
-		config = googleConfig
-	case "twitch":
-		config = twitchConfig
-	case "tiktok":
-		config = tiktokConfig
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid provider"})
+	if c.Query("error") != "" {
+		// The user declined or the provider refused the request
+		s.logger.Printf("%s authorization failed: %s", provider, c.Query("error"))
+		fail("oauth_denied")
 		return
 	}
 
-	token, err := config.Exchange(context.Background(), code)
+	var exchangeOptions []oauth2.AuthCodeOption
+	if oauthState.CodeVerifier != "" {
+		exchangeOptions = append(exchangeOptions, oauth2.VerifierOption(oauthState.CodeVerifier))
+	}
+	token, err := config.Exchange(context.Background(), code, exchangeOptions...)
 	if err != nil {
 		s.logger.Printf("OAuth exchange error: %v", err)
-		c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s/auth?error=oauth_failed", s.config.FrontendURL))
+		fail("oauth_failed")
 		return
 	}
 
 	userProfile, err := s.fetchUserProfile(provider, token.AccessToken, s.logger)
 	if err != nil {
 		s.logger.Printf("Failed to fetch profile: %v", err)
-		s.logger.Printf("Failed to fetch profile: %v", err)
-		c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s/auth?error=profile_failed", s.config.FrontendURL))
+		fail("profile_failed")
 		return
 	}
 
-	// CHECK STATE FOR LINKING
-	if strings.HasPrefix(state, "link:") {
-		userID, err := s.ValidateLinkState(state)
-		if err != nil {
-			s.logger.Printf("Invalid link state: %v", err)
-			c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s/me/settings?error=invalid_link_state", s.config.FrontendURL))
-			return
-		}
-
-		// Link Logic
-		err = s.db.LinkProvider(userID, provider, userProfile.ID, userProfile.Name)
+	// LINK TO THE USER WHO STARTED THE FLOW
+	if oauthState.LinkUserID != 0 {
+		err = s.db.LinkProvider(oauthState.LinkUserID, provider, userProfile.ID, userProfile.Name)
 		if err != nil {
 			s.logger.Printf("Failed to link provider: %v", err)
-			c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s/me/settings?error=link_failed_or_taken", s.config.FrontendURL))
+			fail("link_failed_or_taken")
 			return
 		}
 
-		c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s/me/settings?success=linked", s.config.FrontendURL))
+		c.Redirect(http.StatusTemporaryRedirect, s.frontendURL(oauthState.RedirectPath, url.Values{"success": {"linked"}}))
 		return
 	}
 
 	// NORMAL LOGIN
-	// Check if user exists
-	existingUser, err := s.GetUserByProviderID(provider, userProfile.ID)
-
-	// Create/Update Logic
-	if err == nil {
-		// User exists -> Login
-
-		// Self-healing: Update specific ID column if missing?
-		// e.g. if GoogleID is null but found via ProviderID
-		// We can do this async or here.
-		// For now, let's just Login.
-
-		token, err := s.GenerateSessionToken(existingUser)
-		if err != nil {
-			s.logger.Printf("Failed to generate session token: %v", err)
-			c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s/auth?error=token_err", s.config.FrontendURL))
-			return
-		}
-		c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s/auth?token=%s", s.config.FrontendURL, token))
-	} else {
-		// New User -> Redirect to Signup Step 2
-		signupClaims := SignupClaims{
-			Provider:   provider,
-			ProviderID: userProfile.ID,
-			Email:      userProfile.Email,
-			AvatarURL:  userProfile.Avatar,
-		}
+	// The frontend continues to the redirect path once signed in
+	params := url.Values{}
+	if oauthState.RedirectPath != "" {
+		params.Set("redirect", oauthState.RedirectPath)
+	}
 
-		signupToken, err := s.GenerateSignupToken(signupClaims)
-		if err != nil {
-			s.logger.Printf("Failed to generate signup token: %v", err)
-			c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s/auth?error=token_err", s.config.FrontendURL))
-			return
-		}
+	// The tokens aren't put in the URL, the frontend exchanges the code for
+	// a session token, or a signup token for a new provider account
+	authCode := &dbmodel.AuthCode{
+		SignupProvider:   provider,
+		SignupProviderID: userProfile.ID,
+		SignupEmail:      userProfile.Email,
+		SignupAvatarURL:  userProfile.Avatar,
+	}
+	if existingUser, err := s.GetUserByProviderID(provider, userProfile.ID); err == nil {
+		authCode = &dbmodel.AuthCode{UserID: existingUser.ID}
+	}
 
-		// Redirect to Signup Page with Token
-		c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s/auth?step=2&signup_token=%s", s.config.FrontendURL, signupToken))
+	exchangeCode, err := s.issueAuthCode(authCode)
+	if err != nil {
+		s.logger.Printf("Failed to issue auth code: %v", err)
+		fail("token_err")
+		return
 	}
+	params.Set("code", exchangeCode)
+	c.Redirect(http.StatusTemporaryRedirect, s.frontendURL("/auth", params))
 }
 
 func (s *Service) fetchUserProfile(provider, accessToken string, logger interface{}) (*model.UserProfile, error) {
@@ -307,8 +258,7 @@ func (s *Service) fetchUserProfile(provider, accessToken string, logger interfac
 
 // Struct for Wallet Login
 type WalletLoginRequest struct {
-	Address   string `json:"address" binding:"required"`
-	Timestamp int64  `json:"timestamp" binding:"required"`
+	Message   string `json:"message" binding:"required"` // EIP-4361 / CAIP-122 message with a nonce from /auth/wallet/nonce
 	Signature string `json:"signature" binding:"required"`
 }
 
@@ -319,32 +269,45 @@ func (s *Service) HandleWalletLogin(c *gin.Context) {
 		return
 	}
 
-	// 1. Verify Timestamp (within 1 hour)
-	now := time.Now().Unix()
-	if req.Timestamp < now-3600 || req.Timestamp > now+300 { // 1h past, 5m future buffer
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Timestamp too old or invalid"})
+	// 1. Parse and check the Sign-In With Ethereum message
+	msg, err := ParseSIWEMessage(req.Message)
+	if err == nil {
+		err = s.validateSIWEMessage(msg, time.Now())
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidSIWEMessage) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.Printf("Failed to validate sign-in message: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate sign-in message"})
 		return
 	}
+	address := msg.Address
 
 	// 1b. Check Blacklist
-	if s.IsWalletBlacklisted(req.Address) {
+	if s.IsWalletBlacklisted(address) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Wallet is blacklisted"})
 		return
 	}
 
-	// 2. Check Replay Attack (Used Signature)
-	if s.IsSignatureUsed(req.Signature) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Signature already used"})
+	// 2. Verify Signature of the message as signed
+	isValid, err := verifyWalletSignature(msg.AccountType, address, req.Message, req.Signature)
+	if err != nil || !isValid {
+		s.logger.Printf("Signature verification failed for %s: %v", address, err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
 		return
 	}
 
-	// 3. Verify Signature
-	// Message format must match frontend: `{"address":"%s","timestamp":%d}`
-	msg := fmt.Sprintf(`{"address":"%s","timestamp":%d}`, req.Address, req.Timestamp)
-	isValid, err := verifySignature(req.Address, msg, req.Signature)
-	if err != nil || !isValid {
-		s.logger.Printf("Signature verification failed for %s: %v", req.Address, err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+	// 3. Use up the Nonce, only one request can replay the message past this
+	if err := s.consumeWalletNonce(msg.Nonce); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 3b. Check Replay Attack (Used Signature)
+	if s.IsSignatureUsed(req.Signature) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Signature already used"})
 		return
 	}
 
@@ -356,13 +319,13 @@ func (s *Service) HandleWalletLogin(c *gin.Context) {
 	}
 
 	// 5. Check if User Exists
-	user, err := s.db.GetUserByWalletAddress(req.Address)
+	user, err := s.db.GetUserByWalletAddress(address)
 	if err != nil {
 		// User Not Found -> Return Signup Token
 		signupClaims := SignupClaims{
 			Provider:      "wallet",
-			ProviderID:    req.Address,
-			WalletAddress: req.Address,
+			ProviderID:    address,
+			WalletAddress: address,
 		}
 		signupToken, err := s.GenerateSignupToken(signupClaims)
 		if err != nil {
@@ -378,23 +341,13 @@ func (s *Service) HandleWalletLogin(c *gin.Context) {
 	}
 
 	// 6. User Exists -> Login
-	token, err := s.GenerateSessionToken(user)
+	tokens, err := s.GenerateSessionToken(user, sessionDevice(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	// Also generate wallet session for persistent wallet auth if needed?
-	// The original code was generating `GenerateWalletToken`.
-	// But `GenerateSessionToken` is for USER session.
-	// `GenerateWalletToken` was likely for the wallet context.
-	// Let's stick to `GenerateSessionToken` for full user login as we are migrating to user-centric.
-	// Wait, the previous code used `GenerateWalletToken`.
-	// Let's see what the frontend expects. `useWalletAuth` likely handles both.
-	// The original code returned `token` and `expires_in`.
-	// If the user is fully logged in, we should return the User Session Token.
-
-	c.JSON(http.StatusOK, gin.H{"status": "success", "token": token, "expires_in": 172800}) // 48h
+	c.JSON(http.StatusOK, gin.H{"status": "success", "token": tokens.Token, "refresh_token": tokens.RefreshToken, "expires_in": tokens.ExpiresIn})
 }
 
 // verifySignature checks if the signature matches the address for the given message
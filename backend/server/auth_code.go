@@ -0,0 +1,93 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+)
+
+// Long enough for the frontend to exchange it right after the redirect
+const authCodeTTL = 2 * time.Minute
+
+var ErrInvalidAuthCode = errors.New("invalid or expired code")
+
+// issueAuthCode stores record under a new random code and returns the code.
+// Only its hash is kept, so a database leak can't be replayed.
+func (s *Service) issueAuthCode(record *dbmodel.AuthCode) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := base64.RawURLEncoding.EncodeToString(b)
+
+	record.CodeHash = sha256Hex(code)
+	record.ExpiresAt = time.Now().Add(authCodeTTL)
+	if err := s.db.CreateAuthCode(record); err != nil {
+		return "", fmt.Errorf("failed to store auth code: %v", err)
+	}
+	return code, nil
+}
+
+// ExchangeAuthCode swaps a one-time code from the OAuth callback for a
+// session token, or a signup token for a provider account without a user
+func (s *Service) ExchangeAuthCode(code string, device SessionDevice) (*model.AuthExchangeResponse, error) {
+	if code == "" {
+		return nil, ErrInvalidAuthCode
+	}
+	record, err := s.db.ConsumeAuthCode(sha256Hex(code))
+	if err != nil || time.Now().After(record.ExpiresAt) {
+		return nil, ErrInvalidAuthCode
+	}
+
+	if record.UserID != 0 {
+		user, err := s.db.GetUserByID(record.UserID)
+		if err != nil {
+			return nil, ErrInvalidAuthCode
+		}
+		tokens, err := s.GenerateSessionToken(user, device)
+		if err != nil {
+			return nil, err
+		}
+		return &model.AuthExchangeResponse{SessionTokens: tokens}, nil
+	}
+
+	signupToken, err := s.GenerateSignupToken(SignupClaims{
+		Provider:   record.SignupProvider,
+		ProviderID: record.SignupProviderID,
+		Email:      record.SignupEmail,
+		AvatarURL:  record.SignupAvatarURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.AuthExchangeResponse{SignupToken: signupToken}, nil
+}
+
+func (s *Server) HandleAuthExchange(c *gin.Context) {
+	var req model.AuthExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	resp, err := s.service.ExchangeAuthCode(req.Code, sessionDevice(c))
+	if err != nil {
+		if errors.Is(err, ErrInvalidAuthCode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired code"})
+			return
+		}
+		s.logger.Printf("Failed to exchange auth code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to exchange code"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
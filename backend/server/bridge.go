@@ -0,0 +1,278 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+
+	dbmodel "github.com/patiee/backend/db/model"
+	"gorm.io/gorm"
+)
+
+const defaultLifiAPIURL = "https://li.quest"
+
+// Bridge transfer states reported by a BridgeStatusClient
+const (
+	BridgeStatePending  = "pending"
+	BridgeStateDone     = "done"
+	BridgeStateFailed   = "failed"
+	BridgeStateRefunded = "refunded"
+)
+
+// BridgeStatus is the progress of a cross-chain transfer
+type BridgeStatus struct {
+	State      string
+	DestTxHash string // Set once the transfer was delivered on the destination chain
+	DestAmount string // Decimal amount delivered, after fees and slippage
+	DestAsset  string // Asset delivered, by symbol for the native asset or by token address
+
+	// Native asset delivered, which bridges usually send with an internal
+	// call the destination tx doesn't show. Nil for tokens and when unknown.
+	ReceivedNative *Transfer
+}
+
+// Token addresses bridges use for the native asset
+var nativeTokenAddresses = map[string]bool{
+	"0x0000000000000000000000000000000000000000": true,
+	"0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee": true,
+	"11111111111111111111111111111111":           true,
+	"0x2::sui::sui":                              true,
+	"bitcoin":                                    true,
+}
+
+// nativeTransfer returns amount of chainID's native asset sent to recipient,
+// nil if token isn't the native asset or the amount is invalid
+func nativeTransfer(chainID, token, recipient, amount string) *Transfer {
+	if recipient == "" || !nativeTokenAddresses[strings.ToLower(token)] {
+		return nil
+	}
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok || value.Sign() <= 0 {
+		return nil
+	}
+	symbol, decimals, ok := nativeAsset(chainID)
+	if !ok {
+		return nil
+	}
+	return &Transfer{Recipient: recipient, Asset: symbol, Amount: value, Decimals: decimals}
+}
+
+// bridgeAsset names a token the way verifiers do: the native asset and
+// well-known tokens by symbol, other tokens by address
+func bridgeAsset(chainID, token string) string {
+	if nativeTokenAddresses[strings.ToLower(token)] {
+		if symbol, _, ok := nativeAsset(chainID); ok {
+			return symbol
+		}
+	}
+	symbols := tokenSymbols(chainID)
+	if symbol, ok := symbols[token]; ok {
+		return symbol
+	}
+	if symbol, ok := symbols[strings.ToLower(token)]; ok {
+		return symbol
+	}
+	return token
+}
+
+// BridgeStatusClient reports the status of a bridge transfer started by a source tx
+type BridgeStatusClient interface {
+	Status(ctx context.Context, sourceChain, destChain, txHash string) (*BridgeStatus, error)
+}
+
+// LI.FI chain IDs for the non-EVM chains the frontend uses by name
+var lifiChainIDs = map[string]string{
+	"solana":  "1151111081099710",
+	"bitcoin": "20000000000001",
+	"100003":  "9270000000000000", // Sui
+}
+
+func lifiChainID(chainID string) string {
+	if id, ok := lifiChainIDs[chainID]; ok {
+		return id
+	}
+	return chainID
+}
+
+// LifiStatusClient polls the LI.FI /v1/status endpoint
+type LifiStatusClient struct {
+	baseURL string
+	apiKey  string
+}
+
+func NewLifiStatusClient(baseURL, apiKey string) *LifiStatusClient {
+	if baseURL == "" {
+		baseURL = defaultLifiAPIURL
+	}
+	return &LifiStatusClient{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey}
+}
+
+func (c *LifiStatusClient) Status(ctx context.Context, sourceChain, destChain, txHash string) (*BridgeStatus, error) {
+	query := url.Values{
+		"txHash":    {txHash},
+		"fromChain": {lifiChainID(sourceChain)},
+		"toChain":   {lifiChainID(destChain)},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/v1/status?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("x-lifi-api-key", c.apiKey)
+	}
+
+	resp, err := verifierHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// LI.FI answers 404 until it indexed the source tx
+	if resp.StatusCode == http.StatusNotFound {
+		return &BridgeStatus{State: BridgeStatePending}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("LI.FI status error: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Status    string `json:"status"`    // NOT_FOUND, INVALID, PENDING, DONE, FAILED
+		Substatus string `json:"substatus"` // COMPLETED, PARTIAL, REFUNDED, ...
+		ToAddress string `json:"toAddress"`
+		Receiving struct {
+			TxHash string `json:"txHash"`
+			Amount string `json:"amount"`
+			Token  struct {
+				Address  string `json:"address"`
+				Decimals int    `json:"decimals"`
+			} `json:"token"`
+		} `json:"receiving"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	switch result.Status {
+	case "DONE":
+		if result.Substatus == "REFUNDED" {
+			return &BridgeStatus{State: BridgeStateRefunded}, nil
+		}
+		status := &BridgeStatus{
+			State:          BridgeStateDone,
+			DestTxHash:     result.Receiving.TxHash,
+			ReceivedNative: nativeTransfer(destChain, result.Receiving.Token.Address, result.ToAddress, result.Receiving.Amount),
+		}
+		if amount, ok := new(big.Int).SetString(result.Receiving.Amount, 10); ok && result.Receiving.Token.Address != "" {
+			status.DestAmount = formatUnits(amount, result.Receiving.Token.Decimals)
+			status.DestAsset = bridgeAsset(destChain, result.Receiving.Token.Address)
+		}
+		return status, nil
+	case "FAILED", "INVALID":
+		return &BridgeStatus{State: BridgeStateFailed}, nil
+	default:
+		return &BridgeStatus{State: BridgeStatePending}, nil
+	}
+}
+
+// SetBridgeStatusClient replaces the bridge status provider (e.g. with a local mock)
+func (s *Service) SetBridgeStatusClient(c BridgeStatusClient) {
+	s.bridge = c
+}
+
+// isBridgeTip reports whether the tip was sent cross-chain
+func isBridgeTip(tip *dbmodel.Tip) bool {
+	return tip.SourceChain != "" && tip.DestChain != "" && tip.SourceChain != tip.DestChain
+}
+
+// tipPayment is what the streamer was paid: the delivery of a bridge tip,
+// the tip itself otherwise
+func tipPayment(tip *dbmodel.Tip) (chainID, amount, asset string) {
+	if isBridgeTip(tip) {
+		return tip.DestChain, tip.DestAmount, tip.DestAsset
+	}
+	return tip.ChainID, tip.Amount, tip.Asset
+}
+
+// advanceBridgeTip handles a cross-chain tip:
+// pending -> bridging -> delivered -> confirmed, or refunded / failed.
+// It returns true once the tip reached a final status.
+func (s *Service) advanceBridgeTip(ctx context.Context, tip *dbmodel.Tip) (bool, error) {
+	// 1. Source tx: only the sender is checked, the funds go to the bridge
+	if tip.Status == dbmodel.TipStatusPending {
+		verifier, ok := s.verifiers.Get(tip.SourceChain)
+		if !ok {
+			return false, fmt.Errorf("%w: no verifier registered for chain %q", ErrUnsupportedChain, tip.SourceChain)
+		}
+		if _, err := verifier.VerifyTx(ctx, tip.TxHash, tip.SourceAddress); err != nil {
+			return false, err
+		}
+		s.setTipStatus(tip, dbmodel.TipStatusBridging)
+	}
+
+	// 2. Bridge transfer
+	var status *BridgeStatus
+	if tip.Status == dbmodel.TipStatusBridging {
+		var err error
+		status, err = s.bridge.Status(ctx, tip.SourceChain, tip.DestChain, tip.TxHash)
+		if err != nil {
+			return false, err
+		}
+
+		switch status.State {
+		case BridgeStateRefunded:
+			s.logger.Printf("Bridge transfer refunded for tip %d", tip.ID)
+			s.setTipStatus(tip, dbmodel.TipStatusRefunded)
+			s.EmitTipWebhook(tip, WebhookEventTipFailed)
+			return true, nil
+		case BridgeStateFailed:
+			return false, fmt.Errorf("%w: bridge transfer failed", ErrTxFailed)
+		case BridgeStateDone:
+			if status.DestTxHash == "" || status.DestAsset == "" {
+				return false, fmt.Errorf("bridge reported delivery without a destination tx or asset")
+			}
+			destTxHash := normalizeTxHash(tip.DestChain, status.DestTxHash)
+			claimed, err := s.db.IsTipTxClaimed(tip.DestChain, destTxHash, tip.ID)
+			if err != nil {
+				return false, fmt.Errorf("failed to check destination tx: %v", err)
+			}
+			if claimed {
+				return false, fmt.Errorf("%w: destination tx %s", ErrDuplicateTip, destTxHash)
+			}
+			tip.DestTxHash, tip.DestAmount, tip.DestAsset = destTxHash, status.DestAmount, status.DestAsset
+			if err := s.db.UpdateTipDelivery(tip.ID, tip.DestTxHash, tip.DestAmount, tip.DestAsset); err != nil {
+				if errors.Is(err, gorm.ErrDuplicatedKey) {
+					return false, fmt.Errorf("%w: destination tx %s", ErrDuplicateTip, destTxHash)
+				}
+				return false, fmt.Errorf("failed to save destination tx: %v", err)
+			}
+			s.setTipStatus(tip, dbmodel.TipStatusDelivered)
+		default:
+			return false, nil
+		}
+	}
+
+	// 3. Destination receipt, sent by the bridge so there is no sender to check.
+	// Native deliveries are internal calls, their amount comes from the bridge.
+	if status == nil {
+		var err error
+		if status, err = s.bridge.Status(ctx, tip.SourceChain, tip.DestChain, tip.TxHash); err != nil {
+			return false, err
+		}
+	}
+	var bridged []Transfer
+	if status.ReceivedNative != nil && normalizeTxHash(tip.DestChain, status.DestTxHash) == tip.DestTxHash {
+		bridged = append(bridged, *status.ReceivedNative)
+	}
+	result, err := s.verifyPayment(ctx, tip, tip.DestChain, tip.DestTxHash, "", bridged...)
+	if err != nil {
+		return false, err
+	}
+	return s.confirmIfFinal(ctx, tip, tip.DestChain, result), nil
+}
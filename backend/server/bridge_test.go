@@ -0,0 +1,255 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dbmodel "github.com/patiee/backend/db/model"
+)
+
+// mockBridgeClient stands in for LI.FI with a fixed status
+type mockBridgeClient struct {
+	status *BridgeStatus
+	err    error
+	calls  int
+}
+
+func (m *mockBridgeClient) Status(ctx context.Context, sourceChain, destChain, txHash string) (*BridgeStatus, error) {
+	m.calls++
+	return m.status, m.err
+}
+
+// stubVerifier returns a fixed result for every tx
+type stubVerifier struct {
+	result *TxResult
+	err    error
+}
+
+func (v *stubVerifier) VerifyTx(ctx context.Context, txHash, expectedSender string) (*TxResult, error) {
+	return v.result, v.err
+}
+
+func newTestService() *Service {
+	return &Service{
+		logger:    log.New(io.Discard, "", 0),
+		hub:       NewHub(log.New(io.Discard, "", 0)),
+		verifiers: NewVerifierRegistry(),
+	}
+}
+
+func TestLifiStatusClient(t *testing.T) {
+	const recipient = "0x1111111111111111111111111111111111111111"
+
+	tests := []struct {
+		name     string
+		code     int
+		body     string
+		state    string
+		destTx   string
+		amount   string // Delivered amount and asset
+		asset    string
+		received string // Native wei reported as received, "" for none
+	}{
+		{name: "not indexed", code: http.StatusNotFound, state: BridgeStatePending},
+		{name: "pending", code: http.StatusOK, body: `{"status":"PENDING"}`, state: BridgeStatePending},
+		{name: "failed", code: http.StatusOK, body: `{"status":"FAILED"}`, state: BridgeStateFailed},
+		{name: "refunded", code: http.StatusOK, body: `{"status":"DONE","substatus":"REFUNDED"}`, state: BridgeStateRefunded},
+		{
+			name:     "native delivery",
+			code:     http.StatusOK,
+			body:     `{"status":"DONE","substatus":"COMPLETED","toAddress":"` + recipient + `","receiving":{"txHash":"0xabcd","amount":"500000000000000000","token":{"address":"0x0000000000000000000000000000000000000000","decimals":18}}}`,
+			state:    BridgeStateDone,
+			destTx:   "0xabcd",
+			amount:   "0.5",
+			asset:    "ETH",
+			received: "500000000000000000",
+		},
+		{
+			name:   "token delivery",
+			code:   http.StatusOK,
+			body:   `{"status":"DONE","substatus":"COMPLETED","toAddress":"` + recipient + `","receiving":{"txHash":"0xabcd","amount":"1000000","token":{"address":"0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913","decimals":6}}}`,
+			state:  BridgeStateDone,
+			destTx: "0xabcd",
+			amount: "1",
+			asset:  "USDC",
+		},
+		{
+			name:   "unlisted token delivery",
+			code:   http.StatusOK,
+			body:   `{"status":"DONE","substatus":"COMPLETED","toAddress":"` + recipient + `","receiving":{"txHash":"0xabcd","amount":"25","token":{"address":"0x2222222222222222222222222222222222222222","decimals":1}}}`,
+			state:  BridgeStateDone,
+			destTx: "0xabcd",
+			amount: "2.5",
+			asset:  "0x2222222222222222222222222222222222222222",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/status" {
+					t.Errorf("unexpected path %q", r.URL.Path)
+				}
+				if got := r.URL.Query().Get("fromChain"); got != "1151111081099710" {
+					t.Errorf("fromChain = %q, want the LI.FI Solana ID", got)
+				}
+				if got := r.Header.Get("x-lifi-api-key"); got != "key" {
+					t.Errorf("api key header = %q", got)
+				}
+				w.WriteHeader(tt.code)
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			status, err := NewLifiStatusClient(srv.URL, "key").Status(context.Background(), "solana", "8453", "sig")
+			if err != nil {
+				t.Fatalf("Status: %v", err)
+			}
+			if status.State != tt.state || status.DestTxHash != tt.destTx || status.DestAmount != tt.amount || status.DestAsset != tt.asset {
+				t.Fatalf("got %+v, want state %q dest %q delivering %s %s", status, tt.state, tt.destTx, tt.amount, tt.asset)
+			}
+			if tt.received == "" {
+				if status.ReceivedNative != nil {
+					t.Fatalf("ReceivedNative = %+v, want nil", status.ReceivedNative)
+				}
+				return
+			}
+			r := status.ReceivedNative
+			if r == nil || r.Amount.String() != tt.received || r.Asset != "ETH" || r.Decimals != 18 || r.Recipient != recipient {
+				t.Fatalf("ReceivedNative = %+v", r)
+			}
+		})
+	}
+}
+
+func TestAdvanceBridgeTipNativeDelivery(t *testing.T) {
+	const recipient = "0x1111111111111111111111111111111111111111"
+	delivered := func() *dbmodel.Tip {
+		return &dbmodel.Tip{
+			Status:      dbmodel.TipStatusDelivered,
+			SourceChain: "solana",
+			DestChain:   "8453",
+			DestAddress: recipient,
+			DestTxHash:  "0xabcd",
+			DestAmount:  "0.5",
+			DestAsset:   "ETH",
+			Asset:       "SOL",
+			Amount:      "20",
+		}
+	}
+	// The delivery tx is a contract call, its native transfer is internal
+	dest := &stubVerifier{result: &TxResult{Confirmations: 1}}
+
+	t.Run("amount from bridge", func(t *testing.T) {
+		s := newTestService()
+		s.verifiers.Register("8453", dest)
+		bridge := &mockBridgeClient{status: &BridgeStatus{
+			State:          BridgeStateDone,
+			DestTxHash:     "0xABCD",
+			ReceivedNative: &Transfer{Recipient: recipient, Asset: "ETH", Amount: big.NewInt(5e17), Decimals: 18},
+		}}
+		s.bridge = bridge
+
+		settled, err := s.advanceBridgeTip(context.Background(), delivered())
+		if err != nil {
+			t.Fatalf("advanceBridgeTip: %v", err)
+		}
+		if settled {
+			t.Fatal("settled before the confirmation policy was met")
+		}
+		if bridge.calls != 1 {
+			t.Fatalf("bridge queried %d times, want 1", bridge.calls)
+		}
+	})
+
+	t.Run("no native amount", func(t *testing.T) {
+		s := newTestService()
+		s.verifiers.Register("8453", dest)
+		s.bridge = &mockBridgeClient{status: &BridgeStatus{State: BridgeStateDone, DestTxHash: "0xabcd"}}
+
+		if _, err := s.advanceBridgeTip(context.Background(), delivered()); !errors.Is(err, ErrRecipientMismatch) {
+			t.Fatalf("err = %v, want ErrRecipientMismatch", err)
+		}
+	})
+
+	t.Run("amount for another tx", func(t *testing.T) {
+		s := newTestService()
+		s.verifiers.Register("8453", dest)
+		s.bridge = &mockBridgeClient{status: &BridgeStatus{
+			State:          BridgeStateDone,
+			DestTxHash:     "0xef01",
+			ReceivedNative: &Transfer{Recipient: recipient, Asset: "ETH", Amount: big.NewInt(5e17), Decimals: 18},
+		}}
+
+		if _, err := s.advanceBridgeTip(context.Background(), delivered()); !errors.Is(err, ErrRecipientMismatch) {
+			t.Fatalf("err = %v, want ErrRecipientMismatch", err)
+		}
+	})
+
+	t.Run("not counted twice", func(t *testing.T) {
+		s := newTestService()
+		s.verifiers.Register("8453", &stubVerifier{result: &TxResult{
+			Confirmations: 1,
+			Transfers:     []Transfer{{Recipient: recipient, Asset: "ETH", Amount: big.NewInt(5e17), Decimals: 18}},
+		}})
+		s.bridge = &mockBridgeClient{status: &BridgeStatus{
+			State:          BridgeStateDone,
+			DestTxHash:     "0xabcd",
+			ReceivedNative: &Transfer{Recipient: recipient, Asset: "ETH", Amount: big.NewInt(5e17), Decimals: 18},
+		}}
+
+		// 0.5 matches the claim, 1.0 would need a db correction
+		if _, err := s.advanceBridgeTip(context.Background(), delivered()); err != nil {
+			t.Fatalf("advanceBridgeTip: %v", err)
+		}
+	})
+}
+
+func TestAdvanceBridgeTipTokenDelivery(t *testing.T) {
+	const recipient = "0x1111111111111111111111111111111111111111"
+	usdc := "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"
+	// BTC sent, USDC delivered by a contract call on Base
+	tip := &dbmodel.Tip{
+		Status:      dbmodel.TipStatusDelivered,
+		SourceChain: "bitcoin",
+		DestChain:   "8453",
+		DestAddress: recipient,
+		DestTxHash:  "0xabcd",
+		DestAmount:  "60",
+		DestAsset:   "USDC",
+		Asset:       "BTC",
+		Amount:      "0.001",
+	}
+	deliveredTo := func(to string) *stubVerifier {
+		return &stubVerifier{result: &TxResult{
+			Confirmations: 1,
+			Transfers:     []Transfer{{Recipient: to, Asset: "USDC", Token: usdc, Amount: big.NewInt(60_000_000), Decimals: 6}},
+		}}
+	}
+
+	s := newTestService()
+	s.verifiers.Register("8453", deliveredTo(recipient))
+	s.bridge = &mockBridgeClient{status: &BridgeStatus{State: BridgeStateDone, DestTxHash: "0xabcd", DestAmount: "60", DestAsset: "USDC"}}
+	settled, err := s.advanceBridgeTip(context.Background(), tip)
+	if err != nil {
+		t.Fatalf("advanceBridgeTip: %v", err)
+	}
+	if settled {
+		t.Fatal("settled before the confirmation policy was met")
+	}
+	if tip.Asset != "BTC" || tip.Amount != "0.001" {
+		t.Fatalf("sent %s %s changed", tip.Amount, tip.Asset)
+	}
+
+	// Delivered somewhere else
+	s.verifiers.Register("8453", deliveredTo("0x3333333333333333333333333333333333333333"))
+	if _, err := s.advanceBridgeTip(context.Background(), tip); !errors.Is(err, ErrRecipientMismatch) {
+		t.Fatalf("err = %v, want ErrRecipientMismatch", err)
+	}
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/patiee/backend/db"
+	dbmodel "github.com/patiee/backend/db/model"
+)
+
+const (
+	widgetEventsChannel = "widget_events"
+	pgNotifyMaxPayload  = 7999 // Postgres rejects NOTIFY payloads of 8000 bytes or more
+
+	// Stored widget event type of messages too large for a NOTIFY
+	widgetBroadcastEvent = "BROADCAST"
+)
+
+// Broadcaster distributes widget events to every backend replica, each of
+// which fans them out to its local WebSocket connections.
+type Broadcaster interface {
+	// Publish sends a JSON encoded widget message for userID to all replicas
+	Publish(ctx context.Context, userID uint, payload []byte) error
+	// Subscribe calls deliver for every published message until ctx is done
+	Subscribe(ctx context.Context, deliver func(userID uint, payload []byte))
+}
+
+// MemoryBroadcaster delivers in-process, for a single replica
+type MemoryBroadcaster struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[int]func(userID uint, payload []byte)
+}
+
+func NewMemoryBroadcaster() *MemoryBroadcaster {
+	return &MemoryBroadcaster{handlers: make(map[int]func(uint, []byte))}
+}
+
+func (b *MemoryBroadcaster) Publish(ctx context.Context, userID uint, payload []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, deliver := range b.handlers {
+		deliver(userID, payload)
+	}
+	return nil
+}
+
+func (b *MemoryBroadcaster) Subscribe(ctx context.Context, deliver func(userID uint, payload []byte)) {
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = deliver
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.handlers, id)
+		b.mu.Unlock()
+	}()
+}
+
+// broadcastEnvelope is the NOTIFY payload shared between replicas. Messages
+// too large for a NOTIFY are stored as widget events and sent by EventID.
+type broadcastEnvelope struct {
+	UserID  uint            `json:"user_id"`
+	Message json.RawMessage `json:"message,omitempty"`
+	EventID uint64          `json:"event_id,omitempty"`
+}
+
+// PostgresBroadcaster uses LISTEN/NOTIFY on the application database
+type PostgresBroadcaster struct {
+	db     *db.Database
+	logger *log.Logger
+}
+
+func NewPostgresBroadcaster(database *db.Database, logger *log.Logger) *PostgresBroadcaster {
+	return &PostgresBroadcaster{db: database, logger: logger}
+}
+
+func (b *PostgresBroadcaster) Publish(ctx context.Context, userID uint, payload []byte) error {
+	data, err := json.Marshal(broadcastEnvelope{UserID: userID, Message: payload})
+	if err != nil {
+		return err
+	}
+	if len(data) > pgNotifyMaxPayload {
+		event := &dbmodel.WidgetEvent{UserID: userID, Type: widgetBroadcastEvent, Payload: string(payload)}
+		if err := b.db.CreateWidgetEvent(event); err != nil {
+			return fmt.Errorf("failed to store widget event of %d bytes: %v", len(data), err)
+		}
+		if data, err = json.Marshal(broadcastEnvelope{UserID: userID, EventID: event.ID}); err != nil {
+			return err
+		}
+	}
+	return b.db.Notify(widgetEventsChannel, string(data))
+}
+
+// Subscribe listens in the background, reconnecting with backoff when the
+// connection drops. Events published while disconnected are lost.
+func (b *PostgresBroadcaster) Subscribe(ctx context.Context, deliver func(userID uint, payload []byte)) {
+	handle := func(payload string) {
+		var env broadcastEnvelope
+		if err := json.Unmarshal([]byte(payload), &env); err != nil {
+			b.logger.Printf("Invalid widget event notification: %v", err)
+			return
+		}
+		if env.EventID != 0 {
+			event, err := b.db.GetWidgetEvent(env.EventID)
+			if err != nil {
+				b.logger.Printf("Failed to load widget event %d: %v", env.EventID, err)
+				return
+			}
+			env.Message = json.RawMessage(event.Payload)
+		}
+		deliver(env.UserID, env.Message)
+	}
+
+	go func() {
+		delay := time.Second
+		for {
+			started := time.Now()
+			err := b.db.Listen(ctx, widgetEventsChannel, handle)
+			if ctx.Err() != nil {
+				return
+			}
+			if time.Since(started) > time.Minute {
+				delay = time.Second
+			}
+			b.logger.Printf("Widget event listener stopped: %v. Reconnecting in %s", err, delay)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, 30*time.Second)
+		}
+	}()
+}
+
+func newBroadcaster(config Config, database *db.Database, logger *log.Logger) Broadcaster {
+	if config.Broadcaster == "postgres" {
+		return NewPostgresBroadcaster(database, logger)
+	}
+	return NewMemoryBroadcaster()
+}
+
+// SetBroadcaster replaces the pub/sub backplane, must be called before Start
+func (s *Service) SetBroadcaster(b Broadcaster) {
+	s.broadcaster = b
+}
+
+// StartBroadcaster fans out events from all replicas to the local widget connections
+func (s *Service) StartBroadcaster(ctx context.Context) {
+	s.broadcaster.Subscribe(ctx, s.hub.BroadcastRaw)
+}
+
+// publishWidgetEvent sends msg to userID's widgets on every replica. If the
+// backplane is unavailable the event still reaches local connections.
+func (s *Service) publishWidgetEvent(userID uint, msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		s.logger.Printf("Failed to encode widget event: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.broadcaster.Publish(ctx, userID, data); err != nil {
+		s.logger.Printf("Failed to publish widget event, delivering locally: %v", err)
+		s.hub.BroadcastRaw(userID, data)
+	}
+}
@@ -0,0 +1,272 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+)
+
+const (
+	defaultChatTemplate = "{sender} tipped {amount} {asset}! {message}"
+	chatQueueSize       = 100
+	chatMaxMessage      = 450 // Twitch drops lines over 500 characters
+	chatSendTimeout     = 15 * time.Second
+)
+
+// Twitch lets a regular bot account send 20 messages per 30 seconds across
+// all channels. Each channel gets a smaller share so one busy stream can't
+// starve the others.
+var (
+	chatGlobalLimit  = rateLimit{Events: 20, Window: 30 * time.Second}
+	chatChannelLimit = rateLimit{Events: 5, Window: 30 * time.Second}
+)
+
+var ErrInvalidChatSettings = errors.New("invalid chat settings")
+
+type chatAnnouncement struct {
+	channel string
+	message string
+}
+
+type rateLimit struct {
+	Events int
+	Window time.Duration
+}
+
+// slidingWindow allows limit.Events per limit.Window for each key
+type slidingWindow struct {
+	mu     sync.Mutex
+	limit  rateLimit
+	events map[string][]time.Time
+}
+
+func newSlidingWindow(limit rateLimit) *slidingWindow {
+	return &slidingWindow{limit: limit, events: make(map[string][]time.Time)}
+}
+
+// Reserve records an event for key and returns 0 if it's within the limit,
+// otherwise it records nothing and returns how long until it would be
+func (w *slidingWindow) Reserve(key string, now time.Time) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	events := w.events[key]
+	for len(events) > 0 && now.Sub(events[0]) >= w.limit.Window {
+		events = events[1:]
+	}
+	if len(events) >= w.limit.Events {
+		w.events[key] = events
+		return events[0].Add(w.limit.Window).Sub(now)
+	}
+	w.events[key] = append(events, now)
+	return 0
+}
+
+// newChatClient returns nil when no bot account is configured
+func newChatClient(config Config, logger *log.Logger) ChatClient {
+	if config.TwitchBotUsername == "" && config.TwitchBotToken == "" {
+		return nil
+	}
+	client, err := NewIRCChatClient(config.TwitchIRCURL, config.TwitchBotUsername, config.TwitchBotToken)
+	if err != nil {
+		logger.Printf("Chat announcements disabled: %v", err)
+		return nil
+	}
+	return client
+}
+
+// SetChatClient replaces the chat client, nil disables announcements.
+// Call it before StartChatAnnouncer.
+func (s *Service) SetChatClient(c ChatClient) {
+	s.chat = c
+}
+
+// StartChatAnnouncer posts queued tip announcements within the rate limits
+func (s *Service) StartChatAnnouncer(ctx context.Context) {
+	if s.chat == nil {
+		return
+	}
+	s.chatQueue = make(chan chatAnnouncement, chatQueueSize)
+	global := newSlidingWindow(chatGlobalLimit)
+	channels := newSlidingWindow(chatChannelLimit)
+
+	go func() {
+		defer s.chat.Close()
+		for {
+			var a chatAnnouncement
+			select {
+			case <-ctx.Done():
+				return
+			case a = <-s.chatQueue:
+			}
+
+			if wait := channels.Reserve(a.channel, time.Now()); wait > 0 {
+				s.logger.Printf("Dropping chat announcement in %s, channel rate limit reached", a.channel)
+				continue
+			}
+			for wait := global.Reserve("", time.Now()); wait > 0; wait = global.Reserve("", time.Now()) {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(wait):
+				}
+			}
+
+			sendCtx, cancel := context.WithTimeout(ctx, chatSendTimeout)
+			if err := s.chat.Send(sendCtx, a.channel, a.message); err != nil {
+				s.logger.Printf("Failed to announce tip in %s: %v", a.channel, err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// AnnounceTip queues a chat message for a confirmed tip if the streamer
+// enabled announcements and linked their Twitch account
+func (s *Service) AnnounceTip(tip *dbmodel.Tip) {
+	if s.chatQueue == nil {
+		return
+	}
+	user, err := s.db.GetUserByUsername(tip.StreamerID)
+	if err != nil {
+		s.logger.Printf("Failed to find streamer %s: %v", tip.StreamerID, err)
+		return
+	}
+	if !user.ChatAnnounceEnabled || user.TwitchUsername == nil || *user.TwitchUsername == "" {
+		return
+	}
+
+	template := user.ChatAnnounceTemplate
+	if template == "" {
+		template = defaultChatTemplate
+	}
+	message := tip.Message
+	if !tipRulesFor(user).AlertFlags(tip).ShowMessage {
+		message = ""
+	}
+
+	select {
+	case s.chatQueue <- chatAnnouncement{channel: *user.TwitchUsername, message: renderChatTemplate(template, tip, message)}:
+	default:
+		s.logger.Printf("Dropping chat announcement for tip %d, queue is full", tip.ID)
+	}
+}
+
+// renderChatTemplate fills in the theme placeholders and trims the result
+// to one chat line
+func renderChatTemplate(template string, tip *dbmodel.Tip, message string) string {
+	usd := ""
+	if tip.USDValue != "" {
+		usd = "$" + tip.USDValue
+	}
+	text := strings.NewReplacer(
+		"{sender}", tip.Sender,
+		"{amount}", tip.Amount,
+		"{asset}", tip.Asset,
+		"{usd}", usd,
+		"{message}", message,
+	).Replace(template)
+
+	// A leading / or . would run a chat command as the bot
+	text = strings.TrimLeft(strings.Join(strings.Fields(text), " "), "/.")
+	if runes := []rune(text); len(runes) > chatMaxMessage {
+		text = string(runes[:chatMaxMessage-1]) + "…"
+	}
+	return text
+}
+
+func (s *Service) GetChatSettings(userID uint) (*model.ChatSettings, error) {
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &model.ChatSettings{
+		Enabled:   user.ChatAnnounceEnabled,
+		Template:  user.ChatAnnounceTemplate,
+		Available: s.chat != nil,
+	}
+	if settings.Template == "" {
+		settings.Template = defaultChatTemplate
+	}
+	if user.TwitchUsername != nil {
+		settings.Channel = *user.TwitchUsername
+	}
+	return settings, nil
+}
+
+func (s *Service) UpdateChatSettings(userID uint, req model.ChatSettings) (*model.ChatSettings, error) {
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Enabled && (user.TwitchUsername == nil || *user.TwitchUsername == "") {
+		return nil, fmt.Errorf("%w: link your Twitch account first", ErrInvalidChatSettings)
+	}
+
+	template := strings.TrimSpace(req.Template)
+	if template == defaultChatTemplate {
+		template = ""
+	}
+	if template != "" {
+		if msg := validateTextTemplate(template); msg != "" {
+			return nil, fmt.Errorf("%w: template %s", ErrInvalidChatSettings, msg)
+		}
+	}
+
+	if err := s.db.UpdateChatSettings(userID, req.Enabled, template); err != nil {
+		return nil, err
+	}
+	return s.GetChatSettings(userID)
+}
+
+func (s *Server) HandleGetChatSettings(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	settings, err := s.service.GetChatSettings(claims.UserID)
+	if err != nil {
+		s.logger.Printf("Failed to fetch chat settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch chat settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (s *Server) HandleUpdateChatSettings(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	var req model.ChatSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	settings, err := s.service.UpdateChatSettings(claims.UserID, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidChatSettings) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.Printf("Failed to update chat settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update chat settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTwitchIRCURL = "ircs://irc.chat.twitch.tv:6697"
+	ircDialTimeout      = 10 * time.Second
+	ircWriteTimeout     = 10 * time.Second
+)
+
+// ChatClient posts messages to a streamer's chat channel
+type ChatClient interface {
+	Send(ctx context.Context, channel, message string) error
+	Close() error
+}
+
+// IRCChatClient speaks Twitch's IRC interface as the bot account. It connects
+// on the first message, joins channels as needed and reconnects after errors.
+// An irc:// URL connects without TLS, e.g. to a local IRC server.
+type IRCChatClient struct {
+	addr   string
+	useTLS bool
+	nick   string
+	token  string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	joined map[string]bool
+}
+
+func NewIRCChatClient(rawURL, nick, token string) (*IRCChatClient, error) {
+	if rawURL == "" {
+		rawURL = defaultTwitchIRCURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || (u.Scheme != "irc" && u.Scheme != "ircs") {
+		return nil, fmt.Errorf("invalid irc url %q, expected irc://host:port or ircs://host:port", rawURL)
+	}
+	if nick == "" || token == "" {
+		return nil, errors.New("missing bot username or token")
+	}
+	return &IRCChatClient{
+		addr:   u.Host,
+		useTLS: u.Scheme == "ircs",
+		nick:   strings.ToLower(nick),
+		token:  "oauth:" + strings.TrimPrefix(token, "oauth:"),
+	}, nil
+}
+
+func (c *IRCChatClient) Send(ctx context.Context, channel, message string) error {
+	channel = "#" + strings.ToLower(strings.TrimPrefix(channel, "#"))
+	message = strings.NewReplacer("\r", " ", "\n", " ").Replace(message)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// One retry on a fresh connection if the current one went stale
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if err = c.send(ctx, channel, message); err == nil {
+			return nil
+		}
+		c.dropLocked()
+	}
+	return err
+}
+
+func (c *IRCChatClient) send(ctx context.Context, channel, message string) error {
+	if c.conn == nil {
+		if err := c.connectLocked(ctx); err != nil {
+			return err
+		}
+	}
+	if !c.joined[channel] {
+		if err := c.writeLocked("JOIN " + channel); err != nil {
+			return err
+		}
+		c.joined[channel] = true
+	}
+	return c.writeLocked(fmt.Sprintf("PRIVMSG %s :%s", channel, message))
+}
+
+// connectLocked dials and logs in, waiting for the server's welcome
+func (c *IRCChatClient) connectLocked(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: ircDialTimeout}
+	var conn net.Conn
+	var err error
+	if c.useTLS {
+		host, _, _ := net.SplitHostPort(c.addr)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", c.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", c.addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", c.addr, err)
+	}
+
+	c.conn = conn
+	c.joined = make(map[string]bool)
+	if err := c.writeLocked("PASS " + c.token); err != nil {
+		return err
+	}
+	if err := c.writeLocked("NICK " + c.nick); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(ircDialTimeout))
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("irc login failed: %v", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "PING") {
+			if err := c.writeLocked("PONG" + strings.TrimPrefix(line, "PING")); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.Contains(line, "NOTICE") && strings.Contains(line, "authentication failed") {
+			return errors.New("irc login failed: authentication failed")
+		}
+		if fields := strings.Fields(line); len(fields) > 1 && fields[1] == "001" {
+			break
+		}
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	go c.readLoop(conn, reader)
+	return nil
+}
+
+// readLoop answers keepalive pings until the connection fails
+func (c *IRCChatClient) readLoop(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			c.mu.Lock()
+			if c.conn == conn {
+				c.dropLocked()
+			}
+			c.mu.Unlock()
+			return
+		}
+		if strings.HasPrefix(line, "PING") {
+			c.mu.Lock()
+			if c.conn == conn {
+				c.writeLocked("PONG" + strings.TrimRight(strings.TrimPrefix(line, "PING"), "\r\n"))
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *IRCChatClient) writeLocked(line string) error {
+	c.conn.SetWriteDeadline(time.Now().Add(ircWriteTimeout))
+	_, err := c.conn.Write([]byte(line + "\r\n"))
+	return err
+}
+
+func (c *IRCChatClient) dropLocked() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+func (c *IRCChatClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dropLocked()
+	return nil
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeIRCConn is the server side of one client connection
+type fakeIRCConn struct {
+	conn  net.Conn
+	lines chan string
+}
+
+func (c *fakeIRCConn) expect(t *testing.T, want string) {
+	t.Helper()
+	select {
+	case line, ok := <-c.lines:
+		if !ok {
+			t.Fatalf("connection closed, want %q", want)
+		}
+		if line != want {
+			t.Fatalf("got %q, want %q", line, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %q", want)
+	}
+}
+
+func (c *fakeIRCConn) send(t *testing.T, line string) {
+	t.Helper()
+	if _, err := fmt.Fprintf(c.conn, "%s\r\n", line); err != nil {
+		t.Fatalf("write %q: %v", line, err)
+	}
+}
+
+// login reads the client's credentials and welcomes it after a ping
+func (c *fakeIRCConn) login(t *testing.T) {
+	t.Helper()
+	c.expect(t, "PASS oauth:secret")
+	c.expect(t, "NICK tipbot")
+	c.send(t, "PING :tmi.twitch.tv")
+	c.expect(t, "PONG :tmi.twitch.tv")
+	c.send(t, ":tmi.twitch.tv 001 tipbot :Welcome, GLHF!")
+}
+
+// newFakeIRCServer accepts plain IRC connections on a local port
+func newFakeIRCServer(t *testing.T) (string, chan *fakeIRCConn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	conns := make(chan *fakeIRCConn, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c := &fakeIRCConn{conn: conn, lines: make(chan string, 16)}
+			go func() {
+				defer conn.Close()
+				defer close(c.lines)
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					c.lines <- scanner.Text()
+				}
+			}()
+			conns <- c
+		}
+	}()
+	return "irc://" + ln.Addr().String(), conns
+}
+
+func acceptIRC(t *testing.T, conns chan *fakeIRCConn) *fakeIRCConn {
+	t.Helper()
+	select {
+	case c := <-conns:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the client to connect")
+		return nil
+	}
+}
+
+func TestIRCChatClient(t *testing.T) {
+	url, conns := newFakeIRCServer(t)
+	client, err := NewIRCChatClient(url, "TipBot", "secret")
+	if err != nil {
+		t.Fatalf("NewIRCChatClient: %v", err)
+	}
+	defer client.Close()
+
+	sent := make(chan error, 1)
+	go func() { sent <- client.Send(context.Background(), "Streamer", "alice tipped 1 ETH!\r\nPRIVMSG #other :spam") }()
+	server := acceptIRC(t, conns)
+	server.login(t)
+	server.expect(t, "JOIN #streamer")
+	// Line breaks can't smuggle in another command
+	server.expect(t, "PRIVMSG #streamer :alice tipped 1 ETH!  PRIVMSG #other :spam")
+	if err := <-sent; err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	// Joined channels are remembered and pings after the login are answered
+	server.send(t, "PING :keepalive")
+	server.expect(t, "PONG :keepalive")
+	if err := client.Send(context.Background(), "#streamer", "bob tipped 5 USDC!"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	server.expect(t, "PRIVMSG #streamer :bob tipped 5 USDC!")
+
+	// A dropped connection is replaced on the next message
+	server.conn.Close()
+	waitFor(t, "the client to notice the disconnect", func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		return client.conn == nil
+	})
+	go func() { sent <- client.Send(context.Background(), "streamer", "carol tipped 2 SOL!") }()
+	server = acceptIRC(t, conns)
+	server.login(t)
+	server.expect(t, "JOIN #streamer")
+	server.expect(t, "PRIVMSG #streamer :carol tipped 2 SOL!")
+	if err := <-sent; err != nil {
+		t.Fatalf("Send after reconnect: %v", err)
+	}
+}
+
+func TestIRCChatClientLoginFailure(t *testing.T) {
+	url, conns := newFakeIRCServer(t)
+	client, err := NewIRCChatClient(url, "tipbot", "oauth:secret")
+	if err != nil {
+		t.Fatalf("NewIRCChatClient: %v", err)
+	}
+	defer client.Close()
+
+	sent := make(chan error, 1)
+	go func() { sent <- client.Send(context.Background(), "streamer", "hi") }()
+	// Both the first attempt and the retry are refused
+	for i := 0; i < 2; i++ {
+		server := acceptIRC(t, conns)
+		server.expect(t, "PASS oauth:secret")
+		server.expect(t, "NICK tipbot")
+		server.send(t, ":tmi.twitch.tv NOTICE * :Login authentication failed")
+	}
+
+	select {
+	case err := <-sent:
+		if err == nil || !strings.Contains(err.Error(), "authentication failed") {
+			t.Fatalf("err = %v, want an authentication error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send did not return")
+	}
+}
+
+func TestNewIRCChatClient(t *testing.T) {
+	for _, url := range []string{"http://irc.example.com", "irc://", "::"} {
+		if _, err := NewIRCChatClient(url, "bot", "token"); err == nil {
+			t.Errorf("accepted %q", url)
+		}
+	}
+	if _, err := NewIRCChatClient("", "bot", ""); err == nil {
+		t.Error("accepted a missing token")
+	}
+
+	client, err := NewIRCChatClient("", "Bot", "oauth:token")
+	if err != nil {
+		t.Fatalf("NewIRCChatClient: %v", err)
+	}
+	if client.addr != "irc.chat.twitch.tv:6697" || !client.useTLS || client.nick != "bot" || client.token != "oauth:token" {
+		t.Fatalf("got %+v", client)
+	}
+}
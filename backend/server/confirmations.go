@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ConfirmationPolicy is how deep a tx must be before a tip is shown as final
+type ConfirmationPolicy struct {
+	Confirmations uint64 // Blocks including the tx's own block
+	Finalized     bool   // Wait for the chain's finality ("finalized" tag / commitment)
+}
+
+// Satisfied reports whether the observed tx meets the policy
+func (p ConfirmationPolicy) Satisfied(result *TxResult) bool {
+	if p.Finalized {
+		return result.Finalized
+	}
+	return result.Confirmations >= p.Confirmations
+}
+
+func (p ConfirmationPolicy) String() string {
+	if p.Finalized {
+		return "finalized"
+	}
+	return strconv.FormatUint(p.Confirmations, 10)
+}
+
+// Used for EVM chains without an explicit policy
+var defaultConfirmationPolicy = ConfirmationPolicy{Confirmations: 12}
+
+var defaultConfirmationPolicies = map[string]ConfirmationPolicy{
+	"1":       {Finalized: true},
+	"solana":  {Finalized: true},
+	"bitcoin": {Confirmations: 3},
+	"100003":  {Finalized: true}, // Sui: checkpointed
+}
+
+// ParseConfirmationPolicies parses "chainID=N|finalized" pairs separated by commas,
+// e.g. "1=finalized,8453=20,bitcoin=6"
+func ParseConfirmationPolicies(value string) (map[string]ConfirmationPolicy, error) {
+	policies := make(map[string]ConfirmationPolicy)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		chainID, depth, ok := strings.Cut(pair, "=")
+		if !ok || chainID == "" {
+			return nil, fmt.Errorf("invalid confirmation policy %q", pair)
+		}
+
+		if strings.EqualFold(depth, "finalized") {
+			policies[chainID] = ConfirmationPolicy{Finalized: true}
+			continue
+		}
+
+		n, err := strconv.ParseUint(depth, 10, 64)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("invalid confirmations for chain %s: %q", chainID, depth)
+		}
+		policies[chainID] = ConfirmationPolicy{Confirmations: n}
+	}
+	return policies, nil
+}
+
+// confirmationPolicy returns the configured policy for chainID, falling back to the defaults
+func (s *Service) confirmationPolicy(chainID string) ConfirmationPolicy {
+	if p, ok := s.config.ConfirmationPolicies[chainID]; ok {
+		return p
+	}
+	if p, ok := defaultConfirmationPolicies[chainID]; ok {
+		return p
+	}
+	return defaultConfirmationPolicy
+}
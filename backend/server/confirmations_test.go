@@ -0,0 +1,110 @@
+package server
+
+import "testing"
+
+func TestParseConfirmationPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]ConfirmationPolicy
+		wantErr bool
+	}{
+		{name: "empty", value: "", want: map[string]ConfirmationPolicy{}},
+		{name: "depths and finality", value: " 1=finalized, 8453=20 ,bitcoin=6,solana=FINALIZED,", want: map[string]ConfirmationPolicy{
+			"1":       {Finalized: true},
+			"8453":    {Confirmations: 20},
+			"bitcoin": {Confirmations: 6},
+			"solana":  {Finalized: true},
+		}},
+		{name: "last one wins", value: "8453=5,8453=7", want: map[string]ConfirmationPolicy{"8453": {Confirmations: 7}}},
+		{name: "missing depth", value: "8453", wantErr: true},
+		{name: "missing chain", value: "=12", wantErr: true},
+		{name: "zero", value: "8453=0", wantErr: true},
+		{name: "negative", value: "8453=-1", wantErr: true},
+		{name: "not a number", value: "8453=safe", wantErr: true},
+		{name: "one bad pair", value: "1=finalized,bitcoin=three", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConfirmationPolicies(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("accepted %q: %v", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseConfirmationPolicies: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for chainID, policy := range tt.want {
+				if got[chainID] != policy {
+					t.Errorf("%s = %v, want %v", chainID, got[chainID], policy)
+				}
+			}
+		})
+	}
+}
+
+func TestConfirmationPolicy(t *testing.T) {
+	s := newTestService()
+	s.config.ConfirmationPolicies = map[string]ConfirmationPolicy{"8453": {Confirmations: 20}}
+
+	tests := []struct {
+		chainID   string
+		policy    ConfirmationPolicy
+		satisfied []TxResult
+		waiting   []TxResult
+	}{
+		{
+			chainID:   "137", // EVM default
+			policy:    ConfirmationPolicy{Confirmations: 12},
+			satisfied: []TxResult{{Confirmations: 12}, {Confirmations: 40}},
+			waiting:   []TxResult{{Confirmations: 11}, {Confirmations: 1, Finalized: true}},
+		},
+		{
+			chainID:   "bitcoin",
+			policy:    ConfirmationPolicy{Confirmations: 3},
+			satisfied: []TxResult{{Confirmations: 3}},
+			waiting:   []TxResult{{Confirmations: 2}},
+		},
+		{
+			chainID:   "solana",
+			policy:    ConfirmationPolicy{Finalized: true},
+			satisfied: []TxResult{{Confirmations: 1, Finalized: true}},
+			waiting:   []TxResult{{Confirmations: 100}},
+		},
+		{
+			chainID:   "100003",
+			policy:    ConfirmationPolicy{Finalized: true},
+			satisfied: []TxResult{{Finalized: true}},
+			waiting:   []TxResult{{Confirmations: 1}},
+		},
+		{
+			chainID:   "8453", // Configured override
+			policy:    ConfirmationPolicy{Confirmations: 20},
+			satisfied: []TxResult{{Confirmations: 20}},
+			waiting:   []TxResult{{Confirmations: 12}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chainID, func(t *testing.T) {
+			policy := s.confirmationPolicy(tt.chainID)
+			if policy != tt.policy {
+				t.Fatalf("policy = %v, want %v", policy, tt.policy)
+			}
+			for _, result := range tt.satisfied {
+				if !policy.Satisfied(&result) {
+					t.Errorf("%+v not satisfied", result)
+				}
+			}
+			for _, result := range tt.waiting {
+				if policy.Satisfied(&result) {
+					t.Errorf("%+v satisfied", result)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,382 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+	"gorm.io/gorm"
+)
+
+const goalMaxTitleLength = 100
+
+var (
+	ErrGoalNotFound = errors.New("goal not found")
+	ErrInvalidGoal  = errors.New("invalid goal")
+)
+
+func (s *Service) GetGoals(userID uint) ([]model.GoalItem, error) {
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	goals, err := s.db.GetGoals(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.GoalItem, 0, len(goals))
+	for i := range goals {
+		item, err := s.goalItem(user.Username, &goals[i])
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+func (s *Service) GetGoal(userID, goalID uint) (*model.GoalItem, error) {
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	goal, err := s.db.GetGoal(userID, goalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGoalNotFound
+		}
+		return nil, err
+	}
+	return s.goalItem(user.Username, goal)
+}
+
+func (s *Service) CreateGoal(userID uint, req model.GoalRequest) (*model.GoalItem, error) {
+	goal := &dbmodel.Goal{UserID: userID}
+	if err := applyGoalRequest(goal, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.CreateGoal(goal); err != nil {
+		return nil, err
+	}
+
+	s.broadcastGoalProgress(userID)
+	return s.GetGoal(userID, goal.ID)
+}
+
+func (s *Service) UpdateGoal(userID, goalID uint, req model.GoalRequest) (*model.GoalItem, error) {
+	goal, err := s.db.GetGoal(userID, goalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGoalNotFound
+		}
+		return nil, err
+	}
+	if err := applyGoalRequest(goal, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.UpdateGoal(goal); err != nil {
+		return nil, err
+	}
+
+	if !goal.Active {
+		s.publishWidgetEvent(userID, model.GoalRemovedNotification{Type: "GOAL_REMOVED", GoalID: goal.ID})
+	}
+	s.broadcastGoalProgress(userID)
+	return s.GetGoal(userID, goal.ID)
+}
+
+func (s *Service) DeleteGoal(userID, goalID uint) error {
+	ok, err := s.db.DeleteGoal(userID, goalID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrGoalNotFound
+	}
+
+	s.publishWidgetEvent(userID, model.GoalRemovedNotification{Type: "GOAL_REMOVED", GoalID: goalID})
+	return nil
+}
+
+// applyGoalRequest validates req and copies it onto goal
+func applyGoalRequest(goal *dbmodel.Goal, req model.GoalRequest) error {
+	title := strings.TrimSpace(req.Title)
+	if title == "" || len([]rune(title)) > goalMaxTitleLength {
+		return fmt.Errorf("%w: title must be 1 to %d characters", ErrInvalidGoal, goalMaxTitleLength)
+	}
+	if parseThreshold(req.TargetAmount) == nil || !tipAmountRegex.MatchString(req.TargetAmount) {
+		return fmt.Errorf("%w: target amount must be a positive decimal", ErrInvalidGoal)
+	}
+
+	asset := strings.ToUpper(strings.TrimSpace(req.TargetAsset))
+	if asset == "" {
+		asset = dbmodel.GoalAssetUSD
+	}
+
+	startAt := time.Now()
+	if req.StartAt != nil {
+		startAt = *req.StartAt
+	} else if goal.ID != 0 {
+		startAt = goal.StartAt
+	}
+	if req.EndAt != nil && !req.EndAt.After(startAt) {
+		return fmt.Errorf("%w: end must be after start", ErrInvalidGoal)
+	}
+
+	goal.Title = title
+	goal.TargetAmount = req.TargetAmount
+	goal.TargetAsset = asset
+	goal.StartAt = startAt
+	goal.EndAt = req.EndAt
+	if req.Active != nil {
+		goal.Active = *req.Active
+	} else if goal.ID == 0 {
+		goal.Active = true
+	}
+	return nil
+}
+
+// goalItem adds the goal's current progress
+func (s *Service) goalItem(username string, goal *dbmodel.Goal) (*model.GoalItem, error) {
+	progress, err := s.db.GetGoalProgress(username, goal)
+	if err != nil {
+		return nil, err
+	}
+
+	current := normalizeDecimal(progress.Total)
+	if goal.TargetAsset == dbmodel.GoalAssetUSD {
+		current = usdString(progress.Total)
+	}
+
+	item := &model.GoalItem{
+		ID:           goal.ID,
+		Title:        goal.Title,
+		TargetAmount: goal.TargetAmount,
+		TargetAsset:  goal.TargetAsset,
+		Current:      current,
+		Percent:      goalPercent(progress.Total, goal.TargetAmount),
+		TipCount:     progress.TipCount,
+		StartAt:      goal.StartAt.Format(time.RFC3339),
+		Active:       goal.Active,
+	}
+	if goal.EndAt != nil {
+		item.EndAt = goal.EndAt.Format(time.RFC3339)
+	}
+	return item, nil
+}
+
+// goalPercent is current/target in percent, truncated to two decimals
+func goalPercent(current, target string) float64 {
+	t := parseRat(target)
+	if t.Sign() <= 0 {
+		return 0
+	}
+	pct, _ := new(big.Rat).Mul(new(big.Rat).Quo(parseRat(current), t), big.NewRat(100, 1)).Float64()
+	return float64(int64(pct*100)) / 100
+}
+
+// UpdateGoalProgress pushes the progress of the streamer's running goals
+// after one of their tips was confirmed
+func (s *Service) UpdateGoalProgress(tip *dbmodel.Tip) {
+	user, err := s.db.GetUserByUsername(tip.StreamerID)
+	if err != nil {
+		s.logger.Printf("Failed to find streamer %s: %v", tip.StreamerID, err)
+		return
+	}
+	s.broadcastGoalProgress(user.ID)
+}
+
+// broadcastGoalProgress sends a GOAL_PROGRESS event per running goal
+func (s *Service) broadcastGoalProgress(userID uint) {
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		s.logger.Printf("Failed to find user %d: %v", userID, err)
+		return
+	}
+	goals, err := s.db.GetActiveGoals(userID, time.Now())
+	if err != nil {
+		s.logger.Printf("Failed to list goals of user %d: %v", userID, err)
+		return
+	}
+
+	for i := range goals {
+		item, err := s.goalItem(user.Username, &goals[i])
+		if err != nil {
+			s.logger.Printf("Failed to compute progress of goal %d: %v", goals[i].ID, err)
+			continue
+		}
+		s.publishWidgetEvent(userID, goalProgressNotification(item))
+	}
+}
+
+func goalProgressNotification(item *model.GoalItem) model.GoalProgressNotification {
+	return model.GoalProgressNotification{
+		Type:         "GOAL_PROGRESS",
+		GoalID:       item.ID,
+		Title:        item.Title,
+		Current:      item.Current,
+		TargetAmount: item.TargetAmount,
+		TargetAsset:  item.TargetAsset,
+		Percent:      item.Percent,
+		TipCount:     item.TipCount,
+		Reached:      item.Percent >= 100,
+	}
+}
+
+// GetWidgetGoal returns goal goalID of the widget's streamer, or their newest
+// running goal when goalID is 0
+func (s *Service) GetWidgetGoal(user *dbmodel.User, goalID uint) (*model.GoalItem, error) {
+	if goalID != 0 {
+		return s.GetGoal(user.ID, goalID)
+	}
+
+	goals, err := s.db.GetActiveGoals(user.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(goals) == 0 {
+		return nil, ErrGoalNotFound
+	}
+	return s.goalItem(user.Username, &goals[0])
+}
+
+func (s *Server) HandleGetGoals(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	goals, err := s.service.GetGoals(claims.UserID)
+	if err != nil {
+		s.logger.Printf("Failed to fetch goals: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch goals"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"goals": goals})
+}
+
+func (s *Server) HandleGetGoal(c *gin.Context) {
+	s.handleGoal(c, func(userID, goalID uint) (any, error) {
+		return s.service.GetGoal(userID, goalID)
+	})
+}
+
+func (s *Server) HandleCreateGoal(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	var req model.GoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	goal, err := s.service.CreateGoal(claims.UserID, req)
+	if err != nil {
+		s.writeGoalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, goal)
+}
+
+func (s *Server) HandleUpdateGoal(c *gin.Context) {
+	var req model.GoalRequest
+	s.handleGoal(c, func(userID, goalID uint) (any, error) {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, fmt.Errorf("%w: invalid request body", ErrInvalidGoal)
+		}
+		return s.service.UpdateGoal(userID, goalID, req)
+	})
+}
+
+func (s *Server) HandleDeleteGoal(c *gin.Context) {
+	s.handleGoal(c, func(userID, goalID uint) (any, error) {
+		if err := s.service.DeleteGoal(userID, goalID); err != nil {
+			return nil, err
+		}
+		return gin.H{"message": "Goal deleted"}, nil
+	})
+}
+
+// handleGoal authenticates the streamer and applies action to the :id goal
+func (s *Server) handleGoal(c *gin.Context, action func(userID, goalID uint) (any, error)) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	goalID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid goal ID"})
+		return
+	}
+
+	resp, err := action(claims.UserID, uint(goalID))
+	if err != nil {
+		s.writeGoalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) writeGoalError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidGoal):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrGoalNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		s.logger.Printf("Failed to update goal: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update goal"})
+	}
+}
+
+// HandleGetWidgetGoal is the goal widget's config: its theme and the goal
+// from ?goal=, its config, or the streamer's newest running goal
+func (s *Server) HandleGetWidgetGoal(c *gin.Context) {
+	widget, user, err := s.service.ResolveWidget(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Widget not found"})
+		return
+	}
+	config := widgetConfig(widget)
+
+	goalID := uint64(config.GoalID)
+	if id := c.Query("goal"); id != "" {
+		if goalID, err = strconv.ParseUint(id, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid goal ID"})
+			return
+		}
+	}
+
+	goal, err := s.service.GetWidgetGoal(user, uint(goalID))
+	if err != nil && !errors.Is(err, ErrGoalNotFound) {
+		s.logger.Printf("Failed to fetch widget goal: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch goal"})
+		return
+	}
+
+	// A widget without a running goal stays hidden until one starts
+	c.JSON(http.StatusOK, gin.H{
+		"username":             user.Username,
+		"widget_bg_color":      config.Theme.Colors.Background,
+		"widget_user_color":    config.Theme.Colors.User,
+		"widget_amount_color":  config.Theme.Colors.Amount,
+		"widget_message_color": config.Theme.Colors.Message,
+		"widget_theme":         config.Theme,
+		"goal":                 goal,
+	})
+}
@@ -0,0 +1,299 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsSendBuffer     = 32                  // Queued messages per connection before it counts as slow
+	wsWriteWait      = 10 * time.Second    // Max time to write a single message
+	wsPongWait       = 60 * time.Second    // Max time between pongs from the client
+	wsPingPeriod     = wsPongWait * 9 / 10 // Must be shorter than wsPongWait
+	wsMaxMessageSize = 4096
+
+	widgetClosedEvent = "WIDGET_CLOSED" // Sent between replicas, never to clients
+)
+
+// Hub fans out widget events to the WebSocket connections of each user.
+// Every connection has its own buffered send queue drained by a writer
+// goroutine, so a slow OBS client never blocks broadcasts to the others;
+// clients whose queue fills up are evicted.
+type Hub struct {
+	logger *log.Logger
+
+	// OnMessage, if set, receives every message sent by a client
+	OnMessage func(c *wsClient, data []byte)
+
+	mu      sync.RWMutex
+	clients map[uint]map[*wsClient]struct{} // UserID -> connections
+}
+
+type wsClient struct {
+	hub           *Hub
+	conn          *websocket.Conn
+	userID        uint
+	widgetID      uint     // 0 for dashboards
+	subscriptions []string // Event types the widget receives, nil for all
+	send          chan []byte
+	done          chan struct{}
+	once          sync.Once
+
+	// Live events held while missed ones are replayed, see RegisterReplaying
+	mu      sync.Mutex
+	holding bool
+	held    [][]byte
+}
+
+func NewHub(logger *log.Logger) *Hub {
+	return &Hub{
+		logger:  logger,
+		clients: make(map[uint]map[*wsClient]struct{}),
+	}
+}
+
+// Register adds conn to userID's connections and starts its reader and writer.
+// The hub owns the connection from here on and closes it on disconnect.
+// Broadcasts reach it only for event types matching subscriptions (nil for all).
+func (h *Hub) Register(conn *websocket.Conn, userID, widgetID uint, subscriptions []string) *wsClient {
+	return h.register(conn, userID, widgetID, subscriptions, false)
+}
+
+// RegisterReplaying is Register for a connection catching up on missed
+// events: broadcasts are held back until EndReplay, so they neither get
+// ahead of nor duplicate the replayed ones.
+func (h *Hub) RegisterReplaying(conn *websocket.Conn, userID, widgetID uint, subscriptions []string) *wsClient {
+	return h.register(conn, userID, widgetID, subscriptions, true)
+}
+
+func (h *Hub) register(conn *websocket.Conn, userID, widgetID uint, subscriptions []string, replaying bool) *wsClient {
+	c := &wsClient{
+		hub:           h,
+		conn:          conn,
+		userID:        userID,
+		widgetID:      widgetID,
+		subscriptions: subscriptions,
+		send:          make(chan []byte, wsSendBuffer),
+		done:          make(chan struct{}),
+		holding:       replaying,
+	}
+
+	h.mu.Lock()
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[*wsClient]struct{})
+	}
+	h.clients[userID][c] = struct{}{}
+	h.mu.Unlock()
+
+	go c.writePump()
+	go c.readPump()
+	return c
+}
+
+func (h *Hub) unregister(c *wsClient) {
+	h.mu.Lock()
+	if conns, ok := h.clients[c.userID]; ok {
+		delete(conns, c)
+		if len(conns) == 0 {
+			delete(h.clients, c.userID)
+		}
+	}
+	h.mu.Unlock()
+
+	// Stop the writer, which closes the connection
+	c.once.Do(func() { close(c.done) })
+}
+
+// Broadcast queues msg (JSON encoded) for every connection of userID
+func (h *Hub) Broadcast(userID uint, msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		h.logger.Printf("Failed to encode WS message: %v", err)
+		return
+	}
+	h.BroadcastRaw(userID, data)
+}
+
+// BroadcastRaw queues an already encoded message for every connection of
+// userID subscribed to its type. WIDGET_CLOSED instead disconnects the
+// widget's connections.
+func (h *Hub) BroadcastRaw(userID uint, data []byte) {
+	var event struct {
+		Type     string `json:"type"`
+		WidgetID uint   `json:"widgetId"`
+	}
+	_ = json.Unmarshal(data, &event)
+
+	if event.Type == widgetClosedEvent {
+		h.closeWidget(userID, event.WidgetID)
+		return
+	}
+
+	var slow []*wsClient
+
+	h.mu.RLock()
+	for c := range h.clients[userID] {
+		if c.subscriptions != nil && !subscribed(c.subscriptions, event.Type) {
+			continue
+		}
+		if !c.queue(data) {
+			slow = append(slow, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range slow {
+		h.logger.Printf("Evicting slow WS client of user %d", userID)
+		h.unregister(c)
+	}
+}
+
+// closeWidget disconnects every connection of the widget
+func (h *Hub) closeWidget(userID, widgetID uint) {
+	if widgetID == 0 {
+		return
+	}
+
+	var closing []*wsClient
+	h.mu.RLock()
+	for c := range h.clients[userID] {
+		if c.widgetID == widgetID {
+			closing = append(closing, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range closing {
+		h.unregister(c)
+	}
+}
+
+// Send queues an encoded message for a single connection, waiting up to
+// wsWriteWait for room and evicting it after. It reports whether the message
+// was queued.
+func (h *Hub) Send(c *wsClient, data []byte) bool {
+	timer := time.NewTimer(wsWriteWait)
+	defer timer.Stop()
+	select {
+	case c.send <- data:
+		return true
+	case <-c.done:
+		return false
+	case <-timer.C:
+		h.logger.Printf("Evicting slow WS client of user %d", c.userID)
+		h.unregister(c)
+		return false
+	}
+}
+
+// EndReplay releases the broadcasts held since RegisterReplaying, minus the
+// events up to lastEventID the replay already sent
+func (h *Hub) EndReplay(c *wsClient, lastEventID uint64) {
+	c.mu.Lock()
+	held := c.held
+	c.held, c.holding = nil, false
+	queued := true
+	for _, data := range held {
+		var event struct {
+			EventID uint64 `json:"eventId"`
+		}
+		if json.Unmarshal(data, &event) == nil && event.EventID != 0 && event.EventID <= lastEventID {
+			continue
+		}
+		if queued = c.enqueue(data); !queued {
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	if !queued {
+		h.logger.Printf("Evicting slow WS client of user %d", c.userID)
+		h.unregister(c)
+	}
+}
+
+// queue adds a broadcast to the client's send queue, or holds it while the
+// client is replaying. It reports false when the client is too slow.
+func (c *wsClient) queue(data []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.holding {
+		if len(c.held) >= wsSendBuffer {
+			return false
+		}
+		c.held = append(c.held, data)
+		return true
+	}
+	return c.enqueue(data)
+}
+
+func (c *wsClient) enqueue(data []byte) bool {
+	select {
+	case c.send <- data:
+		return true
+	default:
+		return false
+	}
+}
+
+// ConnectionCount returns the number of open connections of userID
+func (h *Hub) ConnectionCount(userID uint) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[userID])
+}
+
+// writePump is the only goroutine writing to the connection
+func (c *wsClient) writePump() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case data := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.hub.logger.Printf("WS write error: %v", err)
+				c.hub.unregister(c)
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.hub.unregister(c)
+				return
+			}
+		case <-c.done:
+			c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteWait))
+			return
+		}
+	}
+}
+
+// readPump consumes client frames so pongs, close frames and commands are processed
+func (c *wsClient) readPump() {
+	defer c.hub.unregister(c)
+
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if c.hub.OnMessage != nil {
+			c.hub.OnMessage(c, data)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newHubServer registers every WebSocket connection with hub as widgetID of userID.
+// Server side write buffers are tiny so a client that stops reading backs
+// up after a few messages instead of a few megabytes.
+func newHubServer(t *testing.T, hub *Hub, userID, widgetID uint, subscriptions []string) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		if tcp, ok := conn.UnderlyingConn().(*net.TCPConn); ok {
+			tcp.SetWriteBuffer(4096)
+		}
+		hub.Register(conn, userID, widgetID, subscriptions)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dialHub(t *testing.T, srv *httptest.Server, readBuffer int) *websocket.Conn {
+	t.Helper()
+	dialer := websocket.Dialer{
+		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+			if tcp, ok := conn.(*net.TCPConn); ok && readBuffer > 0 {
+				tcp.SetReadBuffer(readBuffer)
+			}
+			return conn, err
+		},
+	}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHubEvictsSlowClients(t *testing.T) {
+	const (
+		userID = 7
+		fast   = 50
+		slow   = 5
+	)
+	hub := NewHub(log.New(io.Discard, "", 0))
+	srv := newHubServer(t, hub, userID, 0, nil)
+
+	received := make([]atomic.Int64, fast)
+	for i := 0; i < fast; i++ {
+		conn := dialHub(t, srv, 0)
+		go func(count *atomic.Int64) {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+				count.Add(1)
+			}
+		}(&received[i])
+	}
+	for i := 0; i < slow; i++ {
+		dialHub(t, srv, 4096) // Never read
+	}
+	waitFor(t, "all clients to register", func() bool { return hub.ConnectionCount(userID) == fast+slow })
+
+	// Keep broadcasting at the pace of the fast clients until the slow
+	// ones overflowed their queues
+	payload := strings.Repeat("x", 16*1024)
+	sent := int64(0)
+	for hub.ConnectionCount(userID) > fast {
+		if sent > 10*wsSendBuffer+1000 {
+			t.Fatalf("slow clients not evicted after %d messages, %d connections left", sent, hub.ConnectionCount(userID))
+		}
+		hub.Broadcast(userID, map[string]string{"type": "TIP", "payload": payload})
+		sent++
+		waitFor(t, "fast clients to read the broadcast", func() bool {
+			for i := range received {
+				if received[i].Load() < sent {
+					return false
+				}
+			}
+			return true
+		})
+	}
+
+	if n := hub.ConnectionCount(userID); n != fast {
+		t.Fatalf("%d connections left, want the %d fast ones", n, fast)
+	}
+
+	// The remaining clients still get every message
+	hub.Broadcast(userID, map[string]string{"type": "TIP"})
+	sent++
+	waitFor(t, "fast clients to read after the eviction", func() bool {
+		for i := range received {
+			if received[i].Load() < sent {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestHubSubscriptions(t *testing.T) {
+	hub := NewHub(log.New(io.Discard, "", 0))
+	alerts := dialHub(t, newHubServer(t, hub, 1, 0, []string{"TIP"}), 0)
+	all := dialHub(t, newHubServer(t, hub, 1, 0, nil), 0)
+	other := dialHub(t, newHubServer(t, hub, 2, 0, nil), 0)
+	waitFor(t, "clients to register", func() bool { return hub.ConnectionCount(1) == 2 && hub.ConnectionCount(2) == 1 })
+
+	hub.Broadcast(1, map[string]string{"type": "GOAL_UPDATE"})
+	hub.Broadcast(1, map[string]string{"type": "TIP"})
+
+	read := func(conn *websocket.Conn) string {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return string(data)
+	}
+	if got := read(alerts); got != `{"type":"TIP"}` {
+		t.Fatalf("TIP subscriber got %s first", got)
+	}
+	if got := read(all); got != `{"type":"GOAL_UPDATE"}` {
+		t.Fatalf("unfiltered client got %s first", got)
+	}
+	if got := read(all); got != `{"type":"TIP"}` {
+		t.Fatalf("unfiltered client got %s second", got)
+	}
+
+	other.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, data, err := other.ReadMessage(); err == nil {
+		t.Fatalf("another user's client got %s", data)
+	}
+}
+
+func TestHubClosesWidget(t *testing.T) {
+	hub := NewHub(log.New(io.Discard, "", 0))
+	closed := dialHub(t, newHubServer(t, hub, 1, 10, nil), 0)
+	kept := dialHub(t, newHubServer(t, hub, 1, 11, nil), 0)
+	dashboard := dialHub(t, newHubServer(t, hub, 1, 0, nil), 0)
+	waitFor(t, "clients to register", func() bool { return hub.ConnectionCount(1) == 3 })
+
+	// Another user's widget with the same ID is left alone
+	hub.BroadcastRaw(2, []byte(`{"type":"WIDGET_CLOSED","widgetId":10}`))
+	hub.BroadcastRaw(1, []byte(`{"type":"WIDGET_CLOSED","widgetId":10}`))
+	if n := hub.ConnectionCount(1); n != 2 {
+		t.Fatalf("%d connections left, want 2", n)
+	}
+
+	closed.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, data, err := closed.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("closed widget read %s, %v; want a close frame", data, err)
+	}
+
+	// The close command itself is never delivered
+	hub.Broadcast(1, map[string]string{"type": "TIP"})
+	for _, conn := range []*websocket.Conn{kept, dashboard} {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, data, err := conn.ReadMessage(); err != nil || string(data) != `{"type":"TIP"}` {
+			t.Fatalf("got %s, %v; want the TIP event", data, err)
+		}
+	}
+}
+
+func TestHubReplayHoldsLiveEvents(t *testing.T) {
+	const userID = 7
+	hub := NewHub(log.New(io.Discard, "", 0))
+	clients := make(chan *wsClient, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		clients <- hub.RegisterReplaying(conn, userID, 1, nil)
+	}))
+	t.Cleanup(srv.Close)
+
+	conn := dialHub(t, srv, 0)
+	client := <-clients
+
+	// Live events broadcast while the replay runs, one of them also replayed
+	hub.BroadcastRaw(userID, []byte(`{"type":"TIP","eventId":5}`))
+	hub.BroadcastRaw(userID, []byte(`{"type":"TIP","eventId":7}`))
+	hub.BroadcastRaw(userID, []byte(`{"type":"ALERT_QUEUE"}`))
+	for _, data := range []string{`{"type":"TIP","eventId":4}`, `{"type":"TIP","eventId":5}`} {
+		if !hub.Send(client, []byte(data)) {
+			t.Fatal("replayed event not queued")
+		}
+	}
+	hub.EndReplay(client, 5)
+	hub.BroadcastRaw(userID, []byte(`{"type":"TIP","eventId":8}`))
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for _, want := range []string{
+		`{"type":"TIP","eventId":4}`,
+		`{"type":"TIP","eventId":5}`,
+		`{"type":"TIP","eventId":7}`,
+		`{"type":"ALERT_QUEUE"}`,
+		`{"type":"TIP","eventId":8}`,
+	} {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if string(data) != want {
+			t.Fatalf("got %s, want %s", data, want)
+		}
+	}
+}
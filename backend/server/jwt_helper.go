@@ -7,12 +7,14 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/patiee/backend/db/model"
+	servermodel "github.com/patiee/backend/server/model"
 )
 
 // Claims for a logged-in user session
 type SessionClaims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID uint   `json:"sid,omitempty"` // UserSession the token was issued for
 	jwt.RegisteredClaims
 }
 
@@ -31,37 +33,53 @@ func (s *Service) getJWTIssuer() string {
 	return issuer
 }
 
-// GenerateSessionToken creates a standard access token for a user
-func (s *Service) GenerateSessionToken(user *model.User) (string, error) {
-	expiresAt := time.Now().Add(48 * time.Hour)
-	claims := SessionClaims{
-		UserID:   user.ID,
-		Username: user.Username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expiresAt),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    s.getJWTIssuer(),
-		},
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(s.GetJWTSecret())
+// GenerateSessionToken signs the user in on a new session from device,
+// returning a short lived access token and the session's first refresh token
+func (s *Service) GenerateSessionToken(user *model.User, device SessionDevice) (*servermodel.SessionTokens, error) {
+	refreshToken, err := newRefreshToken()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Persist to DB
 	session := &model.UserSession{
-		UserID:    user.ID,
-		Token:     tokenString,
-		ExpiresAt: expiresAt,
-		CreatedAt: time.Now(),
+		UserID:     user.ID,
+		Token:      sha256Hex(refreshToken),
+		IP:         device.IP,
+		UserAgent:  device.UserAgent,
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(refreshTokenTTL),
+		CreatedAt:  time.Now(),
+	}
+	if err := s.sessions.SaveUserSession(session); err != nil {
+		return nil, fmt.Errorf("failed to save user session: %v", err)
 	}
 
-	if err := s.db.SaveUserSession(session); err != nil {
-		return "", fmt.Errorf("failed to save user session: %v", err)
+	accessToken, err := s.generateAccessToken(user, session.ID)
+	if err != nil {
+		return nil, err
 	}
+	return &servermodel.SessionTokens{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+	}, nil
+}
 
-	return tokenString, nil
+// generateAccessToken signs an access token for the session
+func (s *Service) generateAccessToken(user *model.User, sessionID uint) (string, error) {
+	claims := SessionClaims{
+		UserID:    user.ID,
+		Username:  user.Username,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    s.getJWTIssuer(),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(s.GetJWTSecret())
 }
 
 // ValidateSessionToken parses and validates the session token
@@ -82,15 +100,29 @@ func (s *Service) ValidateSessionToken(tokenString string) (*SessionClaims, erro
 		return nil, fmt.Errorf("invalid token")
 	}
 
-	// Check DB
-	session, err := s.db.GetUserSession(tokenString)
-	if err != nil {
+	// Check DB, the session is gone once signed out or revoked.
+	// Tokens from before refresh tokens were stored as they are.
+	var session *model.UserSession
+	if claims.SessionID != 0 {
+		session, err = s.sessions.GetUserSessionByID(claims.SessionID)
+	} else {
+		session, err = s.sessions.GetUserSession(tokenString)
+	}
+	if err != nil || session.UserID != claims.UserID {
 		return nil, fmt.Errorf("session not found or expired")
 	}
 
 	if time.Now().After(session.ExpiresAt) {
 		return nil, fmt.Errorf("session expired")
 	}
+	claims.SessionID = session.ID
+
+	if time.Since(session.LastSeenAt) > sessionSeenInterval {
+		now := time.Now()
+		if err := s.sessions.TouchUserSession(session.ID, now, now.Add(-sessionSeenInterval)); err != nil {
+			s.logger.Printf("Failed to update last seen of session %d: %v", session.ID, err)
+		}
+	}
 
 	return claims, nil
 }
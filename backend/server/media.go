@@ -0,0 +1,390 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+	"gorm.io/gorm"
+)
+
+const (
+	mediaBucket      = "media"
+	mediaMaxUploads  = 50
+	mediaMaxTiers    = 20
+	mediaMaxWidth    = 1920
+	mediaMaxHeight   = 1080
+	mediaMaxFileSize = 5 << 20
+)
+
+var (
+	ErrMediaNotFound = errors.New("media not found")
+	ErrInvalidMedia  = errors.New("invalid media")
+)
+
+// Buckets served by HandleServeImage
+var storageBuckets = []string{"images", mediaBucket, ttsBucket}
+
+var mediaKinds = map[string]string{
+	"audio/mpeg": dbmodel.MediaKindAudio,
+	"audio/ogg":  dbmodel.MediaKindAudio,
+	"audio/wav":  dbmodel.MediaKindAudio,
+	"image/gif":  dbmodel.MediaKindAnimation,
+	"image/webp": dbmodel.MediaKindAnimation,
+}
+
+var mediaExtensions = map[string]string{
+	"audio/mpeg": ".mp3",
+	"audio/ogg":  ".ogg",
+	"audio/wav":  ".wav",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Upload limits per media kind
+var mediaLimits = map[string]struct {
+	MaxSize     int64
+	MaxDuration time.Duration
+}{
+	dbmodel.MediaKindAudio:     {MaxSize: 2 << 20, MaxDuration: 20 * time.Second},
+	dbmodel.MediaKindAnimation: {MaxSize: mediaMaxFileSize, MaxDuration: 20 * time.Second},
+}
+
+// storeObject uploads data to MinIO and returns its URL proxied by the backend
+func (s *Service) storeObject(ctx context.Context, bucket, name, contentType string, data []byte) (string, error) {
+	if minioClient == nil {
+		return "", errors.New("storage is not configured")
+	}
+	_, err := minioClient.PutObject(ctx, bucket, name, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return "", err
+	}
+	return s.objectURL(bucket, name), nil
+}
+
+// objectURL is where HandleServeImage serves a stored object
+func (s *Service) objectURL(bucket, name string) string {
+	return fmt.Sprintf("%s/api/images/%s/%s", s.config.BackendURL, bucket, name)
+}
+
+// UploadMedia checks an alert sound or animation by its content and stores it
+func (s *Service) UploadMedia(ctx context.Context, userID uint, filename string, data []byte) (*dbmodel.Media, error) {
+	count, err := s.db.CountMedia(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= mediaMaxUploads {
+		return nil, fmt.Errorf("%w: at most %d uploads are allowed", ErrInvalidMedia, mediaMaxUploads)
+	}
+
+	probe, err := probeMedia(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMedia, err)
+	}
+	kind := mediaKinds[probe.ContentType]
+	limits := mediaLimits[kind]
+	if int64(len(data)) > limits.MaxSize {
+		return nil, fmt.Errorf("%w: %s files must be at most %dMB", ErrInvalidMedia, kind, limits.MaxSize>>20)
+	}
+	if probe.Duration > limits.MaxDuration {
+		return nil, fmt.Errorf("%w: %s is %.1fs long, at most %s is allowed", ErrInvalidMedia, kind, probe.Duration.Seconds(), limits.MaxDuration)
+	}
+	if probe.Width > mediaMaxWidth || probe.Height > mediaMaxHeight {
+		return nil, fmt.Errorf("%w: image is %dx%d, at most %dx%d is allowed", ErrInvalidMedia, probe.Width, probe.Height, mediaMaxWidth, mediaMaxHeight)
+	}
+
+	// The extension follows the sniffed type, never the client's filename
+	name := uuid.New().String() + mediaExtensions[probe.ContentType]
+	url, err := s.storeObject(ctx, mediaBucket, name, probe.ContentType, data)
+	if err != nil {
+		return nil, err
+	}
+
+	media := &dbmodel.Media{
+		UserID:      userID,
+		Kind:        kind,
+		Name:        filepath.Base(filename),
+		URL:         url,
+		ContentType: probe.ContentType,
+		Size:        int64(len(data)),
+		DurationMs:  int(probe.Duration.Milliseconds()),
+	}
+	if err := s.db.CreateMedia(media); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+func (s *Service) GetMedia(userID uint) ([]dbmodel.Media, error) {
+	return s.db.GetMedia(userID)
+}
+
+// DeleteMedia removes an upload and frees its slot, tiers using it stop
+// playing it
+func (s *Service) DeleteMedia(ctx context.Context, userID, mediaID uint) error {
+	media, err := s.db.DeleteMedia(userID, mediaID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrMediaNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if minioClient != nil {
+		if err := minioClient.RemoveObject(ctx, mediaBucket, path.Base(media.URL), minio.RemoveObjectOptions{}); err != nil {
+			s.logger.Printf("Failed to remove media object %s: %v", media.URL, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) GetMediaTiers(userID uint) ([]model.MediaTier, error) {
+	tiers, err := s.db.GetMediaTiers(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.MediaTier, 0, len(tiers))
+	for _, tier := range tiers {
+		items = append(items, model.MediaTier{
+			MinUSD:   tier.MinUSD,
+			MaxUSD:   tier.MaxUSD,
+			SoundURL: tier.SoundURL,
+			MediaURL: tier.MediaURL,
+		})
+	}
+	return items, nil
+}
+
+// UpdateMediaTiers replaces the streamer's tiers. Tiers may not overlap and
+// only link media the streamer uploaded.
+func (s *Service) UpdateMediaTiers(userID uint, req []model.MediaTier) ([]model.MediaTier, error) {
+	if len(req) > mediaMaxTiers {
+		return nil, fmt.Errorf("%w: at most %d tiers are allowed", ErrInvalidMedia, mediaMaxTiers)
+	}
+
+	uploads, err := s.db.GetMedia(userID)
+	if err != nil {
+		return nil, err
+	}
+	kinds := make(map[string]string, len(uploads))
+	for _, media := range uploads {
+		kinds[media.URL] = media.Kind
+	}
+
+	type tierRange struct {
+		min, max *big.Rat // nil max has no upper bound
+		tier     dbmodel.MediaTier
+	}
+	ranges := make([]tierRange, 0, len(req))
+	for i, tier := range req {
+		r := tierRange{min: new(big.Rat)}
+		if tier.MinUSD != "" {
+			if !tipAmountRegex.MatchString(tier.MinUSD) {
+				return nil, fmt.Errorf("%w: tier %d: min_usd must be a decimal", ErrInvalidMedia, i+1)
+			}
+			r.min = parseRat(tier.MinUSD)
+		}
+		if tier.MaxUSD != "" {
+			if r.max = parseThreshold(tier.MaxUSD); r.max == nil || !tipAmountRegex.MatchString(tier.MaxUSD) {
+				return nil, fmt.Errorf("%w: tier %d: max_usd must be a decimal", ErrInvalidMedia, i+1)
+			}
+			if r.max.Cmp(r.min) <= 0 {
+				return nil, fmt.Errorf("%w: tier %d: max_usd must be above min_usd", ErrInvalidMedia, i+1)
+			}
+		}
+		if tier.SoundURL == "" && tier.MediaURL == "" {
+			return nil, fmt.Errorf("%w: tier %d: pick a sound or media", ErrInvalidMedia, i+1)
+		}
+		if tier.SoundURL != "" && kinds[tier.SoundURL] != dbmodel.MediaKindAudio {
+			return nil, fmt.Errorf("%w: tier %d: sound must be one of your audio uploads", ErrInvalidMedia, i+1)
+		}
+		if tier.MediaURL != "" && kinds[tier.MediaURL] != dbmodel.MediaKindAnimation {
+			return nil, fmt.Errorf("%w: tier %d: media must be one of your gif or webp uploads", ErrInvalidMedia, i+1)
+		}
+
+		r.tier = dbmodel.MediaTier{
+			UserID:   userID,
+			MinUSD:   formatDecimal(r.min),
+			SoundURL: tier.SoundURL,
+			MediaURL: tier.MediaURL,
+		}
+		if r.max != nil {
+			r.tier.MaxUSD = formatDecimal(r.max)
+		}
+		ranges = append(ranges, r)
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].min.Cmp(ranges[j].min) < 0 })
+	tiers := make([]dbmodel.MediaTier, 0, len(ranges))
+	for i, r := range ranges {
+		if i > 0 {
+			prev := ranges[i-1]
+			if prev.max == nil || prev.max.Cmp(r.min) > 0 {
+				return nil, fmt.Errorf("%w: tiers from $%s and $%s overlap", ErrInvalidMedia, prev.tier.MinUSD, r.tier.MinUSD)
+			}
+		}
+		tiers = append(tiers, r.tier)
+	}
+
+	if err := s.db.ReplaceMediaTiers(userID, tiers); err != nil {
+		return nil, err
+	}
+	return s.GetMediaTiers(userID)
+}
+
+// resolveAlertMedia returns the sound and media of the streamer's tier
+// containing usd, empty when no tier matches
+func (s *Service) resolveAlertMedia(userID uint, usd *big.Rat) (string, string) {
+	tiers, err := s.db.GetMediaTiers(userID)
+	if err != nil {
+		s.logger.Printf("Failed to fetch media tiers of user %d: %v", userID, err)
+		return "", ""
+	}
+	for _, tier := range tiers {
+		if usd.Cmp(parseRat(tier.MinUSD)) < 0 {
+			continue
+		}
+		if tier.MaxUSD != "" && usd.Cmp(parseRat(tier.MaxUSD)) >= 0 {
+			continue
+		}
+		return tier.SoundURL, tier.MediaURL
+	}
+	return "", ""
+}
+
+func (s *Server) HandleUploadMedia(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	if file.Size > mediaMaxFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("File too large: %.2fMB. Max allowed is %dMB.", float64(file.Size)/(1<<20), mediaMaxFileSize>>20)})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+		return
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, mediaMaxFileSize))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+
+	media, err := s.service.UploadMedia(c.Request.Context(), claims.UserID, file.Filename, data)
+	if err != nil {
+		s.writeMediaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, media)
+}
+
+func (s *Server) HandleGetMedia(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	media, err := s.service.GetMedia(claims.UserID)
+	if err != nil {
+		s.writeMediaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"media": media})
+}
+
+func (s *Server) HandleDeleteMedia(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	mediaID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media ID"})
+		return
+	}
+
+	if err := s.service.DeleteMedia(c.Request.Context(), claims.UserID, uint(mediaID)); err != nil {
+		s.writeMediaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Media deleted"})
+}
+
+func (s *Server) HandleGetMediaTiers(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	tiers, err := s.service.GetMediaTiers(claims.UserID)
+	if err != nil {
+		s.writeMediaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tiers": tiers})
+}
+
+func (s *Server) HandleUpdateMediaTiers(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	var req model.MediaTiersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	tiers, err := s.service.UpdateMediaTiers(claims.UserID, req.Tiers)
+	if err != nil {
+		s.writeMediaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tiers": tiers})
+}
+
+func (s *Server) writeMediaError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidMedia):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrMediaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	s.logger.Printf("Failed to update media: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update media"})
+}
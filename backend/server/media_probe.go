@@ -0,0 +1,321 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+var errMediaCorrupt = errors.New("file is corrupt or truncated")
+
+// mediaProbe is what the upload checks learn from a file's content
+type mediaProbe struct {
+	ContentType   string
+	Duration      time.Duration
+	Width, Height int // 0 for audio
+}
+
+// sniffMedia detects the content type from the file's magic bytes, ignoring
+// the client supplied type and extension
+func sniffMedia(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("ID3")), len(data) > 2 && data[0] == 0xFF && data[1]&0xE6 == 0xE2:
+		// ID3 tag or an MPEG layer III frame header
+		return "audio/mpeg"
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return "audio/ogg"
+	case len(data) >= 12 && bytes.HasPrefix(data, []byte("RIFF")) && string(data[8:12]) == "WAVE":
+		return "audio/wav"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "image/gif"
+	case len(data) >= 12 && bytes.HasPrefix(data, []byte("RIFF")) && string(data[8:12]) == "WEBP":
+		return "image/webp"
+	}
+	return ""
+}
+
+// probeMedia sniffs and measures data, returning an error for unsupported or
+// malformed files
+func probeMedia(data []byte) (*mediaProbe, error) {
+	probe := &mediaProbe{ContentType: sniffMedia(data)}
+
+	var err error
+	switch probe.ContentType {
+	case "audio/mpeg":
+		probe.Duration, err = mp3Duration(data)
+	case "audio/ogg":
+		probe.Duration, err = oggDuration(data)
+	case "audio/wav":
+		probe.Duration, err = wavDuration(data)
+	case "image/gif":
+		probe.Duration, probe.Width, probe.Height, err = gifInfo(data)
+	case "image/webp":
+		probe.Duration, probe.Width, probe.Height, err = webpInfo(data)
+	default:
+		return nil, errors.New("unsupported file type, use mp3, ogg, wav, gif or webp")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return probe, nil
+}
+
+// riffChunks calls fn with the id and payload of each chunk after the
+// 12 byte RIFF header, stopping when fn returns false
+func riffChunks(data []byte, fn func(id string, payload []byte) bool) error {
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		start := pos + 8
+		if size < 0 || start+size > len(data) {
+			return errMediaCorrupt
+		}
+		if !fn(id, data[start:start+size]) {
+			return nil
+		}
+		// Chunks are padded to an even size
+		pos = start + size + size%2
+	}
+	return nil
+}
+
+func wavDuration(data []byte) (time.Duration, error) {
+	var byteRate, dataSize int
+	err := riffChunks(data, func(id string, payload []byte) bool {
+		switch id {
+		case "fmt ":
+			if len(payload) >= 12 {
+				byteRate = int(binary.LittleEndian.Uint32(payload[8:12]))
+			}
+		case "data":
+			dataSize = len(payload)
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	if byteRate == 0 || dataSize == 0 {
+		return 0, errMediaCorrupt
+	}
+	return time.Duration(dataSize) * time.Second / time.Duration(byteRate), nil
+}
+
+// oggDuration reads the sample rate from the Vorbis or Opus header in the
+// first page and the sample count from the last page's granule position
+func oggDuration(data []byte) (time.Duration, error) {
+	if len(data) < 27 {
+		return 0, errMediaCorrupt
+	}
+	segments := int(data[26])
+	start := 27 + segments
+	if start > len(data) {
+		return 0, errMediaCorrupt
+	}
+	packet := data[start:]
+
+	var rate, preSkip uint64
+	switch {
+	case len(packet) >= 16 && bytes.HasPrefix(packet, []byte("\x01vorbis")):
+		rate = uint64(binary.LittleEndian.Uint32(packet[12:16]))
+	case len(packet) >= 12 && bytes.HasPrefix(packet, []byte("OpusHead")):
+		// Opus granules always count 48 kHz samples
+		rate = 48000
+		preSkip = uint64(binary.LittleEndian.Uint16(packet[10:12]))
+	default:
+		return 0, errors.New("unsupported ogg codec, use vorbis or opus")
+	}
+
+	last := bytes.LastIndex(data, []byte("OggS"))
+	if rate == 0 || last < 0 || last+14 > len(data) {
+		return 0, errMediaCorrupt
+	}
+	granule := binary.LittleEndian.Uint64(data[last+6 : last+14])
+	if granule < preSkip {
+		return 0, errMediaCorrupt
+	}
+	return time.Duration((granule - preSkip) * uint64(time.Second) / rate), nil
+}
+
+// Layer III bitrates in kbps by bitrate index, for MPEG-1 and MPEG-2/2.5
+var (
+	mp3BitratesV1 = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mp3BitratesV2 = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+)
+
+// mp3SampleRates by version bits (2.5, reserved, 2, 1) and sample rate index
+var mp3SampleRates = [4][3]int{
+	{11025, 12000, 8000},
+	{0, 0, 0},
+	{22050, 24000, 16000},
+	{44100, 48000, 32000},
+}
+
+// mp3Duration adds up the duration of every layer III frame, skipping an
+// ID3v2 tag and resyncing over garbage between frames
+func mp3Duration(data []byte) (time.Duration, error) {
+	pos := 0
+	if len(data) >= 10 && bytes.HasPrefix(data, []byte("ID3")) {
+		size := int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F)
+		pos = 10 + size
+		if data[5]&0x10 != 0 {
+			pos += 10 // Footer
+		}
+	}
+
+	var duration time.Duration
+	frames := 0
+	for pos+4 <= len(data) {
+		b1, b2 := data[pos+1], data[pos+2]
+		if data[pos] != 0xFF || b1&0xE0 != 0xE0 {
+			pos++
+			continue
+		}
+		version := (b1 >> 3) & 0x03
+		layer := (b1 >> 1) & 0x03
+		bitrateIndex := b2 >> 4
+		rateIndex := (b2 >> 2) & 0x03
+		if version == 1 || layer != 1 || rateIndex == 3 || bitrateIndex == 0 || bitrateIndex == 15 {
+			pos++
+			continue
+		}
+
+		rate := mp3SampleRates[version][rateIndex]
+		bitrate := mp3BitratesV2[bitrateIndex]
+		samples := 576
+		if version == 3 {
+			bitrate = mp3BitratesV1[bitrateIndex]
+			samples = 1152
+		}
+		length := samples/8*bitrate*1000/rate + int((b2>>1)&0x01)
+
+		duration += time.Duration(samples) * time.Second / time.Duration(rate)
+		frames++
+		pos += length
+	}
+
+	if frames == 0 {
+		return 0, errMediaCorrupt
+	}
+	return duration, nil
+}
+
+// gifInfo returns the duration of one loop of the animation and its size.
+// It walks the blocks and reads the frame delays without decoding pixels,
+// so a small file can't expand into gigabytes of frames.
+func gifInfo(data []byte) (time.Duration, int, int, error) {
+	if len(data) < 13 {
+		return 0, 0, 0, errMediaCorrupt
+	}
+	width := int(binary.LittleEndian.Uint16(data[6:8]))
+	height := int(binary.LittleEndian.Uint16(data[8:10]))
+	pos := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		pos += 3 << (flags&0x07 + 1) // Global color table
+	}
+
+	// skipSubBlocks moves past a chain of data sub-blocks ending with an
+	// empty one
+	skipSubBlocks := func() bool {
+		for pos < len(data) {
+			size := int(data[pos])
+			pos += 1 + size
+			if size == 0 {
+				return pos <= len(data)
+			}
+		}
+		return false
+	}
+
+	var duration time.Duration
+	frames := 0
+	delay := -1 // From the last Graphic Control Extension, -1 for none
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // Extension
+			if pos+2 > len(data) {
+				return 0, 0, 0, errMediaCorrupt
+			}
+			label := data[pos+1]
+			pos += 2
+			if label == 0xF9 && pos+4 < len(data) && data[pos] == 4 {
+				delay = int(binary.LittleEndian.Uint16(data[pos+2 : pos+4]))
+			}
+			if !skipSubBlocks() {
+				return 0, 0, 0, errMediaCorrupt
+			}
+		case 0x2C: // Image descriptor
+			if pos+11 > len(data) {
+				return 0, 0, 0, errMediaCorrupt
+			}
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (flags&0x07 + 1) // Local color table
+			}
+			pos++ // LZW minimum code size
+			if !skipSubBlocks() {
+				return 0, 0, 0, errMediaCorrupt
+			}
+			// Browsers show frames without a delay for 100ms
+			if delay <= 0 {
+				delay = 10
+			}
+			duration += time.Duration(delay) * 10 * time.Millisecond
+			frames++
+			delay = -1
+		case 0x3B: // Trailer
+			if frames == 0 || width == 0 || height == 0 {
+				return 0, 0, 0, errMediaCorrupt
+			}
+			if frames == 1 {
+				duration = 0
+			}
+			return duration, width, height, nil
+		default:
+			return 0, 0, 0, errMediaCorrupt
+		}
+	}
+	return 0, 0, 0, errMediaCorrupt
+}
+
+// webpInfo returns the duration of one loop of an animated WebP and its
+// canvas size. Still images have no duration.
+func webpInfo(data []byte) (time.Duration, int, int, error) {
+	var duration time.Duration
+	width, height := 0, 0
+	err := riffChunks(data, func(id string, payload []byte) bool {
+		switch id {
+		case "VP8X":
+			if len(payload) >= 10 {
+				width = int(payload[4]) | int(payload[5])<<8 | int(payload[6])<<16 + 1
+				height = int(payload[7]) | int(payload[8])<<8 | int(payload[9])<<16 + 1
+			}
+		case "VP8 ":
+			if width == 0 && len(payload) >= 10 {
+				width = int(binary.LittleEndian.Uint16(payload[6:8]) & 0x3FFF)
+				height = int(binary.LittleEndian.Uint16(payload[8:10]) & 0x3FFF)
+			}
+		case "VP8L":
+			if width == 0 && len(payload) >= 5 {
+				bits := binary.LittleEndian.Uint32(payload[1:5])
+				width = int(bits&0x3FFF) + 1
+				height = int(bits>>14&0x3FFF) + 1
+			}
+		case "ANMF":
+			if len(payload) >= 16 {
+				duration += time.Duration(int(payload[12])|int(payload[13])<<8|int(payload[14])<<16) * time.Millisecond
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if width == 0 || height == 0 {
+		return 0, 0, 0, errMediaCorrupt
+	}
+	return duration, width, height, nil
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+)
+
+func encodeGIF(t *testing.T, width, height int, delays []int) []byte {
+	t.Helper()
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{Delay: delays}
+	for range delays {
+		g.Image = append(g.Image, image.NewPaletted(image.Rect(0, 0, width, height), palette))
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// riffFile wraps chunks in a RIFF header of form
+func riffFile(form string, chunks ...[]byte) []byte {
+	body := []byte(form)
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	out := []byte("RIFF")
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(body)))
+	return append(out, body...)
+}
+
+func riffChunk(id string, payload []byte) []byte {
+	out := binary.LittleEndian.AppendUint32([]byte(id), uint32(len(payload)))
+	out = append(out, payload...)
+	if len(payload)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func TestProbeMedia(t *testing.T) {
+	// 16 bit mono at 8kHz: 16000 bytes a second
+	wavFmt := make([]byte, 16)
+	binary.LittleEndian.PutUint16(wavFmt[0:2], 1)
+	binary.LittleEndian.PutUint16(wavFmt[2:4], 1)
+	binary.LittleEndian.PutUint32(wavFmt[4:8], 8000)
+	binary.LittleEndian.PutUint32(wavFmt[8:12], 16000)
+	wav := riffFile("WAVE", riffChunk("fmt ", wavFmt), riffChunk("data", make([]byte, 24000)))
+
+	// Animated 640x360 canvas with frames of 400ms and 600ms
+	vp8x := make([]byte, 10)
+	vp8x[4], vp8x[5] = 0x7F, 0x02 // 639
+	vp8x[7], vp8x[8] = 0x67, 0x01 // 359
+	anmf := func(ms int) []byte {
+		payload := make([]byte, 16)
+		payload[12], payload[13] = byte(ms), byte(ms>>8)
+		return riffChunk("ANMF", payload)
+	}
+	webp := riffFile("WEBP", riffChunk("VP8X", vp8x), anmf(400), anmf(600))
+
+	tests := []struct {
+		name          string
+		data          []byte
+		contentType   string
+		duration      time.Duration
+		width, height int
+	}{
+		{name: "wav", data: wav, contentType: "audio/wav", duration: 1500 * time.Millisecond},
+		{name: "animated gif", data: encodeGIF(t, 32, 16, []int{50, 0, 25}), contentType: "image/gif", duration: 850 * time.Millisecond, width: 32, height: 16},
+		{name: "still gif", data: encodeGIF(t, 8, 8, []int{300}), contentType: "image/gif", width: 8, height: 8},
+		// The size is reported without decoding any frame
+		{name: "huge gif", data: encodeGIF(t, 4000, 3000, []int{10, 10}), contentType: "image/gif", duration: 200 * time.Millisecond, width: 4000, height: 3000},
+		{name: "animated webp", data: webp, contentType: "image/webp", duration: time.Second, width: 640, height: 360},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe, err := probeMedia(tt.data)
+			if err != nil {
+				t.Fatalf("probeMedia: %v", err)
+			}
+			if probe.ContentType != tt.contentType || probe.Duration != tt.duration || probe.Width != tt.width || probe.Height != tt.height {
+				t.Fatalf("got %+v, want %s %s %dx%d", probe, tt.contentType, tt.duration, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestProbeMediaRejects(t *testing.T) {
+	animated := encodeGIF(t, 32, 16, []int{50, 50})
+
+	tests := []struct {
+		name    string
+		data    []byte
+		corrupt bool
+	}{
+		{name: "png", data: []byte("\x89PNG\r\n\x1a\n0000000000")},
+		{name: "empty", data: nil},
+		{name: "truncated gif", data: animated[:len(animated)/2], corrupt: true},
+		{name: "gif without trailer", data: animated[:len(animated)-1], corrupt: true},
+		{name: "gif header only", data: []byte("GIF89a"), corrupt: true},
+		{name: "wav without data", data: riffFile("WAVE", riffChunk("fmt ", make([]byte, 16))), corrupt: true},
+		{name: "webp chunk past the end", data: append(riffFile("WEBP"), "VP8X\xff\x00\x00\x00"...), corrupt: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe, err := probeMedia(tt.data)
+			if err == nil {
+				t.Fatalf("accepted as %+v", probe)
+			}
+			if tt.corrupt != errors.Is(err, errMediaCorrupt) {
+				t.Fatalf("err = %v", err)
+			}
+		})
+	}
+}
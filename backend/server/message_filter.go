@@ -0,0 +1,210 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+)
+
+// Filter actions for banned words, links and over-long messages
+const (
+	FilterActionReplace = "replace" // Mask with *** (links are removed, long messages truncated)
+	FilterActionReject  = "reject"  // Refuse the tip
+)
+
+const (
+	filterMask           = "***"
+	filterMaxRepeats     = 3 // "loooool" -> "loool"
+	filterMaxBannedWords = 500
+)
+
+var (
+	ErrMessageRejected      = errors.New("message rejected")
+	ErrInvalidMessageFilter = errors.New("invalid message filter")
+)
+
+var (
+	// Explicit URLs and bare domains with a common TLD
+	linkRegex = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:com|net|org|io|gg|tv|xyz|ly|me|co|app|dev|link|live|info|ru|site|online|shop|store|click|top)\b(?:/\S*)?`)
+	wordRegex = regexp.MustCompile(`[\p{L}\p{N}]+`)
+)
+
+// MessageFilter is a streamer's tip message filter pipeline
+type MessageFilter struct {
+	BannedWords     []string // Single words, * matches any letters ("f*ck", "scam*")
+	StripLinks      bool
+	MaxLength       int // In characters, 0 for no limit
+	CollapseRepeats bool
+	Action          string
+
+	banned []*regexp.Regexp
+}
+
+func newMessageFilter(user *dbmodel.User) *MessageFilter {
+	f := &MessageFilter{
+		BannedWords:     parseBannedWords(user.FilterBannedWords),
+		StripLinks:      user.FilterStripLinks,
+		MaxLength:       user.FilterMaxLength,
+		CollapseRepeats: user.FilterCollapseRepeats,
+		Action:          user.FilterAction,
+	}
+	for _, word := range f.BannedWords {
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(word), `\*`, `[\p{L}\p{N}]*`)
+		f.banned = append(f.banned, regexp.MustCompile(`(?i)^`+pattern+`$`))
+	}
+	return f
+}
+
+// parseBannedWords splits a comma or newline separated list
+func parseBannedWords(list string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" && strings.Trim(w, "*") != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// Apply runs the pipeline: collapse repeats, links, banned words, max length.
+// It returns the filtered message, or ErrMessageRejected in reject mode.
+func (f *MessageFilter) Apply(msg string) (string, error) {
+	reject := f.Action == FilterActionReject
+
+	if f.CollapseRepeats {
+		msg = collapseRepeats(msg, filterMaxRepeats)
+	}
+
+	if f.StripLinks && linkRegex.MatchString(msg) {
+		if reject {
+			return "", fmt.Errorf("%w: links are not allowed", ErrMessageRejected)
+		}
+		msg = strings.Join(strings.Fields(linkRegex.ReplaceAllString(msg, "")), " ")
+	}
+
+	if len(f.banned) > 0 {
+		found := false
+		msg = wordRegex.ReplaceAllStringFunc(msg, func(word string) string {
+			for _, re := range f.banned {
+				if re.MatchString(word) {
+					found = true
+					return filterMask
+				}
+			}
+			return word
+		})
+		if found && reject {
+			return "", fmt.Errorf("%w: contains a banned word", ErrMessageRejected)
+		}
+	}
+
+	if f.MaxLength > 0 && utf8.RuneCountInString(msg) > f.MaxLength {
+		if reject {
+			return "", fmt.Errorf("%w: longer than %d characters", ErrMessageRejected, f.MaxLength)
+		}
+		msg = strings.TrimRightFunc(string([]rune(msg)[:f.MaxLength]), unicode.IsSpace)
+	}
+
+	return msg, nil
+}
+
+// collapseRepeats limits runs of the same character to max
+func collapseRepeats(s string, max int) string {
+	var b strings.Builder
+	var prev rune
+	run := 0
+	for _, r := range s {
+		if r == prev {
+			run++
+		} else {
+			prev, run = r, 1
+		}
+		if run <= max {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (s *Service) GetMessageFilter(userID uint) (*model.MessageFilterSettings, error) {
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.MessageFilterSettings{
+		BannedWords:     parseBannedWords(user.FilterBannedWords),
+		StripLinks:      user.FilterStripLinks,
+		MaxLength:       user.FilterMaxLength,
+		CollapseRepeats: user.FilterCollapseRepeats,
+		Action:          user.FilterAction,
+	}, nil
+}
+
+func (s *Service) UpdateMessageFilter(userID uint, req model.MessageFilterSettings) error {
+	if req.Action != FilterActionReplace && req.Action != FilterActionReject {
+		return fmt.Errorf("%w: action must be %q or %q", ErrInvalidMessageFilter, FilterActionReplace, FilterActionReject)
+	}
+	if req.MaxLength < 0 {
+		return fmt.Errorf("%w: max length must not be negative", ErrInvalidMessageFilter)
+	}
+	words := parseBannedWords(strings.Join(req.BannedWords, "\n"))
+	if len(words) > filterMaxBannedWords {
+		return fmt.Errorf("%w: at most %d banned words are allowed", ErrInvalidMessageFilter, filterMaxBannedWords)
+	}
+
+	return s.db.UpdateMessageFilter(userID, &dbmodel.User{
+		FilterBannedWords:     strings.Join(words, "\n"),
+		FilterStripLinks:      req.StripLinks,
+		FilterMaxLength:       req.MaxLength,
+		FilterCollapseRepeats: req.CollapseRepeats,
+		FilterAction:          req.Action,
+	})
+}
+
+func (s *Server) HandleGetMessageFilter(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	settings, err := s.service.GetMessageFilter(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (s *Server) HandleUpdateMessageFilter(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	var req model.MessageFilterSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := s.service.UpdateMessageFilter(claims.UserID, req); err != nil {
+		if errors.Is(err, ErrInvalidMessageFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.Printf("Failed to update message filter: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update message filter"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message filter updated"})
+}
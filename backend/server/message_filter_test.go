@@ -0,0 +1,85 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	dbmodel "github.com/patiee/backend/db/model"
+)
+
+func TestMessageFilterApply(t *testing.T) {
+	replace := func(u dbmodel.User) dbmodel.User { u.FilterAction = FilterActionReplace; return u }
+	reject := func(u dbmodel.User) dbmodel.User { u.FilterAction = FilterActionReject; return u }
+	banned := dbmodel.User{FilterBannedWords: "Scam*, f*ck\nrug"}
+	links := dbmodel.User{FilterStripLinks: true}
+	short := dbmodel.User{FilterMaxLength: 5}
+
+	tests := []struct {
+		name     string
+		user     dbmodel.User
+		msg      string
+		want     string
+		rejected bool
+	}{
+		{name: "no filter", user: replace(dbmodel.User{}), msg: "visit scam.com lol", want: "visit scam.com lol"},
+
+		// Banned words match whole words, ignoring case, * for any letters
+		{name: "banned word", user: replace(banned), msg: "this is a RUG pull", want: "this is a *** pull"},
+		{name: "wildcard suffix", user: replace(banned), msg: "Scammers everywhere", want: "*** everywhere"},
+		{name: "wildcard infix", user: replace(banned), msg: "what the fck, fuck", want: "what the ***, ***"},
+		{name: "inside another word", user: replace(banned), msg: "drug trugs rugby", want: "drug trugs rugby"},
+		{name: "next to punctuation", user: replace(banned), msg: "rug!rug?(rug)", want: "***!***?(***)"},
+		{name: "unicode words", user: replace(dbmodel.User{FilterBannedWords: "żółw"}), msg: "ŻÓŁW żółwik", want: "*** żółwik"},
+		{name: "banned word rejected", user: reject(banned), msg: "rug", rejected: true},
+		{name: "clean message in reject mode", user: reject(banned), msg: "gg wp", want: "gg wp"},
+
+		// Links
+		{name: "url", user: replace(links), msg: "check https://evil.example/x?y=1 now", want: "check now"},
+		{name: "www", user: replace(links), msg: "www.example.org please", want: "please"},
+		{name: "bare domain", user: replace(links), msg: "go to free-eth.xyz/claim!", want: "go to"},
+		{name: "subdomain", user: replace(links), msg: "mint at app.scam.io", want: "mint at"},
+		{name: "no link", user: replace(links), msg: "3.14 is not a link. ok.", want: "3.14 is not a link. ok."},
+		{name: "link rejected", user: reject(links), msg: "twitch.tv/streamer", rejected: true},
+
+		// Length in characters, not bytes
+		{name: "short enough", user: replace(short), msg: "hello", want: "hello"},
+		{name: "truncated", user: replace(short), msg: "hello world", want: "hello"},
+		{name: "truncated multibyte", user: replace(short), msg: "żółwie", want: "żółwi"},
+		{name: "truncated emoji", user: replace(short), msg: "🚀🚀🚀🚀🚀🚀", want: "🚀🚀🚀🚀🚀"},
+		{name: "trailing space trimmed", user: replace(short), msg: "abcd efg", want: "abcd"},
+		{name: "multibyte within limit", user: reject(short), msg: "żółwi", want: "żółwi"},
+		{name: "too long rejected", user: reject(short), msg: "żółwie", rejected: true},
+
+		// Pipeline order: repeats are collapsed before the length is checked
+		{name: "collapse repeats", user: replace(dbmodel.User{FilterCollapseRepeats: true}), msg: "loooool!!!!!", want: "loool!!!"},
+		{name: "collapse then length", user: reject(dbmodel.User{FilterCollapseRepeats: true, FilterMaxLength: 5}), msg: "aaaaaaab", want: "aaab"},
+		{name: "everything", user: replace(dbmodel.User{FilterBannedWords: "rug", FilterStripLinks: true, FilterMaxLength: 12}), msg: "RUG at rug.com soooo bad", want: "*** at soooo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newMessageFilter(&tt.user).Apply(tt.msg)
+			if tt.rejected {
+				if !errors.Is(err, ErrMessageRejected) {
+					t.Fatalf("Apply(%q) = %q, %v, want ErrMessageRejected", tt.msg, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Apply(%q) = %q, %v, want %q", tt.msg, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBannedWords(t *testing.T) {
+	got := parseBannedWords(" Scam ,\r\nRUG,,*, ** ,\nf*ck ")
+	want := []string{"scam", "rug", "f*ck"}
+	if len(got) != len(want) {
+		t.Fatalf("parseBannedWords = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("parseBannedWords = %q, want %q", got, want)
+		}
+	}
+}
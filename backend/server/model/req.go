@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type TipRequest struct {
 	StreamerID          string `json:"streamerId" binding:"required"`
 	Sender              string `json:"sender" binding:"required"`
@@ -17,6 +19,16 @@ type TipRequest struct {
 	EnableENSTwitter    bool   `json:"enableEnsTwitter"`
 }
 
+// AuthExchangeRequest swaps the code from the OAuth callback redirect
+type AuthExchangeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RefreshRequest rotates, or on logout revokes, a session's refresh token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type SignupRequest struct {
 	Username              string `json:"username"`
 	SignupToken           string `json:"signup_token"`
@@ -42,6 +54,16 @@ type UpdateWidgetRequest struct {
 	MessageColor string `json:"message_color"`
 }
 
+// WidgetRequest creates or updates a widget. Type can't be changed, omitted
+// fields keep their current value.
+type WidgetRequest struct {
+	Type          string        `json:"type"`
+	Name          string        `json:"name"`
+	Config        *WidgetConfig `json:"config"`
+	Enabled       *bool         `json:"enabled"`
+	Subscriptions []string      `json:"subscriptions"` // Event types, TIP* matches a prefix. Empty for the type's defaults.
+}
+
 type UpdateWalletRequest struct {
 	WalletAddress         string `json:"wallet_address" binding:"required"`
 	PreferredChainID      int64  `json:"preferred_chain_id"`
@@ -58,3 +80,82 @@ type UpdateProfileRequest struct {
 	UseEnsDescription bool   `json:"use_ens_description"`
 	UseEnsUsername    bool   `json:"use_ens_username"`
 }
+
+type MessageFilterSettings struct {
+	BannedWords     []string `json:"banned_words"`
+	StripLinks      bool     `json:"strip_links"`
+	MaxLength       int      `json:"max_length"`
+	CollapseRepeats bool     `json:"collapse_repeats"`
+	Action          string   `json:"action"` // replace or reject
+}
+
+// TipRulesSettings holds decimal strings, empty for no minimum
+type TipRulesSettings struct {
+	MinAmounts    map[string]string `json:"min_amounts"` // Asset symbol to minimum amount
+	MinUSD        string            `json:"min_usd"`
+	MessageMinUSD string            `json:"message_min_usd"`
+	TTSMinUSD     string            `json:"tts_min_usd"`
+	MediaMinUSD   string            `json:"media_min_usd"`
+}
+
+// GoalRequest creates or updates a goal. TargetAsset defaults to USD,
+// StartAt to now and Active to true for new goals, updates keep the
+// current StartAt and Active when omitted.
+type GoalRequest struct {
+	Title        string     `json:"title" binding:"required"`
+	TargetAmount string     `json:"target_amount" binding:"required"`
+	TargetAsset  string     `json:"target_asset"`
+	StartAt      *time.Time `json:"start_at"`
+	EndAt        *time.Time `json:"end_at"`
+	Active       *bool      `json:"active"`
+}
+
+type AlertSettingsRequest struct {
+	RequireApproval bool `json:"require_approval"`
+}
+
+// WidgetCommand is a message sent by an overlay over the widget WebSocket
+type WidgetCommand struct {
+	Type    string `json:"type"` // ALERT_DONE, the queue is moderated over the REST API
+	AlertID uint   `json:"alertId"`
+}
+
+// MediaTier links tips worth MinUSD up to MaxUSD (exclusive, empty for no
+// limit) to an uploaded sound and animation
+type MediaTier struct {
+	MinUSD   string `json:"min_usd"`
+	MaxUSD   string `json:"max_usd"`
+	SoundURL string `json:"sound_url"`
+	MediaURL string `json:"media_url"`
+}
+
+// MediaTiersRequest replaces all of the streamer's tiers
+type MediaTiersRequest struct {
+	Tiers []MediaTier `json:"tiers"`
+}
+
+// TTSSettings is the streamer's server side TTS voice. ServerTTS is read
+// only and reports whether the backend renders TTS at all.
+type TTSSettings struct {
+	Voice     string `json:"voice"`
+	Language  string `json:"language"`
+	Rate      int    `json:"rate"` // Words per minute
+	ServerTTS bool   `json:"server_tts"`
+}
+
+// ChatSettings configures tip announcements in the streamer's Twitch chat.
+// Channel and Available are read only.
+type ChatSettings struct {
+	Enabled   bool   `json:"enabled"`
+	Template  string `json:"template"` // Placeholders as in widget themes, e.g. {sender}
+	Channel   string `json:"channel"`
+	Available bool   `json:"available"` // Whether the backend has a bot account
+}
+
+// WebhookRequest creates or updates a webhook, omitted fields keep their
+// current value
+type WebhookRequest struct {
+	URL     string   `json:"url"`
+	Events  []string `json:"events"` // e.g. tip.confirmed, all tip events for a new webhook
+	Enabled *bool    `json:"enabled"`
+}
@@ -6,27 +6,204 @@ type TipsResponse struct {
 }
 
 type TipResponseItem struct {
-	CreatedAt   string `json:"created_at"`
-	Sender      string `json:"sender"`
-	Message     string `json:"message"`
-	Amount      string `json:"amount"`
-	Asset       string `json:"asset"`
-	TxHash      string `json:"tx_hash"`
-	SourceChain string `json:"source_chain"`
-	Status      string `json:"status"`
+	CreatedAt       string `json:"created_at"`
+	Sender          string `json:"sender"`
+	Message         string `json:"message"`
+	OriginalMessage string `json:"original_message,omitempty"` // Before the message filter
+	Amount          string `json:"amount"`
+	Asset           string `json:"asset"`
+	USDValue        string `json:"usd_value,omitempty"`
+	TxHash          string `json:"tx_hash"`
+	SourceChain     string `json:"source_chain"`
+	DestChain       string `json:"dest_chain"`
+	DestTxHash      string `json:"dest_tx_hash,omitempty"`
+	Status          string `json:"status"`
+}
+
+type StatsResponse struct {
+	TipCount int64        `json:"tip_count"`
+	TotalUSD string       `json:"total_usd"`
+	Totals   []AssetTotal `json:"totals"`
+}
+
+type AssetTotal struct {
+	Asset    string `json:"asset"`
+	ChainID  string `json:"chain_id"`
+	Count    int64  `json:"count"`
+	Total    string `json:"total"`
+	Average  string `json:"average"`
+	TotalUSD string `json:"total_usd"`
+}
+
+type TimeSeriesResponse struct {
+	Interval string            `json:"interval"`
+	Points   []TimeSeriesPoint `json:"points"`
+}
+
+type TimeSeriesPoint struct {
+	Bucket   string `json:"bucket"`
+	Asset    string `json:"asset"`
+	Count    int64  `json:"count"`
+	Total    string `json:"total"`
+	TotalUSD string `json:"total_usd"`
+}
+
+type TopSendersResponse struct {
+	By      string      `json:"by"`
+	Senders []TopSender `json:"senders"`
+}
+
+type TopSender struct {
+	Address string `json:"address"`
+	Sender  string `json:"sender"`
+	Asset   string `json:"asset,omitempty"`
+	Count   int64  `json:"count"`
+	Total   string `json:"total,omitempty"`
+}
+
+type AlertQueueResponse struct {
+	Paused          bool        `json:"paused"`
+	RequireApproval bool        `json:"require_approval"`
+	Alerts          []AlertItem `json:"alerts"`
+}
+
+type AlertItem struct {
+	ID               uint   `json:"id"`
+	TipID            uint   `json:"tip_id"`
+	Status           string `json:"status"`
+	RequiresApproval bool   `json:"requires_approval"`
+	Sender           string `json:"sender"`
+	Message          string `json:"message"`
+	OriginalMessage  string `json:"original_message,omitempty"`
+	Amount           string `json:"amount"`
+	Asset            string `json:"asset"`
+	USDValue         string `json:"usd_value,omitempty"`
+	CreatedAt        string `json:"created_at"`
+}
+
+// AlertQueueNotification tells widgets and dashboards that the alert queue changed
+type AlertQueueNotification struct {
+	Type             string `json:"type"` // ALERT_QUEUE
+	Paused           bool   `json:"paused"`
+	RequireApproval  bool   `json:"requireApproval"`
+	PlayingAlertID   uint   `json:"playingAlertId,omitempty"`
+	Queued           int64  `json:"queued"`           // Waiting to play, including those awaiting approval
+	AwaitingApproval int64  `json:"awaitingApproval"` // Queued alerts that need approval
+}
+
+// AlertSkipNotification tells the overlay to stop showing an alert
+type AlertSkipNotification struct {
+	Type    string `json:"type"` // ALERT_SKIP
+	AlertID uint   `json:"alertId"`
+}
+
+// WidgetConfig is a widget's stored JSON config
+type WidgetConfig struct {
+	TTS    bool         `json:"tts_enabled"`
+	Theme  *WidgetTheme `json:"theme,omitempty"`   // Omitted in requests to keep the current theme
+	GoalID uint         `json:"goal_id,omitempty"` // Goal widgets: the goal shown, 0 for the newest running one
+}
+
+type WidgetItem struct {
+	ID            uint         `json:"id"`
+	Type          string       `json:"type"`
+	Name          string       `json:"name"`
+	Token         string       `json:"token"`
+	Config        WidgetConfig `json:"config"`
+	Enabled       bool         `json:"enabled"`
+	Subscriptions []string     `json:"subscriptions"` // Effective, including type defaults
+	CreatedAt     string       `json:"created_at"`
+}
+
+type GoalItem struct {
+	ID           uint    `json:"id"`
+	Title        string  `json:"title"`
+	TargetAmount string  `json:"target_amount"`
+	TargetAsset  string  `json:"target_asset"` // USD or an asset symbol
+	Current      string  `json:"current"`
+	Percent      float64 `json:"percent"` // May exceed 100
+	TipCount     int64   `json:"tip_count"`
+	StartAt      string  `json:"start_at"`
+	EndAt        string  `json:"end_at,omitempty"`
+	Active       bool    `json:"active"`
+}
+
+// GoalProgressNotification tells goal widgets a goal's progress changed
+type GoalProgressNotification struct {
+	Type         string  `json:"type"` // GOAL_PROGRESS
+	GoalID       uint    `json:"goalId"`
+	Title        string  `json:"title"`
+	Current      string  `json:"current"`
+	TargetAmount string  `json:"targetAmount"`
+	TargetAsset  string  `json:"targetAsset"`
+	Percent      float64 `json:"percent"`
+	TipCount     int64   `json:"tipCount"`
+	Reached      bool    `json:"reached"`
+}
+
+// GoalRemovedNotification tells goal widgets to stop showing a deleted or paused goal
+type GoalRemovedNotification struct {
+	Type   string `json:"type"` // GOAL_REMOVED
+	GoalID uint   `json:"goalId"`
+}
+
+// WidgetClosedNotification makes every replica disconnect a widget whose
+// token was rotated, or that was disabled or deleted
+type WidgetClosedNotification struct {
+	Type     string `json:"type"` // WIDGET_CLOSED
+	WidgetID uint   `json:"widgetId"`
 }
 
 type TipNotification struct {
 	Type          string `json:"type"`
+	AlertID       uint   `json:"alertId,omitempty"` // Report ALERT_DONE with it once shown
+	EventID       uint64 `json:"eventId,omitempty"` // Monotonic per backend, for ?since= catch-up
+	Replay        bool   `json:"replay,omitempty"`  // Re-sent on the streamer's request
+	TipID         uint   `json:"tipId,omitempty"`
+	Status        string `json:"status,omitempty"` // e.g. seen, bridging, confirmed
 	StreamerID    string `json:"streamerId"`
 	Sender        string `json:"sender"`
 	Message       string `json:"message"`
 	Amount        string `json:"amount"`
+	Asset         string `json:"asset,omitempty"`
+	USDValue      string `json:"usdValue,omitempty"` // Set once the tip is confirmed and priced
+	ShowMessage   bool   `json:"showMessage"`        // Per the streamer's tip rules
+	TTS           bool   `json:"tts"`
+	ShowMedia     bool   `json:"showMedia"`
+	SoundURL      string `json:"soundUrl,omitempty"` // From the streamer's media tier for the tip's USD value
+	MediaURL      string `json:"mediaUrl,omitempty"`
+	TTSAudioURL   string `json:"ttsAudioUrl,omitempty"` // Message rendered by the server, browser speech when empty
 	AvatarURL     string `json:"avatarUrl"`
 	BackgroundURL string `json:"backgroundUrl"`
 	TwitterHandle string `json:"twitterHandle"`
 }
 
+// SessionTokens sign a user in. The access token is sent as the bearer
+// token, the refresh token is swapped for new tokens before it expires.
+type SessionTokens struct {
+	Token        string `json:"token"` // Access token
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // Seconds until the access token expires
+}
+
+// SessionItem is a signed in device of the user
+type SessionItem struct {
+	ID         uint   `json:"id"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"user_agent"`
+	Current    bool   `json:"current"` // The session making the request
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+// AuthExchangeResponse has session tokens for an existing user, otherwise
+// a signup token to finish registering the provider account
+type AuthExchangeResponse struct {
+	*SessionTokens
+	SignupToken string `json:"signup_token,omitempty"`
+}
+
 type UserProfile struct {
 	ID     string
 	Email  string
@@ -64,3 +241,51 @@ type TikTokResp struct {
 		Message string `json:"message"`
 	} `json:"error"`
 }
+
+type WebhookItem struct {
+	ID        uint     `json:"id"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret"`
+	Events    []string `json:"events"`
+	Enabled   bool     `json:"enabled"`
+	CreatedAt string   `json:"created_at"`
+}
+
+type WebhookDeliveryItem struct {
+	ID             uint   `json:"id"`
+	EventID        string `json:"event_id"`
+	Event          string `json:"event"`
+	Status         string `json:"status"` // pending, delivered or failed
+	Attempts       int    `json:"attempts"`
+	ResponseStatus int    `json:"response_status,omitempty"`
+	Error          string `json:"error,omitempty"`
+	DurationMs     int64  `json:"duration_ms"`
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"`
+	DeliveredAt    string `json:"delivered_at,omitempty"`
+	CreatedAt      string `json:"created_at"`
+}
+
+// WebhookEvent is the JSON body POSTed to webhooks
+type WebhookEvent struct {
+	ID        string         `json:"id"`   // Same for every delivery and retry of the event
+	Type      string         `json:"type"` // tip.pending, tip.confirmed, tip.failed or webhook.test
+	CreatedAt string         `json:"created_at"`
+	Data      WebhookTipData `json:"data"`
+}
+
+type WebhookTipData struct {
+	TipID       uint   `json:"tip_id"`
+	Streamer    string `json:"streamer"`
+	Sender      string `json:"sender"`
+	Message     string `json:"message"` // Empty when hidden by the streamer's tip rules
+	Amount      string `json:"amount"`
+	Asset       string `json:"asset"`
+	USDValue    string `json:"usd_value,omitempty"` // Set once confirmed
+	ChainID     string `json:"chain_id"`
+	TxHash      string `json:"tx_hash"`
+	SourceChain string `json:"source_chain,omitempty"`
+	DestChain   string `json:"dest_chain,omitempty"`
+	DestTxHash  string `json:"dest_tx_hash,omitempty"`
+	Status      string `json:"status"`
+	CreatedAt   string `json:"created_at"`
+}
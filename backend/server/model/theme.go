@@ -0,0 +1,50 @@
+package model
+
+// WidgetTheme is the look of a widget, exported and imported as JSON.
+// Version 1 was the five color strings of UpdateWidgetRequest.
+type WidgetTheme struct {
+	Version      int            `json:"version"`
+	Colors       ThemeColors    `json:"colors"`
+	Font         ThemeFont      `json:"font"`
+	Layout       ThemeLayout    `json:"layout"`
+	Animation    ThemeAnimation `json:"animation"`
+	Sound        ThemeSound     `json:"sound"`
+	ImageURL     string         `json:"image_url"`     // Shown instead of the sender's avatar, empty for the avatar
+	TextTemplate string         `json:"text_template"` // Headline, e.g. "{sender} tipped {amount} {asset}"
+}
+
+type ThemeColors struct {
+	Background string `json:"background"`
+	User       string `json:"user"`
+	Amount     string `json:"amount"`
+	Message    string `json:"message"`
+}
+
+type ThemeFont struct {
+	Family string `json:"family"`
+	Size   int    `json:"size"`   // px
+	Weight int    `json:"weight"` // 100 to 900
+}
+
+type ThemeLayout struct {
+	Position string `json:"position"` // top, center or bottom of the overlay
+	Align    string `json:"align"`    // left, center or right
+	Width    int    `json:"width"`    // px
+}
+
+type ThemeAnimation struct {
+	In         string `json:"in"`
+	Out        string `json:"out"`
+	DurationMs int    `json:"duration_ms"` // How long an alert stays, at least until TTS finishes
+}
+
+type ThemeSound struct {
+	URL    string `json:"url"` // Played when the alert appears, empty for none
+	Volume int    `json:"volume"`
+}
+
+type ThemePreset struct {
+	ID    string      `json:"id"`
+	Name  string      `json:"name"`
+	Theme WidgetTheme `json:"theme"`
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	dbmodel "github.com/patiee/backend/db/model"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oauthStateTTL       = 10 * time.Minute
+	oauthStateCookie    = "oauth_state"
+	oauthMaxRedirectLen = 200
+)
+
+// Providers that accept PKCE (S256) from a confidential web client
+var pkceProviders = map[string]bool{
+	"google": true,
+}
+
+var ErrInvalidOAuthState = errors.New("invalid oauth state")
+
+// oauthStateStore keeps the states of OAuth flows in progress
+type oauthStateStore interface {
+	CreateOAuthState(state *dbmodel.OAuthState) error
+	ConsumeOAuthState(stateHash string) (*dbmodel.OAuthState, error)
+}
+
+// sha256Hex is how states and auth codes are stored, never in the clear
+func sha256Hex(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// safeRedirectPath returns path if it is a path on the frontend, otherwise
+// fallback. Absolute and protocol relative URLs are rejected so the flow
+// can't be used as an open redirect.
+func safeRedirectPath(path, fallback string) string {
+	if path == "" || len(path) > oauthMaxRedirectLen || !strings.HasPrefix(path, "/") ||
+		strings.HasPrefix(path, "//") || strings.ContainsAny(path, "\\\r\n\t") {
+		return fallback
+	}
+	u, err := url.Parse(path)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.User != nil {
+		return fallback
+	}
+	return path
+}
+
+// frontendURL returns the frontend URL of path with params added to its query
+func (s *Service) frontendURL(path string, params url.Values) string {
+	u, err := url.Parse(path)
+	if err != nil {
+		u = &url.URL{Path: "/"}
+	}
+	query := u.Query()
+	for key, values := range params {
+		for _, v := range values {
+			query.Add(key, v)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return strings.TrimRight(s.config.FrontendURL, "/") + u.String()
+}
+
+// newOAuthState starts an OAuth flow for provider, returning the random state
+// to send and the options adding the PKCE challenge where supported.
+// linkUserID links the provider to that user instead of logging in.
+func (s *Service) newOAuthState(provider string, linkUserID uint, redirectPath string) (string, []oauth2.AuthCodeOption, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	state := base64.RawURLEncoding.EncodeToString(b)
+
+	record := &dbmodel.OAuthState{
+		StateHash:    sha256Hex(state),
+		Provider:     provider,
+		LinkUserID:   linkUserID,
+		RedirectPath: redirectPath,
+		ExpiresAt:    time.Now().Add(oauthStateTTL),
+	}
+	var opts []oauth2.AuthCodeOption
+	if pkceProviders[provider] {
+		record.CodeVerifier = oauth2.GenerateVerifier()
+		opts = append(opts, oauth2.S256ChallengeOption(record.CodeVerifier))
+	}
+
+	if err := s.oauthStates.CreateOAuthState(record); err != nil {
+		return "", nil, fmt.Errorf("failed to store oauth state: %v", err)
+	}
+	return state, opts, nil
+}
+
+// consumeOAuthState checks the state the provider sent back and uses it up
+func (s *Service) consumeOAuthState(provider, state string) (*dbmodel.OAuthState, error) {
+	if state == "" {
+		return nil, fmt.Errorf("%w: missing state", ErrInvalidOAuthState)
+	}
+	record, err := s.oauthStates.ConsumeOAuthState(sha256Hex(state))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown or already used state", ErrInvalidOAuthState)
+	}
+	if record.Provider != provider {
+		return nil, fmt.Errorf("%w: state was issued for %s", ErrInvalidOAuthState, record.Provider)
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return nil, fmt.Errorf("%w: state expired", ErrInvalidOAuthState)
+	}
+	return record, nil
+}
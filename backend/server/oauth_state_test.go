@@ -0,0 +1,173 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbmodel "github.com/patiee/backend/db/model"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// memOAuthStateStore keeps OAuth states like the database does, in memory
+type memOAuthStateStore struct {
+	mu     sync.Mutex
+	states map[string]*dbmodel.OAuthState
+}
+
+func (st *memOAuthStateStore) CreateOAuthState(state *dbmodel.OAuthState) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.states[state.StateHash] = state
+	return nil
+}
+
+func (st *memOAuthStateStore) ConsumeOAuthState(stateHash string) (*dbmodel.OAuthState, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	state, ok := st.states[stateHash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	delete(st.states, stateHash)
+	return state, nil
+}
+
+func TestSafeRedirectPath(t *testing.T) {
+	tests := map[string]string{
+		"/me/settings":                 "/me/settings",
+		"/tip/alice?amount=1#message":  "/tip/alice?amount=1#message",
+		"":                             "/fallback",
+		"me/settings":                  "/fallback",
+		"//evil.com":                   "/fallback",
+		"///evil.com":                  "/fallback",
+		`/\evil.com`:                   "/fallback",
+		`/\/evil.com`:                  "/fallback",
+		"https://evil.com":             "/fallback",
+		"javascript:alert(1)":          "/fallback",
+		"/\t/evil.com":                 "/fallback",
+		"/\r\nLocation: https://x.com": "/fallback",
+		"/" + strings.Repeat("a", oauthMaxRedirectLen): "/fallback",
+	}
+	for path, want := range tests {
+		if got := safeRedirectPath(path, "/fallback"); got != want {
+			t.Errorf("safeRedirectPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// oauthCallback calls back from provider with state, sending cookie as the
+// state cookie, and returns where the browser is redirected
+func oauthCallback(t *testing.T, s *Service, provider, state, cookie string) *url.URL {
+	t.Helper()
+	query := url.Values{"state": {state}, "error": {"access_denied"}} // Stops before the code exchange
+	req := httptest.NewRequest(http.MethodGet, "/auth/"+provider+"/callback?"+query.Encode(), nil)
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: oauthStateCookie, Value: cookie})
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	s.HandleOAuthCallback(c, provider)
+
+	if w.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("status %d, want a redirect", w.Code)
+	}
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location
+}
+
+func TestConsumeOAuthStateMissing(t *testing.T) {
+	s := newTestService()
+	s.oauthStates = &memOAuthStateStore{states: map[string]*dbmodel.OAuthState{sha256Hex(""): {Provider: "google", ExpiresAt: time.Now().Add(time.Hour)}}}
+	if _, err := s.consumeOAuthState("google", ""); !errors.Is(err, ErrInvalidOAuthState) {
+		t.Fatalf("err = %v, want ErrInvalidOAuthState", err)
+	}
+}
+
+func TestHandleOAuthCallbackState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if googleConfig == nil {
+		googleConfig = &oauth2.Config{}
+		defer func() { googleConfig = nil }()
+	}
+	if twitchConfig == nil {
+		twitchConfig = &oauth2.Config{}
+		defer func() { twitchConfig = nil }()
+	}
+
+	tests := []struct {
+		name     string
+		provider string                    // Provider the callback is for
+		edit     func(*dbmodel.OAuthState) // Changes the stored state
+		state    string                    // Sent state, "" for the issued one
+		cookie   string                    // Sent cookie, "" for the issued state
+		noCookie bool
+		want     string // Error the browser is sent back with, invalid_state if empty
+		wantPath string // Page it is sent back to, /auth if empty
+	}{
+		{name: "issued to this browser", provider: "google", want: "oauth_denied"},
+		{name: "linking from this browser", provider: "google", edit: func(st *dbmodel.OAuthState) { st.LinkUserID = 7 }, want: "oauth_denied", wantPath: "/tip/alice"},
+		{name: "cookie from another flow", provider: "google", cookie: "other-state"},
+		{name: "no cookie", provider: "google", noCookie: true},
+		{name: "unknown state", provider: "google", state: "guessed", cookie: "guessed"},
+		{name: "issued for another provider", provider: "twitch"},
+		{name: "expired", provider: "google", edit: func(st *dbmodel.OAuthState) { st.ExpiresAt = time.Now().Add(-time.Second) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService()
+			s.config.FrontendURL = "https://example.com"
+			store := &memOAuthStateStore{states: map[string]*dbmodel.OAuthState{}}
+			s.oauthStates = store
+
+			issued, _, err := s.newOAuthState("google", 0, "/tip/alice")
+			if err != nil {
+				t.Fatalf("newOAuthState: %v", err)
+			}
+			if tt.edit != nil {
+				tt.edit(store.states[sha256Hex(issued)])
+			}
+			state, cookie := issued, issued
+			if tt.state != "" {
+				state = tt.state
+			}
+			if tt.cookie != "" {
+				cookie = tt.cookie
+			}
+			if tt.noCookie {
+				cookie = ""
+			}
+
+			want, wantPath := tt.want, tt.wantPath
+			if want == "" {
+				want = "invalid_state"
+			}
+			if wantPath == "" {
+				wantPath = "/auth"
+			}
+			location := oauthCallback(t, s, tt.provider, state, cookie)
+			if location.Host != "example.com" || location.Path != wantPath || location.Query().Get("error") != want {
+				t.Fatalf("redirected to %s, want %s?error=%s", location, wantPath, want)
+			}
+
+			// The state can't be used again, whatever the outcome
+			if len(store.states) != 0 && tt.state == "" {
+				t.Fatal("state was not used up")
+			}
+			if location := oauthCallback(t, s, tt.provider, state, cookie); location.Query().Get("error") != "invalid_state" {
+				t.Fatalf("reused state redirected to %s", location)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	dbmodel "github.com/patiee/backend/db/model"
+)
+
+const (
+	defaultPriceAPIURL = "https://api.coingecko.com/api/v3"
+	priceCacheTTL      = 5 * time.Minute
+)
+
+var ErrPriceUnavailable = errors.New("price unavailable")
+
+// PriceOracle quotes the current USD price of a token on a chain. token is
+// the contract / mint / coin type, empty for the chain's native asset.
+// Symbols are never priced, since any token can claim any symbol.
+type PriceOracle interface {
+	USDPrice(ctx context.Context, chainID, token string) (*big.Rat, error)
+}
+
+// PriceKey identifies a token for pricing, e.g. "8453:native" or
+// "8453:0x833589fcd6edb6e08f4c7c32d4f71b54bda02913"
+func PriceKey(chainID, token string) string {
+	if token == "" {
+		return chainID + ":native"
+	}
+	if _, evm := ChainRPCs[chainID]; evm {
+		token = strings.ToLower(token)
+	}
+	return chainID + ":" + token
+}
+
+// priceToken resolves an asset as stored on tips (the symbol of the native
+// or a well-known token, otherwise the token itself) to the token to price
+func priceToken(chainID, asset string) string {
+	if symbol, _, ok := nativeAsset(chainID); ok && strings.EqualFold(asset, symbol) {
+		return ""
+	}
+	for token, symbol := range tokenSymbols(chainID) {
+		if strings.EqualFold(asset, symbol) {
+			return token
+		}
+	}
+	return asset
+}
+
+// StaticPriceOracle serves fixed prices, e.g. loaded from a file for offline use
+type StaticPriceOracle struct {
+	prices map[string]*big.Rat // By PriceKey
+}
+
+func NewStaticPriceOracle(prices map[string]*big.Rat) *StaticPriceOracle {
+	return &StaticPriceOracle{prices: prices}
+}
+
+func (o *StaticPriceOracle) USDPrice(ctx context.Context, chainID, token string) (*big.Rat, error) {
+	price, ok := o.prices[PriceKey(chainID, token)]
+	if !ok {
+		return nil, fmt.Errorf("%w: no static price for %s", ErrPriceUnavailable, PriceKey(chainID, token))
+	}
+	return price, nil
+}
+
+// LoadPriceFile reads a JSON object of USD prices per chain and token
+// ("native" for the chain's own asset), e.g.
+// {"8453": {"native": "3000.5", "0x833589fcd6edb6e08f4c7c32d4f71b54bda02913": "1"}}
+func LoadPriceFile(path string) (map[string]*big.Rat, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse price file: %v", err)
+	}
+
+	prices := make(map[string]*big.Rat)
+	for chainID, tokens := range raw {
+		for token, value := range tokens {
+			price, ok := new(big.Rat).SetString(value)
+			if !ok || price.Sign() < 0 {
+				return nil, fmt.Errorf("invalid price %q for %s on chain %s", value, token, chainID)
+			}
+			if token == "native" {
+				token = ""
+			}
+			prices[PriceKey(chainID, token)] = price
+		}
+	}
+	return prices, nil
+}
+
+// CoinGecko IDs for the symbols of native and well-known tokens
+var coingeckoIDs = map[string]string{
+	"ETH":   "ethereum",
+	"WETH":  "weth",
+	"BTC":   "bitcoin",
+	"WBTC":  "wrapped-bitcoin",
+	"SOL":   "solana",
+	"SUI":   "sui",
+	"USDC":  "usd-coin",
+	"USDT":  "tether",
+	"DAI":   "dai",
+	"XDAI":  "xdai",
+	"BNB":   "binancecoin",
+	"POL":   "polygon-ecosystem-token",
+	"AVAX":  "avalanche-2",
+	"METIS": "metis-token",
+	"FUSE":  "fuse-network-token",
+	"GLMR":  "moonbeam",
+	"SEI":   "sei-network",
+	"FLR":   "flare-networks",
+	"S":     "sonic-3",
+	"MON":   "monad",
+	"HYPE":  "hyperliquid",
+	"BONK":  "bonk",
+	"JUP":   "jupiter-exchange-solana",
+}
+
+type cachedPrice struct {
+	price     *big.Rat
+	fetchedAt time.Time
+}
+
+// HTTPPriceOracle quotes prices from a CoinGecko compatible /simple/price API
+type HTTPPriceOracle struct {
+	baseURL string
+	apiKey  string
+
+	mu    sync.Mutex
+	cache map[string]cachedPrice
+}
+
+func NewHTTPPriceOracle(baseURL, apiKey string) *HTTPPriceOracle {
+	if baseURL == "" {
+		baseURL = defaultPriceAPIURL
+	}
+	return &HTTPPriceOracle{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		cache:   make(map[string]cachedPrice),
+	}
+}
+
+func (o *HTTPPriceOracle) USDPrice(ctx context.Context, chainID, token string) (*big.Rat, error) {
+	// Only tokens on the allowlists have a trusted symbol
+	asset, _, ok := nativeAsset(chainID)
+	if token != "" {
+		if _, evm := ChainRPCs[chainID]; evm {
+			token = strings.ToLower(token)
+		}
+		asset, ok = tokenSymbols(chainID)[token]
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown token %s", ErrPriceUnavailable, PriceKey(chainID, token))
+	}
+	id, ok := coingeckoIDs[strings.ToUpper(asset)]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown asset %s", ErrPriceUnavailable, asset)
+	}
+
+	o.mu.Lock()
+	cached, ok := o.cache[id]
+	o.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < priceCacheTTL {
+		return cached.price, nil
+	}
+
+	query := url.Values{"ids": {id}, "vs_currencies": {"usd"}, "precision": {"full"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.baseURL+"/simple/price?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("x-cg-demo-api-key", o.apiKey)
+	}
+
+	resp, err := verifierHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("price API error: %d", resp.StatusCode)
+	}
+
+	var result map[string]map[string]json.Number
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	value, ok := result[id]["usd"]
+	if !ok {
+		return nil, fmt.Errorf("%w: no USD quote for %s", ErrPriceUnavailable, asset)
+	}
+	price, ok := new(big.Rat).SetString(value.String())
+	if !ok {
+		return nil, fmt.Errorf("invalid price %q for %s", value, asset)
+	}
+
+	o.mu.Lock()
+	o.cache[id] = cachedPrice{price: price, fetchedAt: time.Now()}
+	o.mu.Unlock()
+
+	return price, nil
+}
+
+func newPriceOracle(config Config) PriceOracle {
+	if len(config.StaticPrices) > 0 {
+		return NewStaticPriceOracle(config.StaticPrices)
+	}
+	return NewHTTPPriceOracle(config.PriceAPIURL, config.PriceAPIKey)
+}
+
+// SetPriceOracle replaces the price provider (e.g. with a StaticPriceOracle)
+func (s *Service) SetPriceOracle(o PriceOracle) {
+	s.prices = o
+}
+
+// usdValue converts a decimal amount of asset on chainID to USD, rounded to cents
+func (s *Service) usdValue(ctx context.Context, chainID, amount, asset string) (string, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return "", fmt.Errorf("invalid amount %q", amount)
+	}
+
+	price, err := s.prices.USDPrice(ctx, chainID, priceToken(chainID, asset))
+	if err != nil {
+		return "", err
+	}
+	return value.Mul(value, price).FloatString(2), nil
+}
+
+// valueTip stores the USD value of the tip at the current price.
+// Pricing is best effort and never blocks a confirmation.
+func (s *Service) valueTip(ctx context.Context, tip *dbmodel.Tip) {
+	chainID, amount, asset := tipPayment(tip)
+	value, err := s.usdValue(ctx, chainID, amount, asset)
+	if err != nil {
+		s.logger.Printf("Failed to price tip %d (%s %s): %v", tip.ID, amount, asset, err)
+		return
+	}
+
+	tip.USDValue = value
+	if err := s.db.UpdateTipUSDValue(tip.ID, value); err != nil {
+		s.logger.Printf("Failed to save USD value for tip %d: %v", tip.ID, err)
+	}
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const baseUSDC = "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"
+
+func TestStaticPriceOracle(t *testing.T) {
+	o := NewStaticPriceOracle(map[string]*big.Rat{
+		PriceKey("8453", ""):       big.NewRat(3000, 1),
+		PriceKey("8453", baseUSDC): big.NewRat(1, 1),
+		PriceKey("solana", ""):     big.NewRat(150, 1),
+	})
+
+	tests := []struct {
+		name    string
+		chainID string
+		token   string
+		want    *big.Rat
+	}{
+		{name: "native", chainID: "8453", want: big.NewRat(3000, 1)},
+		{name: "token, any address case", chainID: "8453", token: "0x833589fcd6edb6e08f4c7c32d4f71b54bda02913", want: big.NewRat(1, 1)},
+		{name: "non-EVM native", chainID: "solana", want: big.NewRat(150, 1)},
+		{name: "same token on another chain", chainID: "1", token: baseUSDC},
+		{name: "unknown token", chainID: "8453", token: "0x2222222222222222222222222222222222222222"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, err := o.USDPrice(context.Background(), tt.chainID, tt.token)
+			if tt.want == nil {
+				if !errors.Is(err, ErrPriceUnavailable) {
+					t.Fatalf("err = %v, want ErrPriceUnavailable", err)
+				}
+				return
+			}
+			if err != nil || price.Cmp(tt.want) != 0 {
+				t.Fatalf("got %v, %v; want %s", price, err, tt.want.FloatString(2))
+			}
+		})
+	}
+}
+
+func TestLoadPriceFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	if err := os.WriteFile(path, []byte(`{"8453": {"native": "3000.5", "`+baseUSDC+`": "1"}, "solana": {"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v": "0.999"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	prices, err := LoadPriceFile(path)
+	if err != nil {
+		t.Fatalf("LoadPriceFile: %v", err)
+	}
+	if len(prices) != 3 {
+		t.Fatalf("got %d prices, want 3", len(prices))
+	}
+	if p := prices[PriceKey("8453", "")]; p == nil || p.FloatString(1) != "3000.5" {
+		t.Fatalf("native price = %v", p)
+	}
+	// Solana mints are case sensitive and kept as is
+	if p := prices["solana:EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"]; p == nil {
+		t.Fatal("missing Solana USDC price")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"8453": {"native": "-1"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPriceFile(path); err == nil {
+		t.Fatal("accepted a negative price")
+	}
+}
+
+func TestPriceToken(t *testing.T) {
+	tests := []struct {
+		chainID, asset, want string
+	}{
+		{"8453", "ETH", ""},
+		{"137", "POL", ""},
+		{"8453", "USDC", "0x833589fcd6edb6e08f4c7c32d4f71b54bda02913"},
+		{"solana", "SOL", ""},
+		{"solana", "USDC", "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"},
+		// Unknown tokens are stored by address and priced by it
+		{"8453", "0x2222222222222222222222222222222222222222", "0x2222222222222222222222222222222222222222"},
+		{"8453", "PEPE", "PEPE"},
+	}
+	for _, tt := range tests {
+		if got := priceToken(tt.chainID, tt.asset); got != tt.want {
+			t.Errorf("priceToken(%s, %s) = %q, want %q", tt.chainID, tt.asset, got, tt.want)
+		}
+	}
+}
+
+func TestHTTPPriceOracle(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Query().Get("ids") {
+		case "ethereum":
+			io.WriteString(w, `{"ethereum": {"usd": 3000.25}}`)
+		case "usd-coin":
+			io.WriteString(w, `{"usd-coin": {"usd": 1}}`)
+		default:
+			t.Errorf("unexpected ids %q", r.URL.Query().Get("ids"))
+			io.WriteString(w, `{}`)
+		}
+	}))
+	defer srv.Close()
+	o := NewHTTPPriceOracle(srv.URL, "")
+
+	price, err := o.USDPrice(context.Background(), "8453", "")
+	if err != nil || price.FloatString(2) != "3000.25" {
+		t.Fatalf("native price = %v, %v", price, err)
+	}
+	if _, err := o.USDPrice(context.Background(), "42161", ""); err != nil {
+		t.Fatalf("cached price: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("%d requests, want the second quote served from cache", requests)
+	}
+
+	if price, err := o.USDPrice(context.Background(), "8453", baseUSDC); err != nil || price.Cmp(big.NewRat(1, 1)) != 0 {
+		t.Fatalf("USDC price = %v, %v", price, err)
+	}
+
+	// A token calling itself USDC is not on the allowlist
+	if _, err := o.USDPrice(context.Background(), "8453", "0x2222222222222222222222222222222222222222"); !errors.Is(err, ErrPriceUnavailable) {
+		t.Fatalf("unknown token: err = %v, want ErrPriceUnavailable", err)
+	}
+	if _, err := o.USDPrice(context.Background(), "unknown", ""); !errors.Is(err, ErrPriceUnavailable) {
+		t.Fatalf("unknown chain: err = %v, want ErrPriceUnavailable", err)
+	}
+}
+
+func TestUSDValue(t *testing.T) {
+	s := newTestService()
+	s.prices = NewStaticPriceOracle(map[string]*big.Rat{
+		PriceKey("8453", ""):       big.NewRat(3000, 1),
+		PriceKey("8453", baseUSDC): big.NewRat(1, 1),
+	})
+
+	tests := []struct {
+		chainID, amount, asset, want string
+	}{
+		{"8453", "0.5", "ETH", "1500.00"},
+		{"8453", "12.345", "USDC", "12.35"},
+		{"8453", "10", baseUSDC, "10.00"},
+	}
+	for _, tt := range tests {
+		got, err := s.usdValue(context.Background(), tt.chainID, tt.amount, tt.asset)
+		if err != nil || got != tt.want {
+			t.Errorf("usdValue(%s %s on %s) = %q, %v; want %q", tt.amount, tt.asset, tt.chainID, got, err, tt.want)
+		}
+	}
+
+	if _, err := s.usdValue(context.Background(), "8453", "1", "0x2222222222222222222222222222222222222222"); !errors.Is(err, ErrPriceUnavailable) {
+		t.Fatalf("unknown token: err = %v, want ErrPriceUnavailable", err)
+	}
+}
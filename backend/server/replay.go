@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+	"gorm.io/gorm"
+)
+
+const (
+	widgetReplayWindow = 6 * time.Hour // Older missed alerts are not replayed
+	widgetReplayLimit  = 50
+)
+
+var ErrNoAlertToReplay = errors.New("no alert to replay")
+
+// recordWidgetEvent stores the notification and stamps it with its event ID
+func (s *Service) recordWidgetEvent(userID uint, n *model.TipNotification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	event := &dbmodel.WidgetEvent{
+		UserID:  userID,
+		Type:    n.Type,
+		TipID:   n.TipID,
+		Payload: string(payload),
+	}
+	if err := s.db.CreateWidgetEvent(event); err != nil {
+		return err
+	}
+	n.EventID = event.ID
+	return nil
+}
+
+func decodeWidgetEvent(event *dbmodel.WidgetEvent) (*model.TipNotification, error) {
+	var n model.TipNotification
+	if err := json.Unmarshal([]byte(event.Payload), &n); err != nil {
+		return nil, err
+	}
+	n.EventID = event.ID
+	return &n, nil
+}
+
+// ReplayMissedAlerts sends the confirmed tip alerts after sinceID to a widget
+// registered with RegisterReplaying, then lets its live events through
+func (s *Service) ReplayMissedAlerts(client *wsClient, userID uint, sinceID uint64) {
+	lastID := sinceID
+	defer func() { s.hub.EndReplay(client, lastID) }()
+
+	events, err := s.db.GetWidgetEventsSince(userID, "TIP", sinceID, time.Now().Add(-widgetReplayWindow), widgetReplayLimit)
+	if err != nil {
+		s.logger.Printf("Failed to load missed alerts for user %d: %v", userID, err)
+		return
+	}
+
+	for i := range events {
+		n, err := decodeWidgetEvent(&events[i])
+		if err != nil {
+			s.logger.Printf("Invalid widget event %d: %v", events[i].ID, err)
+			continue
+		}
+		data, err := json.Marshal(n)
+		if err != nil {
+			continue
+		}
+		if !s.hub.Send(client, data) {
+			return
+		}
+		lastID = n.EventID
+	}
+
+	if len(events) > 0 {
+		s.logger.Printf("Replayed %d missed alerts for user %d since event %d", len(events), userID, sinceID)
+	}
+}
+
+// ReplayLastAlert shows the streamer's most recent tip alert again on all widgets
+func (s *Service) ReplayLastAlert(userID uint) error {
+	event, err := s.db.GetLastWidgetEvent(userID, "TIP")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoAlertToReplay
+		}
+		return err
+	}
+
+	n, err := decodeWidgetEvent(event)
+	if err != nil {
+		return err
+	}
+	// The original alert was played already, the overlay shows the replay
+	// outside the queue and doesn't report it done
+	n.Replay = true
+	n.AlertID = 0
+	s.publishWidgetEvent(userID, n)
+	return nil
+}
+
+// parseSinceEventID reads the ?since= reconnect parameter, 0 when absent
+func parseSinceEventID(c *gin.Context) (uint64, bool) {
+	since := c.Query("since")
+	if since == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseUint(since, 10, 64)
+	return id, err == nil
+}
+
+func (s *Server) HandleReplayLastAlert(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	if err := s.service.ReplayLastAlert(claims.UserID); err != nil {
+		if errors.Is(err, ErrNoAlertToReplay) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.Printf("Failed to replay last alert: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replay alert"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert replayed"})
+}
@@ -2,15 +2,19 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"image"
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
 	"log"
+	"math/big"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -29,7 +33,6 @@ var (
 	googleConfig *oauth2.Config
 	twitchConfig *oauth2.Config
 	tiktokConfig *oauth2.Config
-	oauthState   = "random-string-verification" // In prod, use random state per request
 	minioClient  *minio.Client
 )
 
@@ -51,29 +54,30 @@ func (s *Server) InitMinIO() {
 		return
 	}
 
-	// Ensure bucket exists
-	bucketName := "images"
+	// Ensure buckets exist
 	ctx := context.Background()
-	exists, err := minioClient.BucketExists(ctx, bucketName)
-	if err != nil {
-		s.logger.Printf("Failed to check if bucket exists: %v", err)
-		return
-	}
-
-	if !exists {
-		err = minioClient.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{})
+	for _, bucketName := range storageBuckets {
+		exists, err := minioClient.BucketExists(ctx, bucketName)
 		if err != nil {
-			s.logger.Printf("Failed to create bucket: %v", err)
+			s.logger.Printf("Failed to check if bucket exists: %v", err)
 			return
 		}
-		s.logger.Printf("Created bucket: %s", bucketName)
 
-		// Set Public Policy
-		policy := fmt.Sprintf(`{"Version": "2012-10-17","Statement": [{"Action": ["s3:GetObject"],"Effect": "Allow","Principal": {"AWS": ["*"]},"Resource": ["arn:aws:s3:::%s/*"]}]}`, bucketName)
-		err = minioClient.SetBucketPolicy(ctx, bucketName, policy)
-		if err != nil {
-			s.logger.Printf("Failed to set bucket policy: %v", err)
-			return
+		if !exists {
+			err = minioClient.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{})
+			if err != nil {
+				s.logger.Printf("Failed to create bucket: %v", err)
+				return
+			}
+			s.logger.Printf("Created bucket: %s", bucketName)
+
+			// Set Public Policy
+			policy := fmt.Sprintf(`{"Version": "2012-10-17","Statement": [{"Action": ["s3:GetObject"],"Effect": "Allow","Principal": {"AWS": ["*"]},"Resource": ["arn:aws:s3:::%s/*"]}]}`, bucketName)
+			err = minioClient.SetBucketPolicy(ctx, bucketName, policy)
+			if err != nil {
+				s.logger.Printf("Failed to set bucket policy: %v", err)
+				return
+			}
 		}
 	}
 	s.logger.Println("MinIO initialized successfully")
@@ -88,8 +92,34 @@ func (s *Server) Start(port string) {
 	// Initialize MinIO
 	s.InitMinIO()
 
+	// Move widget settings from before the widgets table into widgets
+	if err := s.service.MigrateWidgets(); err != nil {
+		s.logger.Fatalf("Failed to migrate widgets: %v", err)
+	}
+
+	// Deliver widget events published by any replica
+	s.service.StartBroadcaster(context.Background())
+
+	// Play queued tip alerts
+	s.service.StartAlertQueue(context.Background())
+
+	// Announce confirmed tips in Twitch chat
+	s.service.StartChatAnnouncer(context.Background())
+
+	// Resume and process tip verifications
+	s.service.StartVerificationWorkers(context.Background())
+
+	// Deliver queued webhook events
+	s.service.StartWebhookWorkers(context.Background())
+
 	r := gin.Default()
 
+	// ClientIP only reads X-Forwarded-For from these proxies, nil trusts none
+	// and uses the connection's address
+	if err := r.SetTrustedProxies(s.config.TrustedProxies); err != nil {
+		s.logger.Fatalf("Invalid trusted proxies: %v", err)
+	}
+
 	// CORS
 	if s.config.CORSEnabled {
 		r.Use(func(c *gin.Context) {
@@ -116,20 +146,74 @@ func (s *Server) Start(port string) {
 	r.GET("/auth/tiktok/callback", func(c *gin.Context) { s.service.HandleOAuthCallback(c, "tiktok") })
 
 	// Link Routes
-	r.GET("/auth/google/link", func(c *gin.Context) { s.service.HandleOAuthLogin(c, "google") })
-	r.GET("/auth/twitch/link", func(c *gin.Context) { s.service.HandleOAuthLogin(c, "twitch") })
-	r.GET("/auth/tiktok/link", func(c *gin.Context) { s.service.HandleOAuthLogin(c, "tiktok") })
+	r.POST("/auth/google/link", func(c *gin.Context) { s.service.HandleOAuthLink(c, "google") })
+	r.POST("/auth/twitch/link", func(c *gin.Context) { s.service.HandleOAuthLink(c, "twitch") })
+	r.POST("/auth/tiktok/link", func(c *gin.Context) { s.service.HandleOAuthLink(c, "tiktok") })
 
 	// API Routes
 	api := r.Group("/api")
 	{
 		api.POST("/auth/signup", s.HandleSignup)
 		api.POST("/auth/login", s.HandleLogin)
+		api.POST("/auth/exchange", s.HandleAuthExchange)
+		api.POST("/auth/refresh", s.HandleRefreshSession)
+		api.POST("/auth/logout", s.HandleLogout)
+		api.GET("/auth/wallet/nonce", s.HandleWalletNonce)
 		api.POST("/auth/wallet/login", func(c *gin.Context) { s.service.HandleWalletLogin(c) })
 
 		api.GET("/me", s.HandleMe)
 		api.PUT("/me/profile", s.HandleUpdateProfile)
 		api.GET("/me/tips", s.HandleGetTips)
+		api.GET("/me/stats", s.HandleGetStats)
+		api.GET("/me/stats/timeseries", s.HandleGetStatsTimeSeries)
+		api.GET("/me/stats/top-senders", s.HandleGetTopSenders)
+
+		api.GET("/me/message-filter", s.HandleGetMessageFilter)
+		api.PUT("/me/message-filter", s.HandleUpdateMessageFilter)
+		api.GET("/me/tip-rules", s.HandleGetTipRules)
+		api.PUT("/me/tip-rules", s.HandleUpdateTipRules)
+		api.GET("/me/goals", s.HandleGetGoals)
+		api.POST("/me/goals", s.HandleCreateGoal)
+		api.GET("/me/goals/:id", s.HandleGetGoal)
+		api.PUT("/me/goals/:id", s.HandleUpdateGoal)
+		api.DELETE("/me/goals/:id", s.HandleDeleteGoal)
+		api.GET("/me/widgets", s.HandleGetWidgets)
+		api.POST("/me/widgets", s.HandleCreateWidget)
+		api.PUT("/me/widgets/:id", s.HandleUpdateWidgetByID)
+		api.DELETE("/me/widgets/:id", s.HandleDeleteWidget)
+		api.POST("/me/widgets/:id/regenerate", s.HandleRegenerateWidgetToken)
+		api.GET("/me/widgets/:id/theme", s.HandleExportWidgetTheme)
+		api.PUT("/me/widgets/:id/theme", s.HandleImportWidgetTheme)
+		api.GET("/widget-themes/presets", s.HandleGetThemePresets)
+		api.GET("/me/media", s.HandleGetMedia)
+		api.POST("/me/media", s.HandleUploadMedia)
+		api.DELETE("/me/media/:id", s.HandleDeleteMedia)
+		api.GET("/me/media-tiers", s.HandleGetMediaTiers)
+		api.PUT("/me/media-tiers", s.HandleUpdateMediaTiers)
+		api.GET("/me/tts", s.HandleGetTTSSettings)
+		api.PUT("/me/tts", s.HandleUpdateTTSSettings)
+		api.GET("/me/chat", s.HandleGetChatSettings)
+		api.PUT("/me/chat", s.HandleUpdateChatSettings)
+		api.GET("/me/webhooks", s.HandleGetWebhooks)
+		api.POST("/me/webhooks", s.HandleCreateWebhook)
+		api.PUT("/me/webhooks/:id", s.HandleUpdateWebhook)
+		api.DELETE("/me/webhooks/:id", s.HandleDeleteWebhook)
+		api.POST("/me/webhooks/:id/secret", s.HandleRotateWebhookSecret)
+		api.POST("/me/webhooks/:id/test", s.HandleTestWebhook)
+		api.GET("/me/webhooks/:id/deliveries", s.HandleGetWebhookDeliveries)
+		api.POST("/me/webhooks/:id/deliveries/:delivery/redeliver", s.HandleRedeliverWebhook)
+		api.GET("/me/sessions", s.HandleGetSessions)
+		api.DELETE("/me/sessions/:id", s.HandleRevokeSession)
+		api.POST("/me/sessions/revoke-others", s.HandleRevokeOtherSessions)
+
+		api.GET("/me/alerts", s.HandleGetAlertQueue)
+		api.PUT("/me/alerts/settings", s.HandleUpdateAlertSettings)
+		api.POST("/me/alerts/pause", s.HandlePauseAlerts)
+		api.POST("/me/alerts/resume", s.HandleResumeAlerts)
+		api.POST("/me/alerts/skip", s.HandleSkipAlert)
+		api.POST("/me/alerts/:id/skip", s.HandleSkipAlert)
+		api.POST("/me/alerts/:id/approve", s.HandleApproveAlert)
+		api.POST("/me/alerts/:id/reject", s.HandleRejectAlert)
 
 		api.GET("/user/:username", s.HandleGetUser)
 
@@ -137,7 +221,9 @@ func (s *Server) Start(port string) {
 
 		api.PUT("/widget", s.HandleUpdateWidget)
 		api.POST("/widget/regenerate", s.HandleRegenerateWidget)
+		api.POST("/widget/replay", s.HandleReplayLastAlert)
 		api.GET("/widget/:token/config", s.HandleGetWidgetConfig)
+		api.GET("/widget/:token/goal", s.HandleGetWidgetGoal)
 
 		api.POST("/tips", s.HandleTip)
 
@@ -152,6 +238,7 @@ func (s *Server) Start(port string) {
 	}
 
 	// WS
+	r.GET("/ws/me", s.HandleDashboardWS)
 	r.GET("/ws/:streamerId", s.HandleWS)
 
 	if s.config.CertFile != "" && s.config.KeyFile != "" {
@@ -272,7 +359,7 @@ func (s *Server) HandleServeImage(c *gin.Context) {
 	filename := c.Param("filename")
 
 	// Security: Only allow specific buckets
-	if bucket != "images" {
+	if !slices.Contains(storageBuckets, bucket) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -313,6 +400,7 @@ type Config struct {
 	CertFile           string
 	KeyFile            string
 	CORSEnabled        bool
+	TrustedProxies     []string // IPs or CIDRs of reverse proxies setting X-Forwarded-For
 	MinIOEndpoint      string
 	MinIOAccessKeyID   string
 	MinIOSecretKey     string
@@ -320,6 +408,34 @@ type Config struct {
 	EthRPCURL          string
 	FrontendURL        string
 	BackendURL         string
+
+	// Bridge status provider
+	LifiAPIURL string
+	LifiAPIKey string
+
+	// Per chain overrides of defaultConfirmationPolicies
+	ConfirmationPolicies map[string]ConfirmationPolicy
+
+	// Widget event backplane: "memory" (single replica) or "postgres" (LISTEN/NOTIFY)
+	Broadcaster string
+
+	// USD pricing, StaticPrices takes precedence over the price API
+	PriceAPIURL  string
+	PriceAPIKey  string
+	StaticPrices map[string]*big.Rat
+
+	// Local TTS binary reading text on stdin and writing audio to stdout,
+	// e.g. "espeak-ng -v {voice} -s {rate} --stdout". Empty leaves TTS to the browser.
+	TTSCommand string
+
+	// Twitch bot account announcing tips in chat, disabled when empty.
+	// TwitchIRCURL defaults to Twitch, irc://host:port for a plain IRC server.
+	TwitchBotUsername string
+	TwitchBotToken    string
+	TwitchIRCURL      string
+
+	// Let webhooks deliver to loopback and private network addresses, for local development
+	WebhookAllowPrivateNetworks bool
 }
 
 type Server struct {
@@ -336,18 +452,25 @@ func New(logger *log.Logger, database *db.Database, config Config) *Server {
 		logger:  logger,
 		service: service,
 		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				origin := r.Header.Get("Origin")
-				// Allow frontend origin and localhost dev
-				if origin == config.FrontendURL || strings.HasPrefix(origin, "http://localhost") {
-					return true
-				}
-				return true // Keeping promiscuous for now as user requested simple config, but better to restrict later
-			},
+			Subprotocols: []string{dashboardWSProtocol},
+			CheckOrigin:  checkWSOrigin(config.FrontendURL),
 		},
 	}
 }
 
+// checkWSOrigin accepts WebSocket upgrades from the frontend and from
+// clients that send no Origin, like OBS browser sources
+func checkWSOrigin(frontendURL string) func(r *http.Request) bool {
+	allowed := ""
+	if u, err := url.Parse(frontendURL); err == nil && u.Scheme != "" && u.Host != "" {
+		allowed = strings.ToLower(u.Scheme + "://" + u.Host)
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return origin == "" || (allowed != "" && strings.EqualFold(origin, allowed))
+	}
+}
+
 func (s *Server) InitOAuth() {
 	// Google
 	googleConfig = &oauth2.Config{
@@ -462,11 +585,12 @@ func (s *Server) HandleSignup(c *gin.Context) {
 		req.AvatarURL = claims.AvatarURL
 	}
 
-	newUser, sessionToken, err := s.service.RegisterUser(
+	newUser, tokens, err := s.service.RegisterUser(
 		req,
 		claims.Provider,
 		claims.ProviderID,
 		claims.Email,
+		sessionDevice(c),
 	)
 
 	if err != nil {
@@ -476,7 +600,7 @@ func (s *Server) HandleSignup(c *gin.Context) {
 	}
 
 	s.logger.Printf("New user registered: %s (Provider: %s)", newUser.Username, claims.Provider)
-	c.JSON(http.StatusOK, gin.H{"message": "Registration successful", "user": newUser, "token": sessionToken})
+	c.JSON(http.StatusOK, gin.H{"message": "Registration successful", "user": newUser, "token": tokens.Token, "refresh_token": tokens.RefreshToken, "expires_in": tokens.ExpiresIn})
 }
 
 func (s *Server) HandleLogin(c *gin.Context) {
@@ -523,6 +647,15 @@ func (s *Server) HandleMe(c *gin.Context) {
 		connectedProviders = append(connectedProviders, "tiktok")
 	}
 
+	// The default alert widget, for clients predating multiple widgets
+	widget, err := s.service.DefaultWidget(user.ID)
+	if err != nil {
+		s.logger.Printf("Failed to load widget of user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load widget"})
+		return
+	}
+	widgetSettings := widgetConfig(widget)
+
 	c.JSON(http.StatusOK, gin.H{
 		"id":                      user.ID,
 		"username":                user.Username,
@@ -535,12 +668,12 @@ func (s *Server) HandleMe(c *gin.Context) {
 		"provider":                user.Provider,
 		"connected_providers":     connectedProviders,
 		"wallet_address":          user.WalletAddress,
-		"widget_tts":              user.WidgetTTS,
-		"widget_token":            user.WidgetToken,
-		"widget_bg_color":         user.WidgetBgColor,
-		"widget_user_color":       user.WidgetUserColor,
-		"widget_amount_color":     user.WidgetAmountColor,
-		"widget_message_color":    user.WidgetMessageColor,
+		"widget_tts":              widgetSettings.TTS,
+		"widget_token":            widget.Token,
+		"widget_bg_color":         widgetSettings.Theme.Colors.Background,
+		"widget_user_color":       widgetSettings.Theme.Colors.User,
+		"widget_amount_color":     widgetSettings.Theme.Colors.Amount,
+		"widget_message_color":    widgetSettings.Theme.Colors.Message,
 		"twitch_username":         user.TwitchUsername,
 		"use_ens_avatar":          user.UseEnsAvatar,
 		"use_ens_background":      user.UseEnsBackground,
@@ -568,6 +701,12 @@ func (s *Server) HandleGetUser(c *gin.Context) {
 		connectedProviders = append(connectedProviders, "tiktok")
 	}
 
+	// Alert style for the profile's tip preview
+	widgetSettings := defaultWidgetConfig()
+	if widget, err := s.service.DefaultWidget(user.ID); err == nil {
+		widgetSettings = widgetConfig(widget)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"id":                      user.ID,
 		"username":                user.Username,
@@ -579,12 +718,13 @@ func (s *Server) HandleGetUser(c *gin.Context) {
 		"provider":                user.Provider,
 		"connected_providers":     connectedProviders,
 		"wallet_address":          user.WalletAddress,
-		"widget_tts":              user.WidgetTTS,
-		"widget_bg_color":         user.WidgetBgColor,
-		"widget_user_color":       user.WidgetUserColor,
-		"widget_amount_color":     user.WidgetAmountColor,
-		"widget_message_color":    user.WidgetMessageColor,
+		"widget_tts":              widgetSettings.TTS,
+		"widget_bg_color":         widgetSettings.Theme.Colors.Background,
+		"widget_user_color":       widgetSettings.Theme.Colors.User,
+		"widget_amount_color":     widgetSettings.Theme.Colors.Amount,
+		"widget_message_color":    widgetSettings.Theme.Colors.Message,
 		"twitch_username":         user.TwitchUsername,
+		"tip_rules":               tipRulesSettings(user),
 	})
 }
 
@@ -645,6 +785,11 @@ func (s *Server) HandleUpdateWidget(c *gin.Context) {
 	}
 
 	err = s.service.UpdateWidgetConfig(claims.UserID, req)
+	var themeErr *ThemeError
+	if errors.As(err, &themeErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "fields": themeErr.Fields})
+		return
+	}
 	if err != nil {
 		s.logger.Printf("Failed to update widget config: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update widget settings"})
@@ -738,7 +883,7 @@ func (s *Server) HandleRegenerateWidget(c *gin.Context) {
 		return
 	}
 
-	newToken, err := s.service.RegenerateWidgetToken(claims.UserID)
+	newToken, err := s.service.RegenerateWidgetToken(claims.UserID, 0)
 	if err != nil {
 		s.logger.Printf("Failed to regenerate widget token: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate token"})
@@ -780,10 +925,14 @@ func (s *Server) HandleTip(c *gin.Context) {
 
 	// Delegate processing to Service
 	success, msg, err := s.service.ProcessTip(tip, claims)
+	if errors.Is(err, ErrDuplicateTip) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		// Technical Error
 		s.logger.Printf("ProcessTip error: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process tip"})
 		return
 	}
 
@@ -840,53 +989,84 @@ func (s *Server) HandleGetTips(c *gin.Context) {
 	})
 }
 
+// HandleDashboardWS streams alert queue changes to the signed in streamer's
+// dashboard. Browsers can't set headers on WebSockets, so the access token
+// is offered as a second subprotocol after "bearer".
+func (s *Server) HandleDashboardWS(c *gin.Context) {
+	protocols := websocket.Subprotocols(c.Request)
+	if len(protocols) != 2 || protocols[0] != dashboardWSProtocol {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid token"})
+		return
+	}
+	claims, err := s.service.ValidateSessionToken(protocols[1])
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		s.logger.Printf("Failed to upgrade WS: %v", err)
+		return
+	}
+	s.service.RegisterClient(conn, claims.UserID, 0, dashboardSubscriptions)
+}
+
 func (s *Server) HandleWS(c *gin.Context) {
 	token := c.Param("streamerId") // Route param is still :streamerId for now
 
 	// Authenticate via Widget Token
-	user, err := s.service.db.GetUserByWidgetToken(token)
+	widget, user, err := s.service.ResolveWidget(token)
 	if err != nil {
 		s.logger.Printf("WS Auth Failed: Invalid token %s", token)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid widget token"})
 		return
 	}
 
+	since, ok := parseSinceEventID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since event ID"})
+		return
+	}
+
 	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		s.logger.Printf("Failed to upgrade WS: %v", err)
 		return
 	}
 
-	s.service.RegisterClient(conn, user.ID)
-	s.logger.Printf("New OBS widget connected for user: %s (ID: %d)", user.Username, user.ID)
+	subscriptions := widgetSubscriptions(widget)
+	s.logger.Printf("New OBS %s widget %d connected for user: %s (ID: %d)", widget.Type, widget.ID, user.Username, user.ID)
 
-	go func() {
-		defer s.service.UnregisterClient(conn)
-		for {
-			_, _, err := conn.ReadMessage()
-			if err != nil {
-				break
-			}
-		}
-	}()
+	// Catch up on alerts missed while disconnected, ahead of live ones
+	if since > 0 && subscribed(subscriptions, "TIP") {
+		client := s.service.RegisterReplayingClient(conn, user.ID, widget.ID, subscriptions)
+		s.service.ReplayMissedAlerts(client, user.ID, since)
+		return
+	}
+	s.service.RegisterClient(conn, user.ID, widget.ID, subscriptions)
 }
 
 func (s *Server) HandleGetWidgetConfig(c *gin.Context) {
 	token := c.Param("token")
-	user, err := s.service.db.GetUserByWidgetToken(token)
+	widget, user, err := s.service.ResolveWidget(token)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Widget not found"})
 		return
 	}
+	config := widgetConfig(widget)
 
 	c.JSON(http.StatusOK, gin.H{
 		"username":                user.Username,
 		"wallet_address":          user.WalletAddress,
-		"widget_tts":              user.WidgetTTS,
-		"widget_bg_color":         user.WidgetBgColor,
-		"widget_user_color":       user.WidgetUserColor,
-		"widget_amount_color":     user.WidgetAmountColor,
-		"widget_message_color":    user.WidgetMessageColor,
+		"widget_type":             widget.Type,
+		"widget_name":             widget.Name,
+		"widget_config":           config,
+		"widget_tts":              config.TTS,
+		"widget_bg_color":         config.Theme.Colors.Background,
+		"widget_user_color":       config.Theme.Colors.User,
+		"widget_amount_color":     config.Theme.Colors.Amount,
+		"widget_message_color":    config.Theme.Colors.Message,
 		"avatar_url":              user.AvatarURL,
 		"preferred_chain_id":      user.PreferredChainID,
 		"preferred_asset_address": user.PreferredAsset,
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckWSOrigin(t *testing.T) {
+	check := checkWSOrigin("https://tips.example.com/")
+	tests := map[string]bool{
+		"":                              true,
+		"https://tips.example.com":      true,
+		"HTTPS://Tips.Example.com":      true,
+		"http://tips.example.com":       false,
+		"https://tips.example.com.evil": false,
+		"https://evil.example":          false,
+		"http://localhost:3000":         false,
+		"null":                          false,
+	}
+	for origin, want := range tests {
+		r := httptest.NewRequest("GET", "/ws/token", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if got := check(r); got != want {
+			t.Errorf("origin %q allowed = %v, want %v", origin, got, want)
+		}
+	}
+
+	// Without a frontend only Origin-less clients connect
+	r := httptest.NewRequest("GET", "/ws/token", nil)
+	r.Header.Set("Origin", "https://tips.example.com")
+	if checkWSOrigin("")(r) {
+		t.Error("allowed a browser origin without a configured frontend")
+	}
+}
@@ -1,32 +1,40 @@
 package server
 
 import (
-	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/gorilla/websocket"
 	"github.com/patiee/backend/db"
 	dbmodel "github.com/patiee/backend/db/model"
 	"github.com/patiee/backend/server/model"
+	"gorm.io/gorm"
 )
 
+var tipAmountRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
 var (
-	ErrTxNotFound     = errors.New("tx receipt not found")
-	ErrSenderMismatch = errors.New("sender mismatch")
-	ErrENSNotFound    = errors.New("ens name not found")
+	ErrTxNotFound        = errors.New("tx receipt not found")
+	ErrTxFailed          = errors.New("transaction failed")
+	ErrSenderMismatch    = errors.New("sender mismatch")
+	ErrRecipientMismatch = errors.New("recipient mismatch")
+	ErrUnsupportedChain  = errors.New("unsupported chain")
+	ErrDuplicateTip      = errors.New("transaction was already submitted as a tip")
+	ErrBelowMinimum      = errors.New("tip below the streamer's minimum")
+	ErrENSNotFound       = errors.New("ens name not found")
 )
 
 // Helper to resolve ENS name to address
@@ -206,9 +214,18 @@ type Service struct {
 	db          *db.Database
 	config      Config
 	logger      *log.Logger
-	clients     map[uint]map[*websocket.Conn]bool // UserID -> Set of Conns
-	connsToUser map[*websocket.Conn]uint          // Conn -> UserID (reverse lookup)
-	clientsMu   sync.Mutex
+	hub         *Hub        // Widget WebSocket connections on this replica
+	broadcaster Broadcaster // Widget events across replicas
+	verifiers   *VerifierRegistry
+	bridge      BridgeStatusClient
+	prices      PriceOracle
+	tts         TTSEngine // nil leaves TTS to the browser
+	ttsAudio    ttsStore  // Rendered TTS audio
+	chat        ChatClient
+	chatQueue   chan chatAnnouncement // Set by StartChatAnnouncer
+	webhooks    *http.Client          // Sends webhook deliveries
+	sessions    sessionStore          // Signed in sessions, the database outside tests
+	oauthStates oauthStateStore       // OAuth flows in progress, the database outside tests
 
 	// Security
 	securityMu sync.Mutex
@@ -217,45 +234,53 @@ type Service struct {
 }
 
 func NewService(db *db.Database, config Config, logger *log.Logger) *Service {
-	return &Service{
+	s := &Service{
 		db:          db,
 		config:      config,
 		logger:      logger,
-		clients:     make(map[uint]map[*websocket.Conn]bool),
-		connsToUser: make(map[*websocket.Conn]uint),
+		hub:         NewHub(logger),
+		broadcaster: newBroadcaster(config, db, logger),
+		verifiers:   defaultVerifiers(),
+		bridge:      NewLifiStatusClient(config.LifiAPIURL, config.LifiAPIKey),
+		prices:      newPriceOracle(config),
+		tts:         newTTSEngine(config, logger),
+		chat:        newChatClient(config, logger),
+		webhooks:    newWebhookClient(config.WebhookAllowPrivateNetworks),
+		sessions:    db,
+		oauthStates: db,
 		lastTipReq:  make(map[string]time.Time),
 		strikes:     make(map[string]int),
 	}
+	s.ttsAudio = &minioTTSStore{service: s}
+	s.hub.OnMessage = s.handleWidgetCommand
+	return s
 }
 
 // Logic Methods
 
-func (s *Service) RegisterClient(conn *websocket.Conn, userID uint) {
-	s.clientsMu.Lock()
-	defer s.clientsMu.Unlock()
-	if s.clients[userID] == nil {
-		s.clients[userID] = make(map[*websocket.Conn]bool)
-	}
-	s.clients[userID][conn] = true
-	s.connsToUser[conn] = userID
+// RegisterClient hands a widget (or dashboard, widgetID 0) connection to the
+// hub, which closes it on disconnect
+func (s *Service) RegisterClient(conn *websocket.Conn, userID, widgetID uint, subscriptions []string) *wsClient {
+	return s.hub.Register(conn, userID, widgetID, subscriptions)
 }
 
-func (s *Service) UnregisterClient(conn *websocket.Conn) {
-	s.clientsMu.Lock()
-	defer s.clientsMu.Unlock()
-	if userID, ok := s.connsToUser[conn]; ok {
-		if _, exists := s.clients[userID][conn]; exists {
-			delete(s.clients[userID], conn)
-			if len(s.clients[userID]) == 0 {
-				delete(s.clients, userID)
-			}
-		}
-		delete(s.connsToUser, conn)
-		conn.Close()
-	}
+func (s *Service) RegisterReplayingClient(conn *websocket.Conn, userID, widgetID uint, subscriptions []string) *wsClient {
+	return s.hub.RegisterReplaying(conn, userID, widgetID, subscriptions)
 }
 
+// NotifyWidgets sends the tip alert to the streamer's widgets right away,
+// bypassing the alert queue (used for test tips)
 func (s *Service) NotifyWidgets(tip *dbmodel.Tip) {
+	s.notifyTip(tip, "TIP")
+}
+
+// notifyTip broadcasts a tip event of msgType (e.g. "TIP", "TIP_SEEN") to the streamer's widgets
+func (s *Service) notifyTip(tip *dbmodel.Tip, msgType string) {
+	s.notifyTipAlert(tip, msgType, 0)
+}
+
+// notifyTipAlert is notifyTip for an alert started from the alert queue
+func (s *Service) notifyTipAlert(tip *dbmodel.Tip, msgType string, alertID uint) {
 	// Find UserID for Streamer
 	user, err := s.db.GetUserByUsername(tip.StreamerID)
 	if err != nil {
@@ -263,40 +288,59 @@ func (s *Service) NotifyWidgets(tip *dbmodel.Tip) {
 		return
 	}
 
+	flags := tipRulesFor(user).AlertFlags(tip)
+	if tip.ID == 0 {
+		// Test tips preview the full alert
+		flags = AlertFlags{ShowMessage: tip.Message != "", TTS: tip.Message != "", ShowMedia: true}
+	}
+
 	notification := model.TipNotification{
-		Type:          "TIP",
+		Type:          msgType,
+		AlertID:       alertID,
+		TipID:         tip.ID,
+		Status:        tip.Status,
 		StreamerID:    tip.StreamerID,
 		Sender:        tip.Sender,
 		Message:       tip.Message,
 		Amount:        tip.Amount,
+		Asset:         tip.Asset,
+		USDValue:      tip.USDValue,
+		ShowMessage:   flags.ShowMessage,
+		TTS:           flags.TTS,
+		ShowMedia:     flags.ShowMedia,
 		AvatarURL:     tip.AvatarURL,
 		BackgroundURL: tip.BackgroundURL,
 		TwitterHandle: tip.TwitterHandle,
 	}
+	if flags.ShowMedia {
+		usd := parseRat(tip.USDValue)
+		if tip.ID == 0 {
+			// Test tips pick their tier by amount, as if it was in USD
+			usd = parseRat(tip.Amount)
+		}
+		notification.SoundURL, notification.MediaURL = s.resolveAlertMedia(user.ID, usd)
+	}
+	if flags.TTS {
+		notification.TTSAudioURL = tip.TTSAudioURL
+	}
 
-	s.logger.Printf("Broadcasting notification to %s: %+v", tip.StreamerID, notification)
-
-	s.clientsMu.Lock()
-	defer s.clientsMu.Unlock()
-
-	if conns, ok := s.clients[user.ID]; ok {
-		for client := range conns {
-			err := client.WriteJSON(notification)
-			if err != nil {
-				s.logger.Printf("WS write error: %v", err)
-				client.Close()
-				delete(conns, client)
-				delete(s.connsToUser, client)
-			}
+	// Test tips are shown once and never replayed
+	if tip.ID != 0 {
+		if err := s.recordWidgetEvent(user.ID, &notification); err != nil {
+			s.logger.Printf("Failed to record widget event for tip %d: %v", tip.ID, err)
 		}
 	}
+
+	s.logger.Printf("Broadcasting notification to %s: %+v", tip.StreamerID, notification)
+
+	s.publishWidgetEvent(user.ID, notification)
 }
 
 func (s *Service) CheckUsernameTaken(username string, userID uint) bool {
 	return s.db.CheckUsernameTaken(username, userID)
 }
 
-func (s *Service) CompleteUserProfile(userID uint, username, walletAddress string, mainWallet bool) (*dbmodel.User, string, error) {
+func (s *Service) CompleteUserProfile(userID uint, username, walletAddress string, mainWallet bool, device SessionDevice) (*dbmodel.User, *model.SessionTokens, error) {
 	// Preserve existing description/bg/avatar? Or assume they are empty/unchanged?
 	// For "CompleteUserProfile" usually used in signup/onboarding, so we might not have description yet.
 	// But to be safe, we should probably fetch the user first if we want to preserve fields, OR check if we can pass zero values to ignore?
@@ -316,21 +360,21 @@ func (s *Service) CompleteUserProfile(userID uint, username, walletAddress strin
 
 	err := s.db.UpdateUserProfile(userID, updatedUser)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	// Fetch updated user
 	updatedUser, err = s.db.GetUserByUsername(username)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	token, err := s.GenerateSessionToken(updatedUser)
+	tokens, err := s.GenerateSessionToken(updatedUser, device)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	return updatedUser, token, nil
+	return updatedUser, tokens, nil
 }
 
 func (s *Service) UpdateProfile(userID uint, req model.UpdateProfileRequest) error {
@@ -461,7 +505,7 @@ func (s *Service) UpdateUserWallet(userID uint, walletAddress string, chainID in
 	return s.db.UpdateUserWallet(userID, walletAddress, chainID, assetAddress)
 }
 
-func (s *Service) RegisterUser(req model.SignupRequest, provider, providerID, email string) (*dbmodel.User, string, error) {
+func (s *Service) RegisterUser(req model.SignupRequest, provider, providerID, email string, device SessionDevice) (*dbmodel.User, *model.SessionTokens, error) {
 	user := &dbmodel.User{
 		Username:          req.Username,
 		Provider:          provider,
@@ -476,7 +520,6 @@ func (s *Service) RegisterUser(req model.SignupRequest, provider, providerID, em
 		Description:       req.Description,
 		BackgroundURL:     req.BackgroundURL,
 		TwitterHandle:     req.TwitterHandle,
-		WidgetTTS:         true,
 		UseEnsAvatar:      req.UseEnsAvatar,
 		UseEnsBackground:  req.UseEnsBackground,
 		UseEnsDescription: req.UseEnsDescription,
@@ -484,24 +527,16 @@ func (s *Service) RegisterUser(req model.SignupRequest, provider, providerID, em
 	}
 
 	if err := s.CreateUser(user); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	// Generate Session Token
-	token, err := s.GenerateSessionToken(user)
+	tokens, err := s.GenerateSessionToken(user, device)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	return user, token, nil
-}
-
-func (s *Service) RegenerateWidgetToken(userID uint) (string, error) {
-	return s.db.RefreshWidgetToken(userID)
-}
-
-func (s *Service) UpdateWidgetConfig(userID uint, req model.UpdateWidgetRequest) error {
-	return s.db.UpdateWidgetConfig(userID, req.WaitTTS, req.BgColor, req.UserColor, req.AmountColor, req.MessageColor)
+	return user, tokens, nil
 }
 
 func (s *Service) ProcessTip(tip model.TipRequest, claims *WalletClaims) (bool, string, error) {
@@ -510,6 +545,57 @@ func (s *Service) ProcessTip(tip model.TipRequest, claims *WalletClaims) (bool,
 		return false, "Wallet is blacklisted.", nil
 	}
 
+	// 0b. Amount & Recipient Check
+	// The tx is verified against these later, so the recipient must be one of the streamer's wallets
+	if !tipAmountRegex.MatchString(tip.Amount) {
+		return false, "Invalid amount.", nil
+	}
+
+	streamer, err := s.db.GetUserByUsername(tip.StreamerID)
+	if err != nil {
+		return false, "Streamer not found.", nil
+	}
+	if tip.DestAddress == "" {
+		destChain := tip.DestChain
+		if destChain == "" {
+			destChain = tip.ChainID
+		}
+		tip.DestAddress = streamerAddressForChain(streamer, destChain)
+	}
+	if !isStreamerAddress(streamer, tip.DestAddress) {
+		return false, "Destination address does not belong to the streamer.", nil
+	}
+
+	// 0b2. One tip per transaction, so a payment can't be replayed into more
+	// alerts. Bridge tips are verified on their source chain.
+	if tip.SourceChain != "" && tip.DestChain != "" && tip.SourceChain != tip.DestChain {
+		tip.ChainID = tip.SourceChain
+	}
+	tip.TxHash = normalizeTxHash(tip.ChainID, tip.TxHash)
+	claimed, err := s.db.IsTipTxClaimed(tip.ChainID, tip.TxHash, 0)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to check tip tx: %w", err)
+	}
+	if claimed {
+		return false, "", ErrDuplicateTip
+	}
+
+	// 0c. Streamer's minimum tip, priced on the chain it was sent from since
+	// a bridge tip's delivered amount isn't known yet
+	if reason := s.checkTipMinimum(streamer, tip.ChainID, tip.Amount, tip.Asset); reason != "" {
+		return false, reason, nil
+	}
+
+	// 0d. Streamer's message filter
+	originalMessage := tip.Message
+	tip.Message, err = newMessageFilter(streamer).Apply(tip.Message)
+	if err != nil {
+		return false, fmt.Sprintf("Tip %v.", err), nil
+	}
+	if tip.Message == originalMessage {
+		originalMessage = ""
+	}
+
 	var avatarURL string
 	var backgroundURL string
 	var twitterHandle string
@@ -568,35 +654,88 @@ func (s *Service) ProcessTip(tip model.TipRequest, claims *WalletClaims) (bool,
 
 	// Save to DB as PENDING
 	dbTip := &dbmodel.Tip{
-		StreamerID:    tip.StreamerID,
-		Sender:        tip.Sender,
-		Message:       tip.Message,
-		Amount:        tip.Amount,
-		Asset:         tip.Asset,
-		TxHash:        tip.TxHash,
-		ChainID:       tip.ChainID,
-		SourceChain:   tip.SourceChain,
-		DestChain:     tip.DestChain,
-		SourceAddress: tip.SourceAddress,
-		DestAddress:   tip.DestAddress,
-		Status:        "pending",
-		AvatarURL:     avatarURL,
-		BackgroundURL: backgroundURL,
-		TwitterHandle: twitterHandle,
+		StreamerID:      tip.StreamerID,
+		Sender:          tip.Sender,
+		Message:         tip.Message,
+		OriginalMessage: originalMessage,
+		Amount:          tip.Amount,
+		Asset:           tip.Asset,
+		TxHash:          tip.TxHash,
+		ChainID:         tip.ChainID,
+		SourceChain:     tip.SourceChain,
+		DestChain:       tip.DestChain,
+		SourceAddress:   tip.SourceAddress,
+		DestAddress:     tip.DestAddress,
+		Status:          dbmodel.TipStatusPending,
+		AvatarURL:       avatarURL,
+		BackgroundURL:   backgroundURL,
+		TwitterHandle:   twitterHandle,
 	}
 
 	if err := s.db.CreateTip(dbTip); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return false, "", ErrDuplicateTip
+		}
 		s.logger.Printf("Failed to save pending tip to DB: %v", err)
 		return false, "", fmt.Errorf("failed to save tip: %v", err)
 	}
 
-	// Launch Background Verification
-	// Pass the full dbTip object which has the verified/corrected Sender and AvatarURL
-	go s.monitorTransaction(dbTip)
+	// Queue Background Verification
+	// Persisted so the tip is still verified if this instance restarts
+	if err := s.db.CreateVerificationJob(dbTip.ID, time.Now().Add(verificationBaseDelay)); err != nil {
+		s.logger.Printf("Failed to queue verification for tip %d: %v", dbTip.ID, err)
+		return false, "", fmt.Errorf("failed to queue tip verification: %v", err)
+	}
+	s.EmitTipWebhook(dbTip, WebhookEventTipPending)
 
 	return true, "Tip received! Waiting for transaction confirmation...", nil
 }
 
+// normalizeTxHash returns the canonical spelling of a hex tx hash, so the
+// same tx can't be submitted twice in different case or prefix. Base58
+// hashes (Solana, Sui) are case sensitive and kept as they are.
+func normalizeTxHash(chainID, txHash string) string {
+	txHash = strings.TrimSpace(txHash)
+	_, evm := ChainRPCs[chainID]
+	if !evm && chainID != "bitcoin" {
+		return txHash
+	}
+	digits := strings.TrimPrefix(strings.TrimPrefix(txHash, "0x"), "0X")
+	if _, err := hex.DecodeString(digits); err != nil {
+		return txHash
+	}
+	if evm {
+		return "0x" + strings.ToLower(digits)
+	}
+	return strings.ToLower(digits)
+}
+
+// streamerAddressForChain returns the streamer wallet that receives tips on chainID
+func streamerAddressForChain(user *dbmodel.User, chainID string) string {
+	switch chainID {
+	case "solana":
+		return user.SolanaAddress
+	case "bitcoin":
+		return user.BitcoinAddress
+	case "100003": // Sui
+		return user.SuiAddress
+	default:
+		return user.WalletAddress
+	}
+}
+
+func isStreamerAddress(user *dbmodel.User, address string) bool {
+	if address == "" {
+		return false
+	}
+	for _, a := range []string{user.WalletAddress, user.SolanaAddress, user.BitcoinAddress, user.SuiAddress} {
+		if a != "" && sameAddress(a, address) {
+			return true
+		}
+	}
+	return false
+}
+
 // fetchENSMetadata fetches avatar, header, description and twitter from enstate.rs
 func (s *Service) fetchENSMetadata(ensName string) (string, string, string, string, error) {
 	resp, err := http.Get(fmt.Sprintf("https://enstate.rs/n/%s", ensName))
@@ -642,365 +781,24 @@ func (s *Service) GetTips(username string, limit int, cursor uint) ([]model.TipR
 	responseItems := make([]model.TipResponseItem, 0, len(tips))
 	for _, t := range tips {
 		responseItems = append(responseItems, model.TipResponseItem{
-			CreatedAt:   t.CreatedAt.Format(time.RFC3339),
-			Sender:      t.Sender,
-			Message:     t.Message,
-			Amount:      t.Amount,
-			Asset:       t.Asset,
-			TxHash:      t.TxHash,
-			SourceChain: t.SourceChain,
-			Status:      t.Status,
+			CreatedAt:       t.CreatedAt.Format(time.RFC3339),
+			Sender:          t.Sender,
+			Message:         t.Message,
+			OriginalMessage: t.OriginalMessage,
+			Amount:          t.Amount,
+			Asset:           t.Asset,
+			USDValue:        t.USDValue,
+			TxHash:          t.TxHash,
+			SourceChain:     t.SourceChain,
+			DestChain:       t.DestChain,
+			DestTxHash:      t.DestTxHash,
+			Status:          t.Status,
 		})
 	}
 
 	return responseItems, nextCursor, nil
 }
 
-// monitorTransaction polls for the transaction receipt
-func (s *Service) monitorTransaction(tip *dbmodel.Tip) {
-	// Poll for status
-	// ... implementation detail ...
-	// Logic remains similar but using tip.<Field>
-
-	// Defer panic recovery just in case
-	defer func() {
-		if r := recover(); r != nil {
-			s.logger.Printf("Recovered from panic in monitorTransaction: %v", r)
-		}
-	}()
-
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-
-	timeout := time.After(15 * time.Minute) // Give it time for L1/L2 consistency
-
-	// Extract vars from tip object for clarity
-	tipID := tip.ID
-	chainID := tip.ChainID
-	txHash := tip.TxHash
-	sender := tip.SourceAddress // Note: Checks against Sender Wallet (SourceAddress), NOT the Name.
-	// wait, existing call was `claims.WalletAddress`.
-	// `ProcessTip` validated `claims.WalletAddress` matches `tip.SourceAddress`?
-	// `ValidateWalletToken` returns claims.
-	// In `Model.Tip`, `SourceAddress` is the sender wallet.
-	// So `tip.SourceAddress` is correct.
-
-	rpcURL := ChainRPCs[chainID]
-	// Need to ensure GetRPCURL works with string or convert.
-	// chainID is string in Tip struct? Yes.
-	// existing call passed `tip.ChainID` (string).
-
-	// Wait, GetRPCURL might need looking at.
-	// Assuming GetRPCURL(string) exists or I need to check Config.
-	// Earlier code: `s.monitorTransaction(dbTip.ID, tip.ChainID, ...)`
-
-	for {
-		select {
-		case <-timeout:
-			s.logger.Printf("Transaction verification timed out for tip %d", tipID)
-			s.db.UpdateTipStatus(tipID, "failed")
-			return
-		case <-ticker.C:
-			// Check Status
-			var verified bool
-			var err error
-
-			switch chainID {
-			case "solana":
-				verified, err = s.checkSolanaTx(rpcURL, txHash, sender)
-			case "bitcoin":
-				// Using mempool.space for now if configured
-				verified, err = s.checkBitcoinTx("https://mempool.space/api", txHash, sender, tip.Amount)
-			case "100003": // Sui
-				verified, err = s.checkSuiTx(rpcURL, txHash, sender)
-			default: // EVM
-				verified, err = s.checkEvmTx(rpcURL, txHash, sender)
-			}
-
-			if err != nil {
-				// Special case: If error is strictly "not found", we keep waiting (pending)
-				if errors.Is(err, ErrTxNotFound) {
-					continue
-				}
-
-				// Determine if it is a permanent failure
-				if strings.Contains(err.Error(), "transaction failed") || errors.Is(err, ErrSenderMismatch) {
-					s.logger.Printf("Transaction verification failed for tip %d: %v", tipID, err)
-					s.db.UpdateTipStatus(tipID, "failed")
-					return
-				}
-
-				// Other temporary RPC errors? Log and continue
-				s.logger.Printf("RPC error checking tip %d: %v", tipID, err)
-				continue
-			}
-
-			if verified {
-				s.logger.Printf("Transaction confirmed for tip %d", tipID)
-				s.db.UpdateTipStatus(tipID, "confirmed")
-				s.NotifyWidgets(tip)
-				return
-			}
-		}
-	}
-}
-
-// checkEvmTx checks validity for EVM chains
-func (s *Service) checkEvmTx(rpcURL string, txHash string, expectedSender string) (bool, error) {
-	client, err := ethclient.Dial(rpcURL)
-	if err != nil {
-		return false, fmt.Errorf("failed to connect to RPC: %v", err)
-	}
-	defer client.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	hash := common.HexToHash(txHash)
-
-	// 1. Check Receipt Status
-	receipt, err := client.TransactionReceipt(ctx, hash)
-	if err != nil {
-		// go-ethereum returns unexpored error for not found usually
-		return false, ErrTxNotFound
-	}
-
-	if receipt.Status != 1 {
-		return false, fmt.Errorf("transaction failed (status: 0)")
-	}
-
-	// 2. Check Sender
-	tx, _, err := client.TransactionByHash(ctx, hash)
-	if err != nil {
-		return false, fmt.Errorf("failed to get tx details: %v", err)
-	}
-
-	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
-	if err != nil {
-		return false, fmt.Errorf("failed to recover sender: %v", err)
-	}
-
-	if !strings.EqualFold(from.Hex(), expectedSender) {
-		return false, fmt.Errorf("%w: tx.from=%s, expected=%s", ErrSenderMismatch, from.Hex(), expectedSender)
-	}
-
-	return true, nil
-}
-
-// checkBitcoinTx checks validity via Mempool.space API
-func (s *Service) checkBitcoinTx(apiURL string, txHash string, expectedSender string, expectedAmount string) (bool, error) {
-	// GET /tx/:txid (Full details)
-	resp, err := http.Get(fmt.Sprintf("%s/tx/%s", apiURL, txHash))
-	if err != nil {
-		return false, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 404 {
-		return false, ErrTxNotFound
-	}
-	if resp.StatusCode != 200 {
-		return false, fmt.Errorf("API error: %d", resp.StatusCode)
-	}
-
-	var tx struct {
-		TxID   string `json:"txid"`
-		Status struct {
-			Confirmed bool `json:"confirmed"`
-		} `json:"status"`
-		Vin []struct {
-			Prevout struct {
-				ScriptPubKeyAddress string `json:"scriptpubkey_address"`
-				Value               int64  `json:"value"`
-			} `json:"prevout"`
-		} `json:"vin"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&tx); err != nil {
-		return false, err
-	}
-
-	if !tx.Status.Confirmed {
-		return false, ErrTxNotFound // Pending
-	}
-
-	// Verify Sender
-	senderFound := false
-	for _, input := range tx.Vin {
-		if strings.EqualFold(input.Prevout.ScriptPubKeyAddress, expectedSender) {
-			senderFound = true
-			break
-		}
-	}
-
-	if !senderFound {
-		return false, fmt.Errorf("sender %s not found in transaction inputs", expectedSender)
-	}
-
-	// Amount check is complex due to change outputs and fees.
-	// For now, we verified the sender initiated *a* confirmed transaction with this hash.
-	// This prevents random tx claiming, but allows self-send claiming.
-	// Acceptable risk for Phase 1.
-
-	return true, nil
-}
-
-// checkSolanaTx checks validity via Solana JSON-RPC
-func (s *Service) checkSolanaTx(rpcURL string, txHash string, expectedSender string) (bool, error) {
-	payload := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      1,
-		"method":  "getTransaction",
-		"params": []interface{}{
-			txHash,
-			map[string]interface{}{
-				"encoding":                       "jsonParsed",
-				"maxSupportedTransactionVersion": 0,
-			},
-		},
-	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return false, err
-	}
-
-	resp, err := http.Post(rpcURL, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return false, err
-	}
-	defer resp.Body.Close()
-
-	var result struct {
-		Result *struct {
-			Meta *struct {
-				Err interface{} `json:"err"`
-			} `json:"meta"`
-			Transaction *struct {
-				Message *struct {
-					AccountKeys []struct {
-						Pubkey string `json:"pubkey"`
-						Signer bool   `json:"signer"`
-					} `json:"accountKeys"`
-				} `json:"message"`
-			} `json:"transaction"`
-		} `json:"result"`
-		Error *struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		} `json:"error"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return false, err
-	}
-
-	if result.Error != nil {
-		return false, fmt.Errorf("RPC error: %s", result.Error.Message)
-	}
-
-	if result.Result == nil {
-		return false, ErrTxNotFound
-	}
-
-	if result.Result.Meta.Err != nil {
-		return false, fmt.Errorf("transaction failed on-chain")
-	}
-
-	// Check Sender (First signer)
-	if result.Result.Transaction != nil && result.Result.Transaction.Message != nil {
-		for _, key := range result.Result.Transaction.Message.AccountKeys {
-			if key.Signer {
-				if key.Pubkey == expectedSender {
-					return true, nil
-				}
-			}
-		}
-		return false, fmt.Errorf("%w: expected %s", ErrSenderMismatch, expectedSender)
-	}
-
-	return true, nil
-}
-
-// checkSuiTx checks validity via Sui JSON-RPC
-func (s *Service) checkSuiTx(rpcURL string, txHash string, expectedSender string) (bool, error) {
-	if rpcURL == "" {
-		rpcURL = "https://fullnode.mainnet.sui.io:443"
-	}
-
-	payload := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      1,
-		"method":  "sui_getTransactionBlock",
-		"params": []interface{}{
-			txHash,
-			map[string]interface{}{
-				"showEffects": true,
-				"showInput":   true,
-			},
-		},
-	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return false, err
-	}
-
-	resp, err := http.Post(rpcURL, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return false, err
-	}
-	defer resp.Body.Close()
-
-	var result struct {
-		Result *struct {
-			Effects *struct {
-				Status *struct {
-					Status string `json:"status"`
-					Error  string `json:"error"`
-				} `json:"status"`
-			} `json:"effects"`
-			Transaction *struct {
-				Data *struct {
-					Sender string `json:"sender"`
-				} `json:"data"`
-			} `json:"transaction"`
-		} `json:"result"`
-		Error *struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		} `json:"error"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return false, err
-	}
-
-	if result.Error != nil {
-		return false, ErrTxNotFound
-	}
-
-	if result.Result == nil {
-		return false, ErrTxNotFound // Pending or invalid
-	}
-
-	// 1. Verify Status
-	if result.Result.Effects == nil || result.Result.Effects.Status == nil || result.Result.Effects.Status.Status != "success" {
-		return false, fmt.Errorf("transaction failed on-chain")
-	}
-
-	// 2. Verify Sender
-	if result.Result.Transaction == nil || result.Result.Transaction.Data == nil {
-		return false, fmt.Errorf("transaction data missing")
-	}
-
-	if result.Result.Transaction.Data.Sender != expectedSender {
-		return false, fmt.Errorf("%w: expected %s, got %s", ErrSenderMismatch, expectedSender, result.Result.Transaction.Data.Sender)
-	}
-
-	return true, nil
-}
-
 func (s *Service) IsSignatureUsed(signature string) bool {
 	return s.db.IsSignatureUsed(signature)
 }
@@ -1013,8 +811,16 @@ func (s *Service) GetUserByProviderID(provider, providerID string) (*dbmodel.Use
 	return s.db.GetUserByProviderID(provider, providerID)
 }
 
+// CreateUser stores a new user along with their default alert widget
 func (s *Service) CreateUser(user *dbmodel.User) error {
-	return s.db.CreateUser(user)
+	if err := s.db.CreateUser(user); err != nil {
+		return err
+	}
+	if _, err := s.DefaultWidget(user.ID); err != nil {
+		// Created on first use instead
+		s.logger.Printf("Failed to create widget for user %d: %v", user.ID, err)
+	}
+	return nil
 }
 
 func (s *Service) IsWalletBlacklisted(address string) bool {
@@ -1034,7 +840,7 @@ func (s *Service) SendTestTip(streamerID, sender, message, amount, avatarURL, ba
 		AvatarURL:     avatarURL,
 		BackgroundURL: backgroundURL,
 		TwitterHandle: twitterHandle,
-		Status:        "confirmed",
+		Status:        dbmodel.TipStatusConfirmed,
 	}
 	s.NotifyWidgets(tip)
 	return nil
@@ -0,0 +1,281 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+)
+
+const (
+	accessTokenTTL      = 15 * time.Minute
+	refreshTokenTTL     = 30 * 24 * time.Hour // Since the last refresh
+	sessionMaxLifetime  = 90 * 24 * time.Hour // Sign in again after this, however active
+	sessionSeenInterval = 5 * time.Minute     // How often requests update a session's last seen time
+	maxUserAgentLen     = 512
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// sessionStore keeps signed in sessions and their refresh token history
+type sessionStore interface {
+	GetUserByID(id uint) (*dbmodel.User, error)
+	SaveUserSession(session *dbmodel.UserSession) error
+	GetUserSession(token string) (*dbmodel.UserSession, error)
+	GetUserSessionByID(sessionID uint) (*dbmodel.UserSession, error)
+	GetUserSessions(userID uint) ([]dbmodel.UserSession, error)
+	RotateUserSession(sessionID uint, oldToken, newToken string, expiresAt time.Time) (bool, error)
+	GetUsedRefreshToken(tokenHash string) (*dbmodel.UsedRefreshToken, error)
+	TouchUserSession(sessionID uint, seenAt, staleBefore time.Time) error
+	DeleteUserSession(sessionID uint) error
+	DeleteOtherUserSessions(userID, keepSessionID uint) (int64, error)
+}
+
+// SessionDevice is where a session was signed in from
+type SessionDevice struct {
+	IP        string
+	UserAgent string
+}
+
+func sessionDevice(c *gin.Context) SessionDevice {
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > maxUserAgentLen {
+		userAgent = userAgent[:maxUserAgentLen]
+	}
+	return SessionDevice{IP: c.ClientIP(), UserAgent: userAgent}
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// RefreshSession rotates the refresh token, returning a new access and
+// refresh token for the same session. A refresh token that was already
+// rotated revokes the session, as either its owner or someone else holds a
+// copy.
+func (s *Service) RefreshSession(refreshToken string) (*model.SessionTokens, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	tokenHash := sha256Hex(refreshToken)
+
+	session, err := s.sessions.GetUserSession(tokenHash)
+	if err != nil {
+		if used, err := s.sessions.GetUsedRefreshToken(tokenHash); err == nil {
+			s.logger.Printf("Refresh token reuse on session %d, revoking it", used.SessionID)
+			if err := s.sessions.DeleteUserSession(used.SessionID); err != nil {
+				s.logger.Printf("Failed to revoke session %d: %v", used.SessionID, err)
+			}
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if time.Now().After(session.ExpiresAt) {
+		s.sessions.DeleteUserSession(session.ID)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.sessions.GetUserByID(session.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(refreshTokenTTL)
+	if maxExpiry := session.CreatedAt.Add(sessionMaxLifetime); expiresAt.After(maxExpiry) {
+		expiresAt = maxExpiry
+	}
+
+	rotated, err := s.sessions.RotateUserSession(session.ID, tokenHash, sha256Hex(newToken), expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Another request rotated the same token first
+		s.logger.Printf("Concurrent refresh token reuse on session %d, revoking it", session.ID)
+		if err := s.sessions.DeleteUserSession(session.ID); err != nil {
+			s.logger.Printf("Failed to revoke session %d: %v", session.ID, err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	accessToken, err := s.generateAccessToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.SessionTokens{
+		Token:        accessToken,
+		RefreshToken: newToken,
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// Logout deletes the session of the access token, or else of the refresh
+// token. Unknown tokens are ignored.
+func (s *Service) Logout(accessToken, refreshToken string) error {
+	if accessToken != "" {
+		if claims, err := s.ValidateSessionToken(accessToken); err == nil && claims.SessionID != 0 {
+			return s.sessions.DeleteUserSession(claims.SessionID)
+		}
+	}
+	if refreshToken != "" {
+		if session, err := s.sessions.GetUserSession(sha256Hex(refreshToken)); err == nil {
+			return s.sessions.DeleteUserSession(session.ID)
+		}
+	}
+	return nil
+}
+
+func (s *Server) HandleRefreshSession(c *gin.Context) {
+	var req model.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	tokens, err := s.service.RefreshSession(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, ErrInvalidRefreshToken) || errors.Is(err, ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.Printf("Failed to refresh session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (s *Server) HandleLogout(c *gin.Context) {
+	var req model.RefreshRequest
+	_ = c.ShouldBindJSON(&req) // The refresh token is optional with a bearer token
+
+	accessToken := ""
+	if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		accessToken = authHeader[7:]
+	}
+
+	if err := s.service.Logout(accessToken, req.RefreshToken); err != nil {
+		s.logger.Printf("Failed to log out: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// GetSessions lists the user's signed in devices, marking currentSessionID
+func (s *Service) GetSessions(userID, currentSessionID uint) ([]model.SessionItem, error) {
+	sessions, err := s.sessions.GetUserSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]model.SessionItem, 0, len(sessions))
+	for _, session := range sessions {
+		if session.LastSeenAt.IsZero() {
+			session.LastSeenAt = session.CreatedAt // Signed in before last seen was tracked
+		}
+		items = append(items, model.SessionItem{
+			ID:         session.ID,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			Current:    session.ID == currentSessionID,
+			CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+			LastSeenAt: session.LastSeenAt.Format(time.RFC3339),
+			ExpiresAt:  session.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+	return items, nil
+}
+
+// RevokeSession signs one of the user's sessions out. Its access tokens stop
+// working immediately.
+func (s *Service) RevokeSession(userID, sessionID uint) error {
+	session, err := s.sessions.GetUserSessionByID(sessionID)
+	if err != nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return s.sessions.DeleteUserSession(sessionID)
+}
+
+// RevokeOtherSessions signs the user out everywhere but currentSessionID
+func (s *Service) RevokeOtherSessions(userID, currentSessionID uint) (int64, error) {
+	return s.sessions.DeleteOtherUserSessions(userID, currentSessionID)
+}
+
+func (s *Server) HandleGetSessions(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := s.service.GetSessions(claims.UserID, claims.SessionID)
+	if err != nil {
+		s.logger.Printf("Failed to get sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+func (s *Server) HandleRevokeSession(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	if err := s.service.RevokeSession(claims.UserID, uint(sessionID)); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.Printf("Failed to revoke session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+func (s *Server) HandleRevokeOtherSessions(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := s.service.RevokeOtherSessions(claims.UserID, claims.SessionID)
+	if err != nil {
+		s.logger.Printf("Failed to revoke sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked", "revoked": revoked})
+}
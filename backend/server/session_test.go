@@ -0,0 +1,253 @@
+package server
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	dbmodel "github.com/patiee/backend/db/model"
+	"gorm.io/gorm"
+)
+
+// memSessionStore keeps sessions like the database does, in memory
+type memSessionStore struct {
+	mu       sync.Mutex
+	users    map[uint]*dbmodel.User
+	sessions map[uint]*dbmodel.UserSession
+	used     map[string]uint // Rotated refresh token hash -> Session ID
+	nextID   uint
+}
+
+func newMemSessionStore(users ...*dbmodel.User) *memSessionStore {
+	st := &memSessionStore{users: map[uint]*dbmodel.User{}, sessions: map[uint]*dbmodel.UserSession{}, used: map[string]uint{}}
+	for _, u := range users {
+		st.users[u.ID] = u
+	}
+	return st
+}
+
+func (st *memSessionStore) GetUserByID(id uint) (*dbmodel.User, error) {
+	if u, ok := st.users[id]; ok {
+		return u, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (st *memSessionStore) SaveUserSession(session *dbmodel.UserSession) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.nextID++
+	session.ID = st.nextID
+	saved := *session
+	st.sessions[session.ID] = &saved
+	return nil
+}
+
+func (st *memSessionStore) GetUserSession(token string) (*dbmodel.UserSession, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, session := range st.sessions {
+		if session.Token == token {
+			found := *session
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (st *memSessionStore) GetUserSessionByID(sessionID uint) (*dbmodel.UserSession, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if session, ok := st.sessions[sessionID]; ok {
+		found := *session
+		return &found, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (st *memSessionStore) GetUserSessions(userID uint) ([]dbmodel.UserSession, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	var sessions []dbmodel.UserSession
+	for _, session := range st.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, *session)
+		}
+	}
+	return sessions, nil
+}
+
+func (st *memSessionStore) RotateUserSession(sessionID uint, oldToken, newToken string, expiresAt time.Time) (bool, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	session, ok := st.sessions[sessionID]
+	if !ok || session.Token != oldToken {
+		return false, nil
+	}
+	session.Token, session.ExpiresAt = newToken, expiresAt
+	st.used[oldToken] = sessionID
+	return true, nil
+}
+
+func (st *memSessionStore) GetUsedRefreshToken(tokenHash string) (*dbmodel.UsedRefreshToken, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if sessionID, ok := st.used[tokenHash]; ok {
+		return &dbmodel.UsedRefreshToken{TokenHash: tokenHash, SessionID: sessionID}, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (st *memSessionStore) TouchUserSession(sessionID uint, seenAt, staleBefore time.Time) error {
+	return nil
+}
+
+func (st *memSessionStore) DeleteUserSession(sessionID uint) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	delete(st.sessions, sessionID)
+	for token, id := range st.used {
+		if id == sessionID {
+			delete(st.used, token)
+		}
+	}
+	return nil
+}
+
+func (st *memSessionStore) DeleteOtherUserSessions(userID, keepSessionID uint) (int64, error) {
+	var deleted int64
+	for _, session := range st.sessions {
+		if session.UserID == userID && session.ID != keepSessionID {
+			st.DeleteUserSession(session.ID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func newSessionTestService(t *testing.T) (*Service, *memSessionStore, *dbmodel.User) {
+	t.Helper()
+	user := &dbmodel.User{ID: 7, Username: "alice"}
+	s := newTestService()
+	store := newMemSessionStore(user)
+	s.sessions = store
+	return s, store, user
+}
+
+func TestRefreshSessionRotates(t *testing.T) {
+	s, store, user := newSessionTestService(t)
+	first, err := s.GenerateSessionToken(user, SessionDevice{IP: "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("GenerateSessionToken: %v", err)
+	}
+
+	second, err := s.RefreshSession(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshSession: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+	claims, err := s.ValidateSessionToken(second.Token)
+	if err != nil || claims.UserID != user.ID || claims.SessionID != 1 {
+		t.Fatalf("ValidateSessionToken = %+v, %v", claims, err)
+	}
+
+	third, err := s.RefreshSession(second.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshSession with the rotated token: %v", err)
+	}
+	if len(store.sessions) != 1 {
+		t.Fatalf("%d sessions, want the one being refreshed", len(store.sessions))
+	}
+
+	// Presenting an already rotated token revokes the whole session,
+	// including the tokens issued after it
+	if _, err := s.RefreshSession(first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reused token: err = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := s.RefreshSession(third.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("latest token after reuse: err = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := s.ValidateSessionToken(third.Token); err == nil {
+		t.Fatal("access token still valid after reuse")
+	}
+	if len(store.sessions) != 0 || len(store.used) != 0 {
+		t.Fatalf("session left behind: %v, %v", store.sessions, store.used)
+	}
+}
+
+func TestRefreshSessionRejects(t *testing.T) {
+	s, store, user := newSessionTestService(t)
+	tokens, err := s.GenerateSessionToken(user, SessionDevice{})
+	if err != nil {
+		t.Fatalf("GenerateSessionToken: %v", err)
+	}
+
+	for _, token := range []string{"", "unknown"} {
+		if _, err := s.RefreshSession(token); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("RefreshSession(%q) = %v, want ErrInvalidRefreshToken", token, err)
+		}
+	}
+
+	// Expired sessions are removed on use
+	store.sessions[1].ExpiresAt = time.Now().Add(-time.Minute)
+	if _, err := s.RefreshSession(tokens.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("expired session: err = %v, want ErrInvalidRefreshToken", err)
+	}
+	if len(store.sessions) != 0 {
+		t.Fatal("expired session was not removed")
+	}
+}
+
+func TestRefreshSessionMaxLifetime(t *testing.T) {
+	s, store, user := newSessionTestService(t)
+	tokens, err := s.GenerateSessionToken(user, SessionDevice{})
+	if err != nil {
+		t.Fatalf("GenerateSessionToken: %v", err)
+	}
+	createdAt := time.Now().Add(-sessionMaxLifetime + time.Hour)
+	store.sessions[1].CreatedAt = createdAt
+
+	if _, err := s.RefreshSession(tokens.RefreshToken); err != nil {
+		t.Fatalf("RefreshSession: %v", err)
+	}
+	if want := createdAt.Add(sessionMaxLifetime); !store.sessions[1].ExpiresAt.Equal(want) {
+		t.Fatalf("expires at %v, want the session's max lifetime %v", store.sessions[1].ExpiresAt, want)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	s, store, user := newSessionTestService(t)
+	byAccess, err := s.GenerateSessionToken(user, SessionDevice{})
+	if err != nil {
+		t.Fatalf("GenerateSessionToken: %v", err)
+	}
+	byRefresh, err := s.GenerateSessionToken(user, SessionDevice{})
+	if err != nil {
+		t.Fatalf("GenerateSessionToken: %v", err)
+	}
+
+	// Unknown tokens are ignored
+	if err := s.Logout("not-a-jwt", "unknown"); err != nil || len(store.sessions) != 2 {
+		t.Fatalf("Logout with unknown tokens = %v, %d sessions left", err, len(store.sessions))
+	}
+
+	if err := s.Logout(byAccess.Token, ""); err != nil {
+		t.Fatalf("Logout by access token: %v", err)
+	}
+	if _, err := s.RefreshSession(byAccess.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("refresh after logout: err = %v, want ErrInvalidRefreshToken", err)
+	}
+
+	if err := s.Logout("", byRefresh.RefreshToken); err != nil {
+		t.Fatalf("Logout by refresh token: %v", err)
+	}
+	if _, err := s.RefreshSession(byRefresh.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("refresh after logout: err = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := s.ValidateSessionToken(byRefresh.Token); err == nil {
+		t.Fatal("access token still valid after logout")
+	}
+}
@@ -0,0 +1,309 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/mr-tron/base58"
+	dbmodel "github.com/patiee/backend/db/model"
+)
+
+const (
+	walletNonceTTL   = 10 * time.Minute
+	siweClockSkew    = 5 * time.Minute
+	siweMaxStatement = 500
+	siweMaxResources = 20
+)
+
+// Chain IDs accepted per CAIP-122 account type. Ethereum accounts use the
+// chain IDs of ChainRPCs.
+var walletSIWEChains = map[string]map[string]bool{
+	"Solana":  {"mainnet": true},
+	"Bitcoin": {"000000000019d6689c085ae165831e93": true}, // CAIP-2 reference of mainnet, its genesis hash prefix
+	"Sui":     {"mainnet": true},
+}
+
+var (
+	bitcoinAddressRegex = regexp.MustCompile(`^(?:[13][1-9A-HJ-NP-Za-km-z]{25,34}|bc1[02-9ac-hj-np-z]{11,71})$`)
+	suiAddressRegex     = regexp.MustCompile(`^0x[0-9a-f]{64}$`)
+)
+
+var ErrInvalidSIWEMessage = errors.New("invalid sign-in message")
+
+// SIWEMessage is a parsed EIP-4361 Sign-In With Ethereum message. The same
+// format with a Solana, Bitcoin or Sui account (CAIP-122) is accepted for
+// those wallets.
+type SIWEMessage struct {
+	Scheme         string // Optional, e.g. "https"
+	Domain         string
+	AccountType    string // "Ethereum", "Solana", "Bitcoin" or "Sui"
+	Address        string
+	Statement      string // Optional
+	URI            string
+	Version        string
+	ChainID        string
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// ParseSIWEMessage parses message strictly by the EIP-4361 ABNF: fields in
+// order, one per line, optional fields either fully present or absent
+func ParseSIWEMessage(message string) (*SIWEMessage, error) {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidSIWEMessage, fmt.Sprintf(format, args...))
+	}
+
+	lines := strings.Split(message, "\n")
+	next := 0
+	line := func() (string, bool) {
+		if next >= len(lines) {
+			return "", false
+		}
+		next++
+		return lines[next-1], true
+	}
+	// field reads the "Tag: value" line, or returns false without consuming
+	// it when the line has a different tag and the field is optional
+	field := func(tag string, optional bool) (string, bool, error) {
+		if next < len(lines) && strings.HasPrefix(lines[next], tag+": ") {
+			value, _ := line()
+			return strings.TrimPrefix(value, tag+": "), true, nil
+		}
+		if optional {
+			return "", false, nil
+		}
+		return "", false, invalid("missing %s", tag)
+	}
+	timestamp := func(tag, value string) (time.Time, error) {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return time.Time{}, invalid("%s is not an RFC 3339 timestamp", tag)
+		}
+		return t, nil
+	}
+
+	m := &SIWEMessage{}
+
+	header, _ := line()
+	preamble, ok := strings.CutSuffix(header, " account:")
+	if !ok {
+		return nil, invalid("bad header")
+	}
+	if m.Domain, m.AccountType, ok = strings.Cut(preamble, " wants you to sign in with your "); !ok {
+		return nil, invalid("bad header")
+	}
+	if m.AccountType != "Ethereum" && walletSIWEChains[m.AccountType] == nil {
+		return nil, invalid("unsupported account type %q", m.AccountType)
+	}
+	if scheme, domain, found := strings.Cut(m.Domain, "://"); found {
+		m.Scheme, m.Domain = scheme, domain
+	}
+	if m.Domain == "" || strings.ContainsAny(m.Domain, " /?#") {
+		return nil, invalid("bad domain")
+	}
+
+	if m.Address, ok = line(); !ok || m.Address == "" {
+		return nil, invalid("missing address")
+	}
+	if blank, _ := line(); blank != "" {
+		return nil, invalid("expected a blank line after the address")
+	}
+	// Either a blank line, or the statement followed by one
+	if statement, _ := line(); statement != "" {
+		if len(statement) > siweMaxStatement {
+			return nil, invalid("statement too long")
+		}
+		m.Statement = statement
+		if blank, _ := line(); blank != "" {
+			return nil, invalid("expected a blank line after the statement")
+		}
+	}
+
+	var err error
+	if m.URI, _, err = field("URI", false); err != nil {
+		return nil, err
+	}
+	if m.Version, _, err = field("Version", false); err != nil {
+		return nil, err
+	}
+	if m.ChainID, _, err = field("Chain ID", false); err != nil {
+		return nil, err
+	}
+	if m.Nonce, _, err = field("Nonce", false); err != nil {
+		return nil, err
+	}
+	issuedAt, _, err := field("Issued At", false)
+	if err != nil {
+		return nil, err
+	}
+	if m.IssuedAt, err = timestamp("Issued At", issuedAt); err != nil {
+		return nil, err
+	}
+	if value, found, _ := field("Expiration Time", true); found {
+		t, err := timestamp("Expiration Time", value)
+		if err != nil {
+			return nil, err
+		}
+		m.ExpirationTime = &t
+	}
+	if value, found, _ := field("Not Before", true); found {
+		t, err := timestamp("Not Before", value)
+		if err != nil {
+			return nil, err
+		}
+		m.NotBefore = &t
+	}
+	m.RequestID, _, _ = field("Request ID", true)
+	if next < len(lines) && lines[next] == "Resources:" {
+		next++
+		for next < len(lines) && strings.HasPrefix(lines[next], "- ") {
+			resource, _ := line()
+			m.Resources = append(m.Resources, strings.TrimPrefix(resource, "- "))
+		}
+		if len(m.Resources) > siweMaxResources {
+			return nil, invalid("too many resources")
+		}
+	}
+	if next != len(lines) {
+		return nil, invalid("unexpected line %q", lines[next])
+	}
+
+	if u, err := url.Parse(m.URI); err != nil || u.Scheme == "" {
+		return nil, invalid("URI is not absolute")
+	}
+	if m.Version != "1" {
+		return nil, invalid("unsupported version %q", m.Version)
+	}
+	if len(m.Nonce) < 8 || !isAlphanumeric(m.Nonce) {
+		return nil, invalid("nonce must be at least 8 alphanumeric characters")
+	}
+	if err := m.validateAddress(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// validateAddress requires an EIP-55 checksummed address for Ethereum, a
+// base58 public key for Solana, a mainnet address for Bitcoin and a lowercase
+// hex address for Sui
+func (m *SIWEMessage) validateAddress() error {
+	switch m.AccountType {
+	case "Ethereum":
+		if !common.IsHexAddress(m.Address) || common.HexToAddress(m.Address).Hex() != m.Address {
+			return fmt.Errorf("%w: address must be EIP-55 checksummed", ErrInvalidSIWEMessage)
+		}
+	case "Solana":
+		if key, err := base58.Decode(m.Address); err != nil || len(key) != 32 {
+			return fmt.Errorf("%w: bad Solana address", ErrInvalidSIWEMessage)
+		}
+	case "Bitcoin":
+		if !bitcoinAddressRegex.MatchString(m.Address) {
+			return fmt.Errorf("%w: bad Bitcoin address", ErrInvalidSIWEMessage)
+		}
+	case "Sui":
+		if !suiAddressRegex.MatchString(m.Address) {
+			return fmt.Errorf("%w: Sui address must be 0x and 64 lowercase hex digits", ErrInvalidSIWEMessage)
+		}
+	}
+	return nil
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// NewWalletNonce issues a single use nonce for a wallet sign-in message
+func (s *Service) NewWalletNonce() (*dbmodel.WalletNonce, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	nonce := &dbmodel.WalletNonce{
+		Nonce:     hex.EncodeToString(b),
+		ExpiresAt: time.Now().Add(walletNonceTTL),
+	}
+	if err := s.db.CreateWalletNonce(nonce); err != nil {
+		return nil, fmt.Errorf("failed to store wallet nonce: %v", err)
+	}
+	return nonce, nil
+}
+
+// validateSIWEMessage checks the message was made for this site and a
+// supported chain, and is valid at now. It does not use the nonce up.
+func (s *Service) validateSIWEMessage(m *SIWEMessage, now time.Time) error {
+	frontend, err := url.Parse(s.config.FrontendURL)
+	if err != nil || frontend.Host == "" {
+		return fmt.Errorf("frontend URL is not configured")
+	}
+
+	if m.Domain != frontend.Host {
+		return fmt.Errorf("%w: domain %q does not match %q", ErrInvalidSIWEMessage, m.Domain, frontend.Host)
+	}
+	if m.Scheme != "" && m.Scheme != frontend.Scheme {
+		return fmt.Errorf("%w: scheme %q does not match %q", ErrInvalidSIWEMessage, m.Scheme, frontend.Scheme)
+	}
+	if u, _ := url.Parse(m.URI); u.Scheme != frontend.Scheme || u.Host != frontend.Host {
+		return fmt.Errorf("%w: URI is not on %s", ErrInvalidSIWEMessage, frontend.Host)
+	}
+
+	supported := walletSIWEChains[m.AccountType][m.ChainID]
+	if m.AccountType == "Ethereum" {
+		_, supported = ChainRPCs[m.ChainID]
+	}
+	if !supported {
+		return fmt.Errorf("%w: unsupported chain ID %q", ErrInvalidSIWEMessage, m.ChainID)
+	}
+
+	if m.IssuedAt.After(now.Add(siweClockSkew)) || m.IssuedAt.Before(now.Add(-walletNonceTTL)) {
+		return fmt.Errorf("%w: issued at is out of range", ErrInvalidSIWEMessage)
+	}
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return fmt.Errorf("%w: message expired", ErrInvalidSIWEMessage)
+	}
+	if m.NotBefore != nil && now.Add(siweClockSkew).Before(*m.NotBefore) {
+		return fmt.Errorf("%w: message is not valid yet", ErrInvalidSIWEMessage)
+	}
+	return nil
+}
+
+// consumeWalletNonce uses up the message's nonce, after its signature checked out
+func (s *Service) consumeWalletNonce(nonce string) error {
+	record, err := s.db.ConsumeWalletNonce(nonce)
+	if err != nil {
+		return fmt.Errorf("%w: unknown or already used nonce", ErrInvalidSIWEMessage)
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return fmt.Errorf("%w: nonce expired", ErrInvalidSIWEMessage)
+	}
+	return nil
+}
+
+func (s *Server) HandleWalletNonce(c *gin.Context) {
+	nonce, err := s.service.NewWalletNonce()
+	if err != nil {
+		s.logger.Printf("Failed to create wallet nonce: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create nonce"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"nonce": nonce.Nonce, "expires_at": nonce.ExpiresAt.Format(time.RFC3339)})
+}
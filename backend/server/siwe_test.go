@@ -0,0 +1,149 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const siweEthAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+// siweMessage builds a message for example.com signed by address of accountType
+func siweMessage(accountType, address, chainID string, extra ...string) string {
+	lines := []string{
+		"example.com wants you to sign in with your " + accountType + " account:",
+		address,
+		"",
+		"Sign in to Stream Tips.",
+		"",
+		"URI: https://example.com",
+		"Version: 1",
+		"Chain ID: " + chainID,
+		"Nonce: abcdef0123456789",
+		"Issued At: 2026-10-17T12:00:00Z",
+	}
+	return strings.Join(append(lines, extra...), "\n")
+}
+
+func TestParseSIWEMessage(t *testing.T) {
+	full := strings.Join([]string{
+		"https://example.com wants you to sign in with your Ethereum account:",
+		siweEthAddress,
+		"",
+		"",
+		"URI: https://example.com/login",
+		"Version: 1",
+		"Chain ID: 8453",
+		"Nonce: abcdef0123456789",
+		"Issued At: 2026-10-17T12:00:00.123Z",
+		"Expiration Time: 2026-10-17T12:10:00Z",
+		"Not Before: 2026-10-17T11:59:00Z",
+		"Request ID: req-1",
+		"Resources:",
+		"- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq",
+		"- https://example.com/terms",
+	}, "\n")
+	m, err := ParseSIWEMessage(full)
+	if err != nil {
+		t.Fatalf("ParseSIWEMessage: %v", err)
+	}
+	if m.Scheme != "https" || m.Domain != "example.com" || m.AccountType != "Ethereum" || m.Address != siweEthAddress || m.Statement != "" {
+		t.Fatalf("header = %+v", m)
+	}
+	if m.ChainID != "8453" || m.Nonce != "abcdef0123456789" || m.RequestID != "req-1" || len(m.Resources) != 2 {
+		t.Fatalf("fields = %+v", m)
+	}
+	if m.ExpirationTime == nil || !m.ExpirationTime.Equal(time.Date(2026, 10, 17, 12, 10, 0, 0, time.UTC)) || m.NotBefore == nil {
+		t.Fatalf("times = %v, %v", m.ExpirationTime, m.NotBefore)
+	}
+
+	// CAIP-122 forms of the other supported wallets
+	for _, tt := range []struct{ accountType, address, chainID string }{
+		{"Solana", "5Hs4WCj7fEJyNYNNb3Mdx8XbrbSbkTasxCwaXbxwozbG", "mainnet"},
+		{"Bitcoin", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", "000000000019d6689c085ae165831e93"},
+		{"Bitcoin", "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV", "000000000019d6689c085ae165831e93"},
+		{"Sui", "0x" + strings.Repeat("ab", 32), "mainnet"},
+	} {
+		m, err := ParseSIWEMessage(siweMessage(tt.accountType, tt.address, tt.chainID))
+		if err != nil {
+			t.Errorf("%s message: %v", tt.accountType, err)
+			continue
+		}
+		if m.AccountType != tt.accountType || m.Address != tt.address || m.Statement != "Sign in to Stream Tips." {
+			t.Errorf("%s message = %+v", tt.accountType, m)
+		}
+	}
+}
+
+func TestParseSIWEMessageRejects(t *testing.T) {
+	valid := siweMessage("Ethereum", siweEthAddress, "1")
+	tests := map[string]string{
+		"empty":                 "",
+		"bad header":            strings.Replace(valid, " wants you to sign in with your ", " wants ", 1),
+		"unknown account type":  siweMessage("Dogecoin", "DH5yaieqoZN36fDVciNyRueRGvGLR3mr7L", "mainnet"),
+		"domain with a path":    strings.Replace(valid, "example.com wants", "example.com/x wants", 1),
+		"lowercase eth address": siweMessage("Ethereum", strings.ToLower(siweEthAddress), "1"),
+		"short solana key":      siweMessage("Solana", "3yZe7d", "mainnet"),
+		"testnet bitcoin":       siweMessage("Bitcoin", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", "000000000019d6689c085ae165831e93"),
+		"uppercase sui address": siweMessage("Sui", "0x"+strings.Repeat("AB", 32), "mainnet"),
+		"missing blank line":    strings.Replace(valid, "\n\nSign in", "\nSign in", 1),
+		"fields out of order":   strings.Replace(valid, "Version: 1\nChain ID: 1", "Chain ID: 1\nVersion: 1", 1),
+		"missing nonce":         strings.Replace(valid, "Nonce: abcdef0123456789\n", "", 1),
+		"short nonce":           strings.Replace(valid, "abcdef0123456789", "abc", 1),
+		"nonce with symbols":    strings.Replace(valid, "abcdef0123456789", "abcdef01-2345", 1),
+		"version 2":             strings.Replace(valid, "Version: 1", "Version: 2", 1),
+		"relative uri":          strings.Replace(valid, "URI: https://example.com", "URI: /login", 1),
+		"bad timestamp":         strings.Replace(valid, "2026-10-17T12:00:00Z", "yesterday", 1),
+		"trailing line":         valid + "\nExtra: field",
+		"optional field order":  siweMessage("Ethereum", siweEthAddress, "1", "Not Before: 2026-10-17T12:00:00Z", "Expiration Time: 2026-10-17T12:10:00Z"),
+		"too many resources":    siweMessage("Ethereum", siweEthAddress, "1", "Resources:"+strings.Repeat("\n- https://example.com", siweMaxResources+1)),
+		"long statement":        strings.Replace(valid, "Sign in to Stream Tips.", strings.Repeat("a", siweMaxStatement+1), 1),
+	}
+	for name, message := range tests {
+		if _, err := ParseSIWEMessage(message); !errors.Is(err, ErrInvalidSIWEMessage) {
+			t.Errorf("%s: err = %v, want ErrInvalidSIWEMessage", name, err)
+		}
+	}
+}
+
+func TestValidateSIWEMessage(t *testing.T) {
+	s := newTestService()
+	s.config.FrontendURL = "https://example.com"
+	now := time.Date(2026, 10, 17, 12, 1, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		message string
+		now     time.Time
+		valid   bool
+	}{
+		{name: "ethereum", message: siweMessage("Ethereum", siweEthAddress, "8453"), now: now, valid: true},
+		{name: "bitcoin", message: siweMessage("Bitcoin", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", "000000000019d6689c085ae165831e93"), now: now, valid: true},
+		{name: "sui", message: siweMessage("Sui", "0x"+strings.Repeat("ab", 32), "mainnet"), now: now, valid: true},
+		{name: "unsupported evm chain", message: siweMessage("Ethereum", siweEthAddress, "999999"), now: now},
+		{name: "solana devnet", message: siweMessage("Solana", "5Hs4WCj7fEJyNYNNb3Mdx8XbrbSbkTasxCwaXbxwozbG", "devnet"), now: now},
+		{name: "bitcoin testnet chain", message: siweMessage("Bitcoin", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", "000000000933ea01ad0ee984209779ba"), now: now},
+		{name: "other domain", message: strings.Replace(siweMessage("Ethereum", siweEthAddress, "1"), "example.com wants", "evil.example wants", 1), now: now},
+		{name: "uri on another site", message: strings.Replace(siweMessage("Ethereum", siweEthAddress, "1"), "URI: https://example.com", "URI: https://evil.example", 1), now: now},
+		{name: "issued too long ago", message: siweMessage("Ethereum", siweEthAddress, "1"), now: now.Add(walletNonceTTL)},
+		{name: "issued in the future", message: siweMessage("Ethereum", siweEthAddress, "1"), now: now.Add(-time.Hour)},
+		{name: "expired", message: siweMessage("Ethereum", siweEthAddress, "1", "Expiration Time: 2026-10-17T12:00:30Z"), now: now},
+		{name: "not valid yet", message: siweMessage("Ethereum", siweEthAddress, "1", "Not Before: 2026-10-17T13:00:00Z"), now: now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseSIWEMessage(tt.message)
+			if err != nil {
+				t.Fatalf("ParseSIWEMessage: %v", err)
+			}
+			err = s.validateSIWEMessage(m, tt.now)
+			if tt.valid && err != nil {
+				t.Fatalf("validateSIWEMessage: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidSIWEMessage) {
+				t.Fatalf("err = %v, want ErrInvalidSIWEMessage", err)
+			}
+		})
+	}
+}
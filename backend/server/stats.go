@@ -0,0 +1,244 @@
+package server
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patiee/backend/db"
+	"github.com/patiee/backend/server/model"
+)
+
+// Stats are computed over confirmed tips only
+
+func (s *Service) GetTipStats(filter db.StatsFilter) (*model.StatsResponse, error) {
+	rows, err := s.db.GetTipTotals(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	totalUSD := new(big.Rat)
+	resp := &model.StatsResponse{Totals: make([]model.AssetTotal, 0, len(rows))}
+	for _, r := range rows {
+		resp.TipCount += r.Count
+		if usd, ok := new(big.Rat).SetString(r.TotalUSD); ok {
+			totalUSD.Add(totalUSD, usd)
+		}
+		resp.Totals = append(resp.Totals, model.AssetTotal{
+			Asset:    r.Asset,
+			ChainID:  r.ChainID,
+			Count:    r.Count,
+			Total:    normalizeDecimal(r.Total),
+			Average:  normalizeDecimal(r.Average),
+			TotalUSD: usdString(r.TotalUSD),
+		})
+	}
+	resp.TotalUSD = totalUSD.FloatString(2)
+	return resp, nil
+}
+
+func (s *Service) GetTipTimeSeries(filter db.StatsFilter, interval string) (*model.TimeSeriesResponse, error) {
+	rows, err := s.db.GetTipTimeSeries(filter, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &model.TimeSeriesResponse{Interval: interval, Points: make([]model.TimeSeriesPoint, 0, len(rows))}
+	for _, r := range rows {
+		resp.Points = append(resp.Points, model.TimeSeriesPoint{
+			Bucket:   r.Bucket.UTC().Format(time.RFC3339),
+			Asset:    r.Asset,
+			Count:    r.Count,
+			Total:    normalizeDecimal(r.Total),
+			TotalUSD: usdString(r.TotalUSD),
+		})
+	}
+	return resp, nil
+}
+
+// GetTopSenders ranks supporters by tip count, or by amount sent in asset
+// (defaulting to the streamer's most tipped asset)
+func (s *Service) GetTopSenders(filter db.StatsFilter, by, asset string, limit int) (*model.TopSendersResponse, error) {
+	var rows []db.TopSenderRow
+	var err error
+
+	if by == "count" {
+		rows, err = s.db.GetTopSendersByCount(filter, limit)
+	} else {
+		if asset == "" {
+			totals, err := s.db.GetTipTotals(filter)
+			if err != nil {
+				return nil, err
+			}
+			if len(totals) > 0 {
+				asset = totals[0].Asset
+			}
+		}
+		rows, err = s.db.GetTopSendersByAmount(filter, asset, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &model.TopSendersResponse{By: by, Senders: make([]model.TopSender, 0, len(rows))}
+	for _, r := range rows {
+		resp.Senders = append(resp.Senders, model.TopSender{
+			Address: r.SourceAddress,
+			Sender:  r.Sender,
+			Asset:   r.Asset,
+			Count:   r.Count,
+			Total:   normalizeDecimal(r.Total),
+		})
+	}
+	return resp, nil
+}
+
+// normalizeDecimal trims trailing zeros from a numeric string ("1.5000" -> "1.5")
+func normalizeDecimal(value string) string {
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return value
+	}
+	out := strings.TrimRight(r.FloatString(18), "0")
+	return strings.TrimSuffix(out, ".")
+}
+
+// usdString renders a numeric string with two decimals ("12.5" -> "12.50")
+func usdString(value string) string {
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return "0.00"
+	}
+	return r.FloatString(2)
+}
+
+// statsFilter builds the filter for the logged in streamer from ?from= and ?to= (RFC3339)
+func statsFilter(c *gin.Context, username string) (db.StatsFilter, error) {
+	filter := db.StatsFilter{StreamerID: username}
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s, expected RFC3339", param)
+		}
+		*target = &t
+	}
+	return filter, nil
+}
+
+// requireSession validates the Bearer token, writing a 401 when it is missing or invalid
+func (s *Server) requireSession(c *gin.Context) (*SessionClaims, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if len(authHeader) < 8 || authHeader[:7] != "Bearer " {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid token"})
+		return nil, false
+	}
+
+	claims, err := s.service.ValidateSessionToken(authHeader[7:])
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return nil, false
+	}
+	return claims, true
+}
+
+func (s *Server) HandleGetStats(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	filter, err := statsFilter(c, claims.Username)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := s.service.GetTipStats(filter)
+	if err != nil {
+		s.logger.Printf("Failed to fetch tip stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (s *Server) HandleGetStatsTimeSeries(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	filter, err := statsFilter(c, claims.Username)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	interval := c.DefaultQuery("interval", "day")
+	if interval != "day" && interval != "hour" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be 'day' or 'hour'"})
+		return
+	}
+
+	// Default to the last 30 days (or 48 hours for hourly buckets)
+	if filter.From == nil {
+		from := time.Now().AddDate(0, 0, -30)
+		if interval == "hour" {
+			from = time.Now().Add(-48 * time.Hour)
+		}
+		filter.From = &from
+	}
+
+	series, err := s.service.GetTipTimeSeries(filter, interval)
+	if err != nil {
+		s.logger.Printf("Failed to fetch tip time series: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+func (s *Server) HandleGetTopSenders(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	filter, err := statsFilter(c, claims.Username)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	by := c.DefaultQuery("by", "amount")
+	if by != "amount" && by != "count" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "by must be 'amount' or 'count'"})
+		return
+	}
+
+	limit := 10
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	top, err := s.service.GetTopSenders(filter, by, c.Query("asset"), limit)
+	if err != nil {
+		s.logger.Printf("Failed to fetch top senders: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, top)
+}
@@ -0,0 +1,362 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+)
+
+const (
+	widgetThemeVersion   = 2
+	themeMaxImportSize   = 16 << 10
+	themeMaxURLLength    = 500
+	themeMaxTemplateSize = 200
+)
+
+var (
+	themeColorRegex       = regexp.MustCompile(`^(?:#(?:[0-9a-f]{3}|[0-9a-f]{4}|[0-9a-f]{6}|[0-9a-f]{8})|rgba?\(\s*\d{1,3}\s*,\s*\d{1,3}\s*,\s*\d{1,3}\s*(?:,\s*(?:0|1|0?\.\d+)\s*)?\)|transparent)$`)
+	themeFontRegex        = regexp.MustCompile(`^[A-Za-z0-9 ]{1,40}$`)
+	themePlaceholderRegex = regexp.MustCompile(`\{([a-z_]*)\}`)
+
+	themePlaceholders  = []string{"sender", "amount", "asset", "usd", "message"}
+	themePositions     = []string{"top", "center", "bottom"}
+	themeAligns        = []string{"left", "center", "right"}
+	themeAnimationsIn  = []string{"none", "fade", "zoom", "bounce", "slide-up", "slide-down", "slide-left", "slide-right"}
+	themeAnimationsOut = []string{"none", "fade", "zoom", "slide-up", "slide-down", "slide-left", "slide-right"}
+)
+
+// Inclusive bounds of the theme's numeric fields
+var themeBounds = map[string][2]int{
+	"font.size":             {10, 96},
+	"font.weight":           {100, 900},
+	"layout.width":          {240, 1920},
+	"animation.duration_ms": {2000, 60000},
+	"sound.volume":          {0, 100},
+}
+
+// ThemeError lists the invalid fields of a theme by their JSON path
+type ThemeError struct {
+	Fields map[string]string
+}
+
+func (e *ThemeError) Error() string {
+	paths := make([]string, 0, len(e.Fields))
+	for path := range e.Fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	msgs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		msgs = append(msgs, path+": "+e.Fields[path])
+	}
+	return "invalid theme: " + strings.Join(msgs, "; ")
+}
+
+func (e *ThemeError) Unwrap() error {
+	return ErrInvalidWidget
+}
+
+func defaultWidgetTheme() model.WidgetTheme {
+	return model.WidgetTheme{
+		Version: widgetThemeVersion,
+		Colors: model.ThemeColors{
+			Background: "#000000",
+			User:       "#ffffff",
+			Amount:     "#22c55e",
+			Message:    "#ffffff",
+		},
+		Font:         model.ThemeFont{Family: "Inter", Size: 18, Weight: 700},
+		Layout:       model.ThemeLayout{Position: "bottom", Align: "center", Width: 576},
+		Animation:    model.ThemeAnimation{In: "zoom", Out: "zoom", DurationMs: 10000},
+		Sound:        model.ThemeSound{Volume: 50},
+		TextTemplate: "{sender} tipped {amount}",
+	}
+}
+
+// Built in themes offered by the dashboard
+var themePresets = func() []model.ThemePreset {
+	neon := defaultWidgetTheme()
+	neon.Colors = model.ThemeColors{Background: "#0b0221", User: "#f0abfc", Amount: "#22d3ee", Message: "#e0e7ff"}
+	neon.Font = model.ThemeFont{Family: "Orbitron", Size: 20, Weight: 800}
+	neon.Animation = model.ThemeAnimation{In: "bounce", Out: "fade", DurationMs: 10000}
+
+	minimal := defaultWidgetTheme()
+	minimal.Colors = model.ThemeColors{Background: "rgba(0, 0, 0, 0.6)", User: "#ffffff", Amount: "#ffffff", Message: "#d4d4d8"}
+	minimal.Font = model.ThemeFont{Family: "Inter", Size: 16, Weight: 500}
+	minimal.Layout = model.ThemeLayout{Position: "top", Align: "right", Width: 420}
+	minimal.Animation = model.ThemeAnimation{In: "slide-left", Out: "slide-right", DurationMs: 7000}
+	minimal.TextTemplate = "{sender} · {amount} {asset}"
+
+	retro := defaultWidgetTheme()
+	retro.Colors = model.ThemeColors{Background: "#1e1b4b", User: "#fde047", Amount: "#4ade80", Message: "#ffffff"}
+	retro.Font = model.ThemeFont{Family: "Press Start 2P", Size: 14, Weight: 400}
+	retro.Animation = model.ThemeAnimation{In: "slide-up", Out: "slide-down", DurationMs: 12000}
+	retro.TextTemplate = "{sender} sent {amount} {asset}!"
+
+	return []model.ThemePreset{
+		{ID: "classic", Name: "Classic", Theme: defaultWidgetTheme()},
+		{ID: "neon", Name: "Neon", Theme: neon},
+		{ID: "minimal", Name: "Minimal", Theme: minimal},
+		{ID: "retro", Name: "Retro", Theme: retro},
+	}
+}()
+
+// legacyWidgetTheme upgrades the version 1 colors, empty colors keep the defaults
+func legacyWidgetTheme(bg, user, amount, message string) model.WidgetTheme {
+	theme := defaultWidgetTheme()
+	for _, color := range []struct {
+		value  string
+		target *string
+	}{
+		{bg, &theme.Colors.Background},
+		{user, &theme.Colors.User},
+		{amount, &theme.Colors.Amount},
+		{message, &theme.Colors.Message},
+	} {
+		if color.value != "" {
+			*color.target = color.value
+		}
+	}
+	return theme
+}
+
+// validateTheme normalises theme in place and reports every invalid field
+func validateTheme(theme *model.WidgetTheme) error {
+	fields := make(map[string]string)
+
+	if theme.Version == 0 {
+		theme.Version = widgetThemeVersion
+	}
+	if theme.Version != widgetThemeVersion {
+		fields["version"] = fmt.Sprintf("unsupported version %d, expected %d", theme.Version, widgetThemeVersion)
+	}
+
+	for path, color := range map[string]*string{
+		"colors.background": &theme.Colors.Background,
+		"colors.user":       &theme.Colors.User,
+		"colors.amount":     &theme.Colors.Amount,
+		"colors.message":    &theme.Colors.Message,
+	} {
+		*color = strings.ToLower(strings.TrimSpace(*color))
+		if !themeColorRegex.MatchString(*color) {
+			fields[path] = "must be a hex color like #1a2b3c, rgb(), rgba() or transparent"
+		}
+	}
+
+	theme.Font.Family = strings.TrimSpace(theme.Font.Family)
+	if !themeFontRegex.MatchString(theme.Font.Family) {
+		fields["font.family"] = "must be 1 to 40 letters, digits or spaces"
+	}
+	if theme.Font.Weight%100 != 0 {
+		fields["font.weight"] = "must be a multiple of 100"
+	}
+
+	for path, value := range map[string]int{
+		"font.size":             theme.Font.Size,
+		"font.weight":           theme.Font.Weight,
+		"layout.width":          theme.Layout.Width,
+		"animation.duration_ms": theme.Animation.DurationMs,
+		"sound.volume":          theme.Sound.Volume,
+	} {
+		bounds := themeBounds[path]
+		if value < bounds[0] || value > bounds[1] {
+			fields[path] = fmt.Sprintf("must be between %d and %d", bounds[0], bounds[1])
+		}
+	}
+
+	for _, choice := range []struct {
+		path    string
+		value   string
+		allowed []string
+	}{
+		{"layout.position", theme.Layout.Position, themePositions},
+		{"layout.align", theme.Layout.Align, themeAligns},
+		{"animation.in", theme.Animation.In, themeAnimationsIn},
+		{"animation.out", theme.Animation.Out, themeAnimationsOut},
+	} {
+		if !slices.Contains(choice.allowed, choice.value) {
+			fields[choice.path] = "must be one of " + strings.Join(choice.allowed, ", ")
+		}
+	}
+
+	for path, value := range map[string]*string{
+		"sound.url": &theme.Sound.URL,
+		"image_url": &theme.ImageURL,
+	} {
+		*value = strings.TrimSpace(*value)
+		if msg := validateThemeURL(*value); msg != "" {
+			fields[path] = msg
+		}
+	}
+
+	if msg := validateTextTemplate(theme.TextTemplate); msg != "" {
+		fields["text_template"] = msg
+	}
+
+	if len(fields) > 0 {
+		return &ThemeError{Fields: fields}
+	}
+	return nil
+}
+
+// validateThemeURL accepts an empty value or an absolute http(s) URL
+func validateThemeURL(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) > themeMaxURLLength {
+		return fmt.Sprintf("must be at most %d characters", themeMaxURLLength)
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "must be an http(s) URL"
+	}
+	return ""
+}
+
+func validateTextTemplate(tmpl string) string {
+	if strings.TrimSpace(tmpl) == "" {
+		return "must not be empty"
+	}
+	if len([]rune(tmpl)) > themeMaxTemplateSize {
+		return fmt.Sprintf("must be at most %d characters", themeMaxTemplateSize)
+	}
+	for _, m := range themePlaceholderRegex.FindAllStringSubmatch(tmpl, -1) {
+		if !slices.Contains(themePlaceholders, m[1]) {
+			return fmt.Sprintf("unknown placeholder %s, use {%s}", m[0], strings.Join(themePlaceholders, "}, {"))
+		}
+	}
+	if strings.ContainsAny(themePlaceholderRegex.ReplaceAllString(tmpl, ""), "{}") {
+		return "has an unmatched { or }"
+	}
+	return ""
+}
+
+// decodeTheme parses an exported theme. Missing fields take the defaults,
+// unknown fields are rejected so typos don't go unnoticed.
+func decodeTheme(data []byte) (*model.WidgetTheme, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, &ThemeError{Fields: map[string]string{"theme": "must be a JSON object: " + err.Error()}}
+	}
+
+	if header.Version == 1 {
+		// Version 1 was UpdateWidgetRequest's colors
+		var legacy struct {
+			Version int    `json:"version"`
+			TTS     bool   `json:"tts_enabled"`
+			Bg      string `json:"background_color"`
+			User    string `json:"user_color"`
+			Amount  string `json:"amount_color"`
+			Message string `json:"message_color"`
+		}
+		if err := strictUnmarshal(data, &legacy); err != nil {
+			return nil, err
+		}
+		theme := legacyWidgetTheme(legacy.Bg, legacy.User, legacy.Amount, legacy.Message)
+		if err := validateTheme(&theme); err != nil {
+			return nil, err
+		}
+		return &theme, nil
+	}
+
+	theme := defaultWidgetTheme()
+	if err := strictUnmarshal(data, &theme); err != nil {
+		return nil, err
+	}
+	if err := validateTheme(&theme); err != nil {
+		return nil, err
+	}
+	return &theme, nil
+}
+
+func strictUnmarshal(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return &ThemeError{Fields: map[string]string{"theme": err.Error()}}
+	}
+	return nil
+}
+
+func (s *Service) GetWidgetTheme(userID, widgetID uint) (*model.WidgetTheme, error) {
+	widget, err := s.getWidget(userID, widgetID)
+	if err != nil {
+		return nil, err
+	}
+	return widgetConfig(widget).Theme, nil
+}
+
+// ImportWidgetTheme replaces the widget's theme with an exported one
+func (s *Service) ImportWidgetTheme(userID, widgetID uint, data []byte) (*model.WidgetTheme, error) {
+	widget, err := s.getWidget(userID, widgetID)
+	if err != nil {
+		return nil, err
+	}
+	theme, err := decodeTheme(data)
+	if err != nil {
+		return nil, err
+	}
+
+	config := widgetConfig(widget)
+	config.Theme = theme
+	if err := s.UpdateWidgetSettings(widget, config); err != nil {
+		return nil, err
+	}
+	return theme, nil
+}
+
+// themeFilename names an exported theme after its widget
+func themeFilename(widget *dbmodel.Widget) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(widget.Name))
+	return strings.Trim(name, "-") + "-theme.json"
+}
+
+func (s *Server) HandleGetThemePresets(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"version": widgetThemeVersion, "presets": themePresets})
+}
+
+// HandleExportWidgetTheme downloads the widget's theme as JSON
+func (s *Server) HandleExportWidgetTheme(c *gin.Context) {
+	s.handleWidget(c, func(userID, widgetID uint) (any, error) {
+		widget, err := s.service.getWidget(userID, widgetID)
+		if err != nil {
+			return nil, err
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, themeFilename(widget)))
+		return widgetConfig(widget).Theme, nil
+	})
+}
+
+// HandleImportWidgetTheme replaces the widget's theme with the JSON body
+func (s *Server) HandleImportWidgetTheme(c *gin.Context) {
+	s.handleWidget(c, func(userID, widgetID uint) (any, error) {
+		data, err := io.ReadAll(io.LimitReader(c.Request.Body, themeMaxImportSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > themeMaxImportSize {
+			return nil, &ThemeError{Fields: map[string]string{"theme": fmt.Sprintf("must be at most %d bytes", themeMaxImportSize)}}
+		}
+		return s.service.ImportWidgetTheme(userID, widgetID, data)
+	})
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/patiee/backend/server/model"
+)
+
+func TestValidateTheme(t *testing.T) {
+	for _, preset := range themePresets {
+		theme := preset.Theme
+		if err := validateTheme(&theme); err != nil {
+			t.Errorf("preset %s: %v", preset.ID, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		edit   func(*model.WidgetTheme)
+		fields []string // Invalid fields, none for a valid theme
+	}{
+		{name: "missing version is current", edit: func(th *model.WidgetTheme) { th.Version = 0 }},
+		{name: "rgba and short hex colors", edit: func(th *model.WidgetTheme) {
+			th.Colors.Background = " RGBA(0, 0, 0, .5) "
+			th.Colors.User = "#FFF"
+			th.Colors.Amount = "transparent"
+		}},
+		{name: "template with every placeholder", edit: func(th *model.WidgetTheme) {
+			th.TextTemplate = "{sender} sent {amount} {asset} (${usd}): {message}"
+		}},
+		{name: "urls", edit: func(th *model.WidgetTheme) {
+			th.ImageURL = "https://example.com/a.png"
+			th.Sound.URL = "http://example.com/a.mp3"
+		}},
+		{name: "future version", edit: func(th *model.WidgetTheme) { th.Version = 3 }, fields: []string{"version"}},
+		{name: "css injection in a color", edit: func(th *model.WidgetTheme) {
+			th.Colors.Message = "red; background: url(https://evil.example)"
+		}, fields: []string{"colors.message"}},
+		{name: "named color", edit: func(th *model.WidgetTheme) { th.Colors.User = "red" }, fields: []string{"colors.user"}},
+		{name: "font family with quotes", edit: func(th *model.WidgetTheme) { th.Font.Family = `Inter", serif` }, fields: []string{"font.family"}},
+		{name: "odd font weight", edit: func(th *model.WidgetTheme) { th.Font.Weight = 450 }, fields: []string{"font.weight"}},
+		{name: "out of bounds", edit: func(th *model.WidgetTheme) {
+			th.Font.Size = 200
+			th.Layout.Width = 10
+			th.Animation.DurationMs = 500
+			th.Sound.Volume = 101
+		}, fields: []string{"font.size", "layout.width", "animation.duration_ms", "sound.volume"}},
+		{name: "unknown choices", edit: func(th *model.WidgetTheme) {
+			th.Layout.Position = "left"
+			th.Layout.Align = "justify"
+			th.Animation.In = "spin"
+			th.Animation.Out = "bounce"
+		}, fields: []string{"layout.position", "layout.align", "animation.in", "animation.out"}},
+		{name: "script url", edit: func(th *model.WidgetTheme) { th.ImageURL = "javascript:alert(1)" }, fields: []string{"image_url"}},
+		{name: "relative url", edit: func(th *model.WidgetTheme) { th.Sound.URL = "/sound.mp3" }, fields: []string{"sound.url"}},
+		{name: "long url", edit: func(th *model.WidgetTheme) {
+			th.ImageURL = "https://example.com/" + strings.Repeat("a", themeMaxURLLength)
+		}, fields: []string{"image_url"}},
+		{name: "empty template", edit: func(th *model.WidgetTheme) { th.TextTemplate = "  " }, fields: []string{"text_template"}},
+		{name: "unknown placeholder", edit: func(th *model.WidgetTheme) { th.TextTemplate = "{streamer} got {amount}" }, fields: []string{"text_template"}},
+		{name: "unmatched brace", edit: func(th *model.WidgetTheme) { th.TextTemplate = "{sender tipped" }, fields: []string{"text_template"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme := defaultWidgetTheme()
+			tt.edit(&theme)
+			err := validateTheme(&theme)
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Fatalf("validateTheme: %v", err)
+				}
+				return
+			}
+
+			var themeErr *ThemeError
+			if !errors.As(err, &themeErr) || !errors.Is(err, ErrInvalidWidget) {
+				t.Fatalf("err = %v, want a ThemeError", err)
+			}
+			if len(themeErr.Fields) != len(tt.fields) {
+				t.Fatalf("invalid fields %v, want %v", themeErr.Fields, tt.fields)
+			}
+			for _, field := range tt.fields {
+				if themeErr.Fields[field] == "" {
+					t.Errorf("%s not reported in %v", field, themeErr.Fields)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateThemeNormalises(t *testing.T) {
+	theme := defaultWidgetTheme()
+	theme.Version = 0
+	theme.Colors.Background = "  #ABCDEF "
+	theme.Font.Family = " Press Start 2P "
+	theme.ImageURL = " https://example.com/a.png "
+	if err := validateTheme(&theme); err != nil {
+		t.Fatalf("validateTheme: %v", err)
+	}
+	if theme.Version != widgetThemeVersion || theme.Colors.Background != "#abcdef" || theme.Font.Family != "Press Start 2P" || theme.ImageURL != "https://example.com/a.png" {
+		t.Fatalf("not normalised: %+v", theme)
+	}
+}
+
+func TestDecodeTheme(t *testing.T) {
+	// Version 1 was the widget's colors, missing ones keep the defaults
+	theme, err := decodeTheme([]byte(`{"version": 1, "tts_enabled": true, "background_color": "#111111", "user_color": ""}`))
+	if err != nil {
+		t.Fatalf("decodeTheme v1: %v", err)
+	}
+	if theme.Version != widgetThemeVersion || theme.Colors.Background != "#111111" || theme.Colors.User != defaultWidgetTheme().Colors.User {
+		t.Fatalf("upgraded theme = %+v", theme)
+	}
+
+	theme, err = decodeTheme([]byte(`{"version": 2, "font": {"family": "Orbitron", "size": 20, "weight": 800}}`))
+	if err != nil {
+		t.Fatalf("decodeTheme v2: %v", err)
+	}
+	if theme.Font.Family != "Orbitron" || theme.Layout != defaultWidgetTheme().Layout {
+		t.Fatalf("partial theme = %+v", theme)
+	}
+
+	for _, data := range []string{
+		`not json`,
+		`{"version": 2, "colour": {}}`,
+		`{"version": 1, "theme": "dark"}`,
+		`{"version": 2, "colors": {"background": "url(x)"}}`,
+	} {
+		if _, err := decodeTheme([]byte(data)); !errors.Is(err, ErrInvalidWidget) {
+			t.Errorf("decodeTheme(%s) = %v, want ErrInvalidWidget", data, err)
+		}
+	}
+}
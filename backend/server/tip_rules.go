@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+)
+
+var ErrInvalidTipRules = errors.New("invalid tip rules")
+
+// TipRules are a streamer's minimums. USD thresholds compare against the
+// tip's USD value; unpriced tips count as $0.
+type TipRules struct {
+	MinAmounts    map[string]*big.Rat // Per asset symbol (upper case)
+	MinUSD        *big.Rat
+	MessageMinUSD *big.Rat // Below: the message is hidden
+	TTSMinUSD     *big.Rat // Below: the message is not read out
+	MediaMinUSD   *big.Rat // Below: attached media is not shown
+}
+
+// AlertFlags is what the overlay renders for a tip. TTS additionally
+// requires TTS to be enabled in the widget's config.
+type AlertFlags struct {
+	ShowMessage bool
+	TTS         bool
+	ShowMedia   bool
+}
+
+func tipRulesFor(user *dbmodel.User) *TipRules {
+	rules := &TipRules{
+		MinAmounts:    make(map[string]*big.Rat),
+		MinUSD:        parseThreshold(user.TipMinUSD),
+		MessageMinUSD: parseThreshold(user.MessageMinUSD),
+		TTSMinUSD:     parseThreshold(user.TTSMinUSD),
+		MediaMinUSD:   parseThreshold(user.MediaMinUSD),
+	}
+
+	var amounts map[string]string
+	if user.TipMinAmounts != "" && json.Unmarshal([]byte(user.TipMinAmounts), &amounts) == nil {
+		for asset, amount := range amounts {
+			if min := parseThreshold(amount); min != nil {
+				rules.MinAmounts[strings.ToUpper(asset)] = min
+			}
+		}
+	}
+	return rules
+}
+
+// parseThreshold returns nil for empty, invalid or zero thresholds
+func parseThreshold(value string) *big.Rat {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || r.Sign() <= 0 {
+		return nil
+	}
+	return r
+}
+
+func belowThreshold(value *big.Rat, min *big.Rat) bool {
+	return min != nil && value.Cmp(min) < 0
+}
+
+// CheckMinimum returns a user facing reason when amount of asset on chainID
+// is below the streamer's minimum, or "" if the tip is allowed
+func (s *Service) CheckMinimum(ctx context.Context, rules *TipRules, chainID, amount, asset string) string {
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return "Invalid amount."
+	}
+
+	if min, ok := rules.MinAmounts[strings.ToUpper(asset)]; ok && belowThreshold(value, min) {
+		return fmt.Sprintf("Minimum tip is %s %s.", formatDecimal(min), asset)
+	}
+
+	if rules.MinUSD != nil {
+		usd, err := s.usdValue(ctx, chainID, amount, asset)
+		if err != nil {
+			// Don't block tips in assets we can't price
+			s.logger.Printf("Skipping USD minimum for %s %s: %v", amount, asset, err)
+			return ""
+		}
+		if belowThreshold(parseRat(usd), rules.MinUSD) {
+			return fmt.Sprintf("Minimum tip is $%s.", rules.MinUSD.FloatString(2))
+		}
+	}
+	return ""
+}
+
+// AlertFlags decides what the overlay shows for the tip
+func (rules *TipRules) AlertFlags(tip *dbmodel.Tip) AlertFlags {
+	usd := parseRat(tip.USDValue)
+	flags := AlertFlags{
+		ShowMessage: tip.Message != "" && !belowThreshold(usd, rules.MessageMinUSD),
+		ShowMedia:   !belowThreshold(usd, rules.MediaMinUSD),
+	}
+	flags.TTS = flags.ShowMessage && !belowThreshold(usd, rules.TTSMinUSD)
+	return flags
+}
+
+// parseRat parses a decimal string, treating invalid or empty values as 0
+func parseRat(value string) *big.Rat {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return new(big.Rat)
+	}
+	return r
+}
+
+// formatDecimal renders r without trailing zeros
+func formatDecimal(r *big.Rat) string {
+	return normalizeDecimal(r.FloatString(18))
+}
+
+func (s *Service) GetTipRules(userID uint) (*model.TipRulesSettings, error) {
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return tipRulesSettings(user), nil
+}
+
+func tipRulesSettings(user *dbmodel.User) *model.TipRulesSettings {
+	rules := tipRulesFor(user)
+	settings := &model.TipRulesSettings{MinAmounts: make(map[string]string, len(rules.MinAmounts))}
+	for asset, min := range rules.MinAmounts {
+		settings.MinAmounts[asset] = formatDecimal(min)
+	}
+	for _, t := range []struct {
+		value *big.Rat
+		out   *string
+	}{
+		{rules.MinUSD, &settings.MinUSD},
+		{rules.MessageMinUSD, &settings.MessageMinUSD},
+		{rules.TTSMinUSD, &settings.TTSMinUSD},
+		{rules.MediaMinUSD, &settings.MediaMinUSD},
+	} {
+		if t.value != nil {
+			*t.out = t.value.FloatString(2)
+		}
+	}
+	return settings
+}
+
+func (s *Service) UpdateTipRules(userID uint, req model.TipRulesSettings) error {
+	// Empty means no threshold
+	for name, value := range map[string]string{
+		"min_usd":         req.MinUSD,
+		"message_min_usd": req.MessageMinUSD,
+		"tts_min_usd":     req.TTSMinUSD,
+		"media_min_usd":   req.MediaMinUSD,
+	} {
+		if value != "" && !tipAmountRegex.MatchString(value) {
+			return fmt.Errorf("%w: %s must be a positive decimal", ErrInvalidTipRules, name)
+		}
+	}
+
+	amounts := make(map[string]string, len(req.MinAmounts))
+	for asset, value := range req.MinAmounts {
+		asset = strings.ToUpper(strings.TrimSpace(asset))
+		if asset == "" || !tipAmountRegex.MatchString(value) {
+			return fmt.Errorf("%w: invalid minimum %q for asset %q", ErrInvalidTipRules, value, asset)
+		}
+		amounts[asset] = value
+	}
+	minAmounts, err := json.Marshal(amounts)
+	if err != nil {
+		return err
+	}
+
+	return s.db.UpdateTipRules(userID, &dbmodel.User{
+		TipMinAmounts: string(minAmounts),
+		TipMinUSD:     req.MinUSD,
+		MessageMinUSD: req.MessageMinUSD,
+		TTSMinUSD:     req.TTSMinUSD,
+		MediaMinUSD:   req.MediaMinUSD,
+	})
+}
+
+// checkTipMinimum enforces the streamer's minimum on a submitted tip
+func (s *Service) checkTipMinimum(streamer *dbmodel.User, chainID, amount, asset string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.CheckMinimum(ctx, tipRulesFor(streamer), chainID, amount, asset)
+}
+
+func (s *Server) HandleGetTipRules(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	rules, err := s.service.GetTipRules(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (s *Server) HandleUpdateTipRules(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	var req model.TipRulesSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := s.service.UpdateTipRules(claims.UserID, req); err != nil {
+		if errors.Is(err, ErrInvalidTipRules) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.Printf("Failed to update tip rules: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tip rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tip rules updated"})
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+)
+
+func TestCheckMinimum(t *testing.T) {
+	s := newTestService()
+	s.prices = NewStaticPriceOracle(map[string]*big.Rat{
+		PriceKey("8453", ""):       big.NewRat(2000, 1),
+		PriceKey("8453", baseUSDC): big.NewRat(1, 1),
+		PriceKey("bitcoin", ""):    big.NewRat(50000, 1),
+	})
+	rules := tipRulesFor(&dbmodel.User{TipMinAmounts: `{"eth": "0.001", "USDC": "2.5"}`, TipMinUSD: "5"})
+
+	tests := []struct {
+		name    string
+		chainID string
+		amount  string
+		asset   string
+		allowed bool
+	}{
+		{name: "asset minimum met", chainID: "8453", amount: "0.003", asset: "ETH", allowed: true},
+		{name: "asset minimum equal", chainID: "8453", amount: "5", asset: "usdc", allowed: true},
+		{name: "below asset minimum", chainID: "8453", amount: "0.0009", asset: "ETH"},
+		{name: "below asset minimum by contract", chainID: "8453", amount: "2", asset: "USDC"},
+		{name: "usd minimum equal", chainID: "8453", amount: "0.0025", asset: "ETH", allowed: true},
+		{name: "below usd minimum", chainID: "8453", amount: "0.002", asset: "ETH"},
+		{name: "token priced by contract", chainID: "8453", amount: "4.99", asset: baseUSDC},
+		{name: "bridge tip priced on its source chain", chainID: "bitcoin", amount: "0.0001", asset: "BTC", allowed: true},
+		{name: "bridge tip below usd minimum", chainID: "bitcoin", amount: "0.00009", asset: "BTC"},
+		{name: "unpriced asset", chainID: "8453", amount: "1", asset: "0x2222222222222222222222222222222222222222", allowed: true},
+		{name: "unpriced chain", chainID: "solana", amount: "0.0001", asset: "SOL", allowed: true},
+		{name: "invalid amount", chainID: "8453", amount: "one", asset: "ETH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := s.CheckMinimum(context.Background(), rules, tt.chainID, tt.amount, tt.asset)
+			if tt.allowed && reason != "" {
+				t.Fatalf("rejected: %s", reason)
+			}
+			if !tt.allowed && reason == "" {
+				t.Fatal("allowed")
+			}
+		})
+	}
+
+	// No rules, nothing to price
+	s.prices = nil
+	if reason := s.CheckMinimum(context.Background(), tipRulesFor(&dbmodel.User{}), "8453", "0.0000001", "ETH"); reason != "" {
+		t.Fatalf("rejected without rules: %s", reason)
+	}
+}
+
+func TestAlertFlags(t *testing.T) {
+	rules := tipRulesFor(&dbmodel.User{MessageMinUSD: "1", TTSMinUSD: "5", MediaMinUSD: "10.00"})
+
+	tests := []struct {
+		usd     string
+		message string
+		want    AlertFlags
+	}{
+		{usd: "", message: "hi", want: AlertFlags{}}, // Unpriced counts as $0
+		{usd: "0.99", message: "hi", want: AlertFlags{}},
+		{usd: "1", message: "hi", want: AlertFlags{ShowMessage: true}},
+		{usd: "4.99", message: "hi", want: AlertFlags{ShowMessage: true}},
+		{usd: "5.00", message: "hi", want: AlertFlags{ShowMessage: true, TTS: true}},
+		{usd: "10", message: "hi", want: AlertFlags{ShowMessage: true, TTS: true, ShowMedia: true}},
+		{usd: "10", message: "", want: AlertFlags{ShowMedia: true}}, // Nothing to show or read
+	}
+	for _, tt := range tests {
+		if got := rules.AlertFlags(&dbmodel.Tip{USDValue: tt.usd, Message: tt.message}); got != tt.want {
+			t.Errorf("$%q %q: got %+v, want %+v", tt.usd, tt.message, got, tt.want)
+		}
+	}
+
+	// Without thresholds everything is shown
+	if got := tipRulesFor(&dbmodel.User{TTSMinUSD: "0"}).AlertFlags(&dbmodel.Tip{Message: "hi"}); got != (AlertFlags{ShowMessage: true, TTS: true, ShowMedia: true}) {
+		t.Errorf("no thresholds: got %+v", got)
+	}
+}
+
+func TestTipRulesFor(t *testing.T) {
+	rules := tipRulesFor(&dbmodel.User{TipMinAmounts: `{"eth": "0.01", "usdc": "0", "sol": "x"}`, TipMinUSD: "-1", MessageMinUSD: "2.50"})
+	if len(rules.MinAmounts) != 1 || rules.MinAmounts["ETH"].Cmp(big.NewRat(1, 100)) != 0 {
+		t.Fatalf("MinAmounts = %v", rules.MinAmounts)
+	}
+	if rules.MinUSD != nil || rules.MessageMinUSD.Cmp(big.NewRat(5, 2)) != 0 {
+		t.Fatalf("thresholds = %v, %v", rules.MinUSD, rules.MessageMinUSD)
+	}
+
+	settings := tipRulesSettings(&dbmodel.User{TipMinAmounts: `{"ETH": "0.0100"}`, TipMinUSD: "5", TTSMinUSD: "1.5"})
+	if settings.MinAmounts["ETH"] != "0.01" || settings.MinUSD != "5.00" || settings.TTSMinUSD != "1.50" || settings.MediaMinUSD != "" {
+		t.Fatalf("settings = %+v", settings)
+	}
+}
+
+func TestUpdateTipRulesRejects(t *testing.T) {
+	s := newTestService()
+	tests := map[string]model.TipRulesSettings{
+		"negative usd":          {MinUSD: "-5"},
+		"usd with a symbol":     {MessageMinUSD: "$5"},
+		"exponent":              {TTSMinUSD: "1e3"},
+		"comma decimal":         {MediaMinUSD: "1,5"},
+		"empty asset":           {MinAmounts: map[string]string{" ": "1"}},
+		"empty asset minimum":   {MinAmounts: map[string]string{"ETH": ""}},
+		"invalid asset minimum": {MinAmounts: map[string]string{"ETH": "0.1.2"}},
+	}
+	for name, req := range tests {
+		// Rejected before anything is stored
+		if err := s.UpdateTipRules(1, req); !errors.Is(err, ErrInvalidTipRules) {
+			t.Errorf("%s: err = %v, want ErrInvalidTipRules", name, err)
+		}
+	}
+}
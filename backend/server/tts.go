@@ -0,0 +1,288 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+)
+
+const (
+	ttsBucket          = "tts"
+	ttsTimeout         = 15 * time.Second
+	ttsMaxAudioSize    = 10 << 20
+	ttsDefaultLanguage = "en"
+	ttsDefaultRate     = 175
+	ttsMinRate         = 80
+	ttsMaxRate         = 400
+)
+
+var ErrInvalidTTSSettings = errors.New("invalid tts settings")
+
+var (
+	ttsVoiceRegex    = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_+-]{0,39}$`)
+	ttsLanguageRegex = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+)
+
+// TTSVoice selects how a message is spoken
+type TTSVoice struct {
+	Voice    string // Engine specific voice name, empty for the language's default
+	Language string // e.g. "en", "pt-br"
+	Rate     int    // Words per minute
+}
+
+// TTSEngine renders text to speech, returning the audio and its content type
+type TTSEngine interface {
+	Synthesize(ctx context.Context, text string, voice TTSVoice) ([]byte, string, error)
+}
+
+// CommandTTSEngine runs an offline TTS binary that reads text on stdin and
+// writes audio to stdout. {voice}, {language} and {rate} in its arguments are
+// replaced per message.
+type CommandTTSEngine struct {
+	name string
+	args []string
+}
+
+func NewCommandTTSEngine(command string) (*CommandTTSEngine, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, errors.New("empty tts command")
+	}
+	if _, err := exec.LookPath(fields[0]); err != nil {
+		return nil, fmt.Errorf("tts binary not found: %v", err)
+	}
+	return &CommandTTSEngine{name: fields[0], args: fields[1:]}, nil
+}
+
+func (e *CommandTTSEngine) Synthesize(ctx context.Context, text string, voice TTSVoice) ([]byte, string, error) {
+	voiceName := voice.Voice
+	if voiceName == "" {
+		voiceName = voice.Language
+	}
+	replacer := strings.NewReplacer("{voice}", voiceName, "{language}", voice.Language, "{rate}", strconv.Itoa(voice.Rate))
+	args := make([]string, len(e.args))
+	for i, arg := range e.args {
+		args[i] = replacer.Replace(arg)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ttsTimeout)
+	defer cancel()
+
+	// Text goes through stdin, never the arguments
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.name, args...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, "", fmt.Errorf("%s failed: %v: %s", e.name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	audio := stdout.Bytes()
+	if len(audio) > ttsMaxAudioSize {
+		return nil, "", fmt.Errorf("%s wrote %d bytes, more than %d", e.name, len(audio), ttsMaxAudioSize)
+	}
+	contentType := sniffMedia(audio)
+	if mediaKinds[contentType] != dbmodel.MediaKindAudio {
+		return nil, "", fmt.Errorf("%s wrote unrecognized audio", e.name)
+	}
+	return audio, contentType, nil
+}
+
+// newTTSEngine returns nil when server side TTS isn't configured
+func newTTSEngine(config Config, logger *log.Logger) TTSEngine {
+	if config.TTSCommand == "" {
+		return nil
+	}
+	engine, err := NewCommandTTSEngine(config.TTSCommand)
+	if err != nil {
+		logger.Printf("Server side TTS disabled: %v", err)
+		return nil
+	}
+	return engine
+}
+
+// SetTTSEngine replaces the TTS engine, nil leaves TTS to the browser
+func (s *Service) SetTTSEngine(e TTSEngine) {
+	s.tts = e
+}
+
+// ttsVoiceFor returns the streamer's voice with defaults filled in
+func ttsVoiceFor(user *dbmodel.User) TTSVoice {
+	voice := TTSVoice{Voice: user.TTSVoice, Language: user.TTSLanguage, Rate: user.TTSRate}
+	if voice.Language == "" {
+		voice.Language = ttsDefaultLanguage
+	}
+	if voice.Rate == 0 {
+		voice.Rate = ttsDefaultRate
+	}
+	return voice
+}
+
+// ttsStore keeps rendered audio under a key derived from its text and voice
+type ttsStore interface {
+	Lookup(ctx context.Context, key string) (url string, ok bool, err error)
+	Store(ctx context.Context, key, contentType string, audio []byte) (string, error)
+}
+
+// minioTTSStore keeps rendered audio in the tts bucket
+type minioTTSStore struct {
+	service *Service
+}
+
+// Lookup finds audio rendered earlier under key, in any format
+func (m *minioTTSStore) Lookup(ctx context.Context, key string) (string, bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for object := range minioClient.ListObjects(ctx, ttsBucket, minio.ListObjectsOptions{Prefix: key + "."}) {
+		if object.Err != nil {
+			return "", false, object.Err
+		}
+		return m.service.objectURL(ttsBucket, object.Key), true, nil
+	}
+	return "", false, nil
+}
+
+func (m *minioTTSStore) Store(ctx context.Context, key, contentType string, audio []byte) (string, error) {
+	return m.service.storeObject(ctx, ttsBucket, key+mediaExtensions[contentType], contentType, audio)
+}
+
+// renderTipTTS renders the confirmed tip's message once, before its alert is
+// queued
+func (s *Service) renderTipTTS(ctx context.Context, tip *dbmodel.Tip) {
+	if s.tts == nil || minioClient == nil || tip.Message == "" || tip.TTSAudioURL != "" {
+		return
+	}
+	user, err := s.db.GetUserByUsername(tip.StreamerID)
+	if err != nil {
+		s.logger.Printf("Failed to find streamer %s: %v", tip.StreamerID, err)
+		return
+	}
+	if !tipRulesFor(user).AlertFlags(tip).TTS {
+		return
+	}
+
+	url, err := s.tipTTSAudio(ctx, tip, ttsVoiceFor(user))
+	if err != nil {
+		s.logger.Printf("Failed to render TTS for tip %d: %v", tip.ID, err)
+		return
+	}
+
+	tip.TTSAudioURL = url
+	if err := s.db.UpdateTipTTSAudio(tip.ID, url); err != nil {
+		s.logger.Printf("Failed to save TTS audio of tip %d: %v", tip.ID, err)
+	}
+}
+
+// tipTTSAudio returns the URL of the tip's message spoken in voice.
+// Identical text in the same voice reuses the stored audio.
+func (s *Service) tipTTSAudio(ctx context.Context, tip *dbmodel.Tip, voice TTSVoice) (string, error) {
+	text := fmt.Sprintf("%s says: %s", tip.Sender, tip.Message)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d\x00%s", voice.Voice, voice.Language, voice.Rate, text)))
+	key := hex.EncodeToString(sum[:])
+
+	url, ok, err := s.ttsAudio.Lookup(ctx, key)
+	if err != nil {
+		s.logger.Printf("Failed to look up cached TTS %s: %v", key, err)
+	}
+	if ok {
+		return url, nil
+	}
+
+	audio, contentType, err := s.tts.Synthesize(ctx, text, voice)
+	if err != nil {
+		return "", err
+	}
+	return s.ttsAudio.Store(ctx, key, contentType, audio)
+}
+
+func (s *Service) GetTTSSettings(userID uint) (*model.TTSSettings, error) {
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	voice := ttsVoiceFor(user)
+	return &model.TTSSettings{
+		Voice:     user.TTSVoice,
+		Language:  voice.Language,
+		Rate:      voice.Rate,
+		ServerTTS: s.tts != nil,
+	}, nil
+}
+
+func (s *Service) UpdateTTSSettings(userID uint, req model.TTSSettings) (*model.TTSSettings, error) {
+	voice := strings.TrimSpace(req.Voice)
+	if voice != "" && !ttsVoiceRegex.MatchString(voice) {
+		return nil, fmt.Errorf("%w: voice must be up to 40 letters, digits, _, + or -", ErrInvalidTTSSettings)
+	}
+	language := strings.ToLower(strings.TrimSpace(req.Language))
+	if language != "" && !ttsLanguageRegex.MatchString(language) {
+		return nil, fmt.Errorf("%w: language must be a code like en or pt-br", ErrInvalidTTSSettings)
+	}
+	if req.Rate != 0 && (req.Rate < ttsMinRate || req.Rate > ttsMaxRate) {
+		return nil, fmt.Errorf("%w: rate must be between %d and %d words per minute", ErrInvalidTTSSettings, ttsMinRate, ttsMaxRate)
+	}
+
+	if err := s.db.UpdateTTSSettings(userID, &dbmodel.User{TTSVoice: voice, TTSLanguage: language, TTSRate: req.Rate}); err != nil {
+		return nil, err
+	}
+	return s.GetTTSSettings(userID)
+}
+
+func (s *Server) HandleGetTTSSettings(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	settings, err := s.service.GetTTSSettings(claims.UserID)
+	if err != nil {
+		s.logger.Printf("Failed to fetch TTS settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch TTS settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (s *Server) HandleUpdateTTSSettings(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	var req model.TTSSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	settings, err := s.service.UpdateTTSSettings(claims.UserID, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidTTSSettings) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.Printf("Failed to update TTS settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update TTS settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
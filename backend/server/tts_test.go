@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	dbmodel "github.com/patiee/backend/db/model"
+)
+
+// stubTTSEngine records what it was asked to say
+type stubTTSEngine struct {
+	texts  []string
+	voices []TTSVoice
+	err    error
+}
+
+func (e *stubTTSEngine) Synthesize(ctx context.Context, text string, voice TTSVoice) ([]byte, string, error) {
+	e.texts = append(e.texts, text)
+	e.voices = append(e.voices, voice)
+	if e.err != nil {
+		return nil, "", e.err
+	}
+	return []byte("ID3audio"), "audio/mpeg", nil
+}
+
+// memoryTTSStore keeps audio in a map
+type memoryTTSStore map[string][]byte
+
+func (m memoryTTSStore) Lookup(ctx context.Context, key string) (string, bool, error) {
+	_, ok := m[key]
+	return "mem://" + key, ok, nil
+}
+
+func (m memoryTTSStore) Store(ctx context.Context, key, contentType string, audio []byte) (string, error) {
+	m[key] = audio
+	return "mem://" + key, nil
+}
+
+func TestTipTTSAudio(t *testing.T) {
+	engine := &stubTTSEngine{}
+	store := memoryTTSStore{}
+	s := newTestService()
+	s.SetTTSEngine(engine)
+	s.ttsAudio = store
+
+	tip := &dbmodel.Tip{Sender: "alice", Message: "gm"}
+	voice := ttsVoiceFor(&dbmodel.User{TTSVoice: "en-us+f3"})
+	url, err := s.tipTTSAudio(context.Background(), tip, voice)
+	if err != nil {
+		t.Fatalf("tipTTSAudio: %v", err)
+	}
+	if len(engine.texts) != 1 || engine.texts[0] != "alice says: gm" {
+		t.Fatalf("engine said %q", engine.texts)
+	}
+	if v := engine.voices[0]; v.Voice != "en-us+f3" || v.Language != ttsDefaultLanguage || v.Rate != ttsDefaultRate {
+		t.Fatalf("voice = %+v, want defaults filled in", v)
+	}
+	if len(store) != 1 {
+		t.Fatalf("%d stored files, want 1", len(store))
+	}
+
+	// The same text in the same voice is rendered once
+	again, err := s.tipTTSAudio(context.Background(), &dbmodel.Tip{Sender: "alice", Message: "gm"}, voice)
+	if err != nil || again != url {
+		t.Fatalf("got %q, %v; want cached %q", again, err, url)
+	}
+	if len(engine.texts) != 1 {
+		t.Fatalf("engine called %d times, want 1", len(engine.texts))
+	}
+
+	// Another voice is a different recording
+	voice.Rate = 200
+	if other, err := s.tipTTSAudio(context.Background(), tip, voice); err != nil || other == url {
+		t.Fatalf("got %q, %v; want a new recording", other, err)
+	}
+
+	engine.err = errors.New("engine down")
+	if _, err := s.tipTTSAudio(context.Background(), &dbmodel.Tip{Sender: "bob", Message: "hi"}, voice); !errors.Is(err, engine.err) {
+		t.Fatalf("err = %v, want the engine error", err)
+	}
+	if len(store) != 2 {
+		t.Fatalf("%d stored files after a failed render, want 2", len(store))
+	}
+}
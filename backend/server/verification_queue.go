@@ -0,0 +1,291 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	dbmodel "github.com/patiee/backend/db/model"
+	"gorm.io/gorm"
+)
+
+const (
+	verificationWorkers   = 4
+	verificationLease     = 2 * time.Minute
+	verificationPoll      = 2 * time.Second
+	verificationBaseDelay = 5 * time.Second
+	verificationMaxDelay  = 5 * time.Minute
+	verificationTimeout   = 2 * time.Hour // Give it time for L1/L2 consistency and slow BTC blocks
+)
+
+// StartVerificationWorkers resumes pending tips left over from a previous run
+// and starts the worker pool that drains the verification job table.
+func (s *Service) StartVerificationWorkers(ctx context.Context) {
+	if n, err := s.db.EnqueuePendingTips(); err != nil {
+		s.logger.Printf("Failed to resume pending tips: %v", err)
+	} else if n > 0 {
+		s.logger.Printf("Resumed verification of %d pending tips", n)
+	}
+
+	hostname, _ := os.Hostname()
+	for i := 0; i < verificationWorkers; i++ {
+		owner := fmt.Sprintf("%s-%d-%s", hostname, i, uuid.New().String()[:8])
+		go s.runVerificationWorker(ctx, owner)
+	}
+}
+
+func (s *Service) runVerificationWorker(ctx context.Context, owner string) {
+	for {
+		job, err := s.db.LeaseVerificationJob(owner, verificationLease)
+		if err != nil {
+			s.logger.Printf("Failed to lease verification job: %v", err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(verificationPoll):
+			}
+			continue
+		}
+
+		s.processVerificationJob(job)
+	}
+}
+
+func (s *Service) processVerificationJob(job *dbmodel.VerificationJob) {
+	// Defer panic recovery just in case
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Printf("Recovered from panic verifying tip %d: %v", job.TipID, r)
+			s.retryVerificationJob(job, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	tip, err := s.db.GetTipByID(job.TipID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Printf("Failed to load tip %d for verification: %v", job.TipID, err)
+		s.retryVerificationJob(job, err)
+		return
+	}
+	if err != nil || !isUnsettledTipStatus(tip.Status) {
+		// Tip deleted or already settled
+		s.db.DeleteVerificationJob(job.ID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	var settled bool
+	if isBridgeTip(tip) {
+		settled, err = s.advanceBridgeTip(ctx, tip)
+	} else {
+		settled, err = s.advanceDirectTip(ctx, tip)
+	}
+	cancel()
+
+	if settled {
+		s.db.DeleteVerificationJob(job.ID)
+		return
+	}
+
+	if err != nil && isPermanentVerifyError(err) {
+		s.logger.Printf("Transaction verification failed for tip %d: %v", tip.ID, err)
+		s.failTip(tip)
+		s.db.DeleteVerificationJob(job.ID)
+		return
+	}
+
+	if time.Since(job.CreatedAt) > verificationTimeout {
+		s.logger.Printf("Transaction verification timed out for tip %d", tip.ID)
+		s.failTip(tip)
+		s.db.DeleteVerificationJob(job.ID)
+		return
+	}
+
+	// Not found yet or a temporary RPC error, try again later
+	if err != nil && !errors.Is(err, ErrTxNotFound) {
+		s.logger.Printf("RPC error checking tip %d: %v", tip.ID, err)
+	}
+	s.retryVerificationJob(job, err)
+}
+
+// advanceDirectTip handles a tip paid on a single chain: pending -> seen -> confirmed.
+// It returns true once the tip reached a final status.
+func (s *Service) advanceDirectTip(ctx context.Context, tip *dbmodel.Tip) (bool, error) {
+	// Checks against Sender Wallet (SourceAddress), NOT the Name.
+	result, err := s.verifyPayment(ctx, tip, tip.ChainID, tip.TxHash, tip.SourceAddress)
+	if err != nil {
+		return false, err
+	}
+
+	if s.confirmIfFinal(ctx, tip, tip.ChainID, result) {
+		return true, nil
+	}
+	if tip.Status == dbmodel.TipStatusPending {
+		s.setTipStatus(tip, dbmodel.TipStatusSeen)
+	}
+	return false, nil
+}
+
+// confirmIfFinal marks the tip confirmed and alerts the widgets once the tx
+// meets the chain's confirmation policy
+func (s *Service) confirmIfFinal(ctx context.Context, tip *dbmodel.Tip, chainID string, result *TxResult) bool {
+	policy := s.confirmationPolicy(chainID)
+	if !policy.Satisfied(result) {
+		s.logger.Printf("Tip %d seen with %d confirmations, waiting for %s", tip.ID, result.Confirmations, policy)
+		return false
+	}
+
+	s.logger.Printf("Transaction confirmed for tip %d (%d confirmations, policy %s)", tip.ID, result.Confirmations, policy)
+	s.valueTip(ctx, tip)
+	if err := s.db.UpdateTipStatus(tip.ID, dbmodel.TipStatusConfirmed); err != nil {
+		// Alerting without the confirmed status would alert again on the retry
+		s.logger.Printf("Failed to update tip %d status: %v", tip.ID, err)
+		return false
+	}
+	tip.Status = dbmodel.TipStatusConfirmed
+	s.renderTipTTS(ctx, tip)
+	s.EnqueueAlert(tip)
+	s.UpdateGoalProgress(tip)
+	s.AnnounceTip(tip)
+	s.EmitTipWebhook(tip, WebhookEventTipConfirmed)
+	return true
+}
+
+// setTipStatus stores an intermediate status and lets widgets follow the
+// progress with a TIP_<STATUS> event (e.g. TIP_SEEN, TIP_BRIDGING)
+func (s *Service) setTipStatus(tip *dbmodel.Tip, status string) {
+	tip.Status = status
+	if err := s.db.UpdateTipStatus(tip.ID, status); err != nil {
+		s.logger.Printf("Failed to update tip %d status: %v", tip.ID, err)
+		return
+	}
+	s.notifyTip(tip, "TIP_"+strings.ToUpper(status))
+}
+
+// failTip marks the tip failed for good
+func (s *Service) failTip(tip *dbmodel.Tip) {
+	tip.Status = dbmodel.TipStatusFailed
+	if err := s.db.UpdateTipStatus(tip.ID, tip.Status); err != nil {
+		s.logger.Printf("Failed to update tip %d status: %v", tip.ID, err)
+		return
+	}
+	s.EmitTipWebhook(tip, WebhookEventTipFailed)
+}
+
+func isUnsettledTipStatus(status string) bool {
+	switch status {
+	case dbmodel.TipStatusPending, dbmodel.TipStatusSeen, dbmodel.TipStatusBridging, dbmodel.TipStatusDelivered:
+		return true
+	}
+	return false
+}
+
+func (s *Service) retryVerificationJob(job *dbmodel.VerificationJob, cause error) {
+	attempts := job.Attempts + 1
+	var lastError string
+	if cause != nil {
+		lastError = cause.Error()
+	}
+	if err := s.db.RescheduleVerificationJob(job.ID, attempts, time.Now().Add(verificationBackoff(attempts)), lastError); err != nil {
+		s.logger.Printf("Failed to reschedule verification of tip %d: %v", job.TipID, err)
+	}
+}
+
+// verificationBackoff doubles the delay after every attempt, up to verificationMaxDelay
+func verificationBackoff(attempts int) time.Duration {
+	delay := verificationBaseDelay
+	for i := 1; i < attempts && delay < verificationMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > verificationMaxDelay {
+		delay = verificationMaxDelay
+	}
+	return delay
+}
+
+// verifyPayment checks txHash on chainID and that it paid the tip's DestAddress.
+// An empty sender skips the sender check (e.g. bridge relayer deliveries).
+// bridged are transfers reported by the bridge, counted unless the tx already
+// shows a payment of the same asset to the recipient. The stored amount (the
+// delivery for bridge tips) is corrected to the on-chain value if the claimed
+// one didn't match, and must still meet the streamer's minimum. Finality is
+// left to the caller's confirmation policy.
+func (s *Service) verifyPayment(ctx context.Context, tip *dbmodel.Tip, chainID, txHash, sender string, bridged ...Transfer) (*TxResult, error) {
+	verifier, ok := s.verifiers.Get(chainID)
+	if !ok {
+		return nil, fmt.Errorf("%w: no verifier registered for chain %q", ErrUnsupportedChain, chainID)
+	}
+
+	result, err := verifier.VerifyTx(ctx, txHash, sender)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bridged) > 0 {
+		merged := *result
+		merged.Transfers = append([]Transfer(nil), result.Transfers...)
+		for _, b := range bridged {
+			if !hasTransfer(result.Transfers, b.Recipient, b.Asset) {
+				merged.Transfers = append(merged.Transfers, b)
+			}
+		}
+		result = &merged
+	}
+
+	// Check what the streamer actually received
+	amount, asset, err := matchTipTransfer(tip, result)
+	if err != nil {
+		return nil, err
+	}
+
+	// Store the on-chain value if the claimed one doesn't match
+	_, claimedAmount, claimedAsset := tipPayment(tip)
+	if !sameAmount(amount, claimedAmount) || !strings.EqualFold(asset, claimedAsset) {
+		s.logger.Printf("Correcting tip %d from claimed %s %s to on-chain %s %s", tip.ID, claimedAmount, claimedAsset, amount, asset)
+		if isBridgeTip(tip) {
+			tip.DestAmount, tip.DestAsset = amount, asset
+			err = s.db.UpdateTipDelivery(tip.ID, tip.DestTxHash, amount, asset)
+		} else {
+			tip.Amount, tip.Asset = amount, asset
+			err = s.db.UpdateTipAmount(tip.ID, amount, asset)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to update tip amount: %v", err)
+		}
+
+		// The minimum was checked against the claimed amount
+		streamer, err := s.db.GetUserByUsername(tip.StreamerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find streamer %s: %v", tip.StreamerID, err)
+		}
+		if reason := s.checkTipMinimum(streamer, chainID, amount, asset); reason != "" {
+			return nil, fmt.Errorf("%w: %s", ErrBelowMinimum, reason)
+		}
+	}
+
+	return result, nil
+}
+
+func hasTransfer(transfers []Transfer, recipient, asset string) bool {
+	for _, t := range transfers {
+		if sameAddress(t.Recipient, recipient) && strings.EqualFold(t.Asset, asset) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPermanentVerifyError(err error) bool {
+	return errors.Is(err, ErrTxFailed) ||
+		errors.Is(err, ErrSenderMismatch) ||
+		errors.Is(err, ErrRecipientMismatch) ||
+		errors.Is(err, ErrUnsupportedChain) ||
+		errors.Is(err, ErrDuplicateTip) ||
+		errors.Is(err, ErrBelowMinimum)
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	dbmodel "github.com/patiee/backend/db/model"
+)
+
+// ChainVerifier checks a tip transaction on a single chain.
+// Implementations are registered per chain ID in a VerifierRegistry, so new
+// chains can be supported without touching the polling loop.
+type ChainVerifier interface {
+	// VerifyTx looks up txHash and checks that it succeeded and was sent by
+	// expectedSender (skipped when empty). It returns ErrTxNotFound while the
+	// tx is not visible yet.
+	VerifyTx(ctx context.Context, txHash string, expectedSender string) (*TxResult, error)
+}
+
+// TxResult is what a verifier observed on-chain for a transaction
+type TxResult struct {
+	Sender        string
+	Confirmations uint64
+	Finalized     bool       // Past the chain's finality point, if it has one
+	Transfers     []Transfer // Value received by each recipient
+}
+
+// Transfer is a single movement of value to a recipient within a transaction
+type Transfer struct {
+	Recipient string
+	Asset     string   // Symbol of a known asset, e.g. "ETH", "USDC", otherwise Token
+	Token     string   // Contract / mint / coin type, empty for the native asset
+	Amount    *big.Int // In base units
+	Decimals  int
+}
+
+// Native assets of the non-EVM chains, as their verifiers name them
+var nativeAssets = map[string]struct {
+	Symbol   string
+	Decimals int
+}{
+	"solana":  {"SOL", 9},
+	"bitcoin": {"BTC", 8},
+	"100003":  {"SUI", 9},
+}
+
+// nativeAsset returns the symbol and decimals of chainID's native asset
+func nativeAsset(chainID string) (symbol string, decimals int, ok bool) {
+	if native, ok := nativeAssets[chainID]; ok {
+		return native.Symbol, native.Decimals, true
+	}
+	if _, evm := ChainRPCs[chainID]; evm {
+		return evmNativeSymbol(chainID), 18, true
+	}
+	return "", 0, false
+}
+
+// tokenSymbols returns the well-known tokens of chainID, keyed by contract
+// (lowercase) / mint / coin type
+func tokenSymbols(chainID string) map[string]string {
+	switch chainID {
+	case "solana":
+		return splTokenSymbols
+	case "100003":
+		return suiCoinSymbols
+	}
+	return evmTokenSymbols[chainID]
+}
+
+// VerifierRegistry maps chain IDs (as stored on Tip.ChainID) to verifiers
+type VerifierRegistry struct {
+	mu        sync.RWMutex
+	verifiers map[string]ChainVerifier
+}
+
+func NewVerifierRegistry() *VerifierRegistry {
+	return &VerifierRegistry{verifiers: make(map[string]ChainVerifier)}
+}
+
+// Register adds or replaces the verifier for chainID
+func (r *VerifierRegistry) Register(chainID string, v ChainVerifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.verifiers[chainID] = v
+}
+
+func (r *VerifierRegistry) Get(chainID string) (ChainVerifier, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	v, ok := r.verifiers[chainID]
+	return v, ok
+}
+
+// defaultVerifiers registers an EVM verifier for every generated chain RPC
+// plus the non-EVM chains the frontend can tip from.
+func defaultVerifiers() *VerifierRegistry {
+	r := NewVerifierRegistry()
+	for chainID, rpcURL := range ChainRPCs {
+		r.Register(chainID, NewEVMVerifier(rpcURL, evmNativeSymbol(chainID), evmTokenSymbols[chainID]))
+	}
+	r.Register("solana", NewSolanaVerifier(defaultSolanaRPC))
+	r.Register("bitcoin", NewBitcoinVerifier(defaultBitcoinAPI))
+	r.Register("100003", NewSuiVerifier(defaultSuiRPC))
+	return r
+}
+
+// RegisterVerifier plugs in a verifier for an additional chain (e.g. Tron, Aptos)
+func (s *Service) RegisterVerifier(chainID string, v ChainVerifier) {
+	s.verifiers.Register(chainID, v)
+}
+
+var verifierHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// callJSONRPC performs a JSON-RPC 2.0 request and decodes the raw response into out
+func callJSONRPC(ctx context.Context, rpcURL, method string, params []interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rpcURL, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := verifierHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("RPC HTTP error: %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// rpcError is the error object of a JSON-RPC 2.0 response
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// matchTipTransfer returns the amount of the claimed asset the tip's
+// DestAddress actually received in the transaction. The asset is claimed by
+// symbol for native and well-known tokens or by contract / mint / coin type,
+// and returned as the verifier names it. Other assets paid don't count.
+// Bridge tips claim the asset the bridge delivered, not the one sent.
+func matchTipTransfer(tip *dbmodel.Tip, result *TxResult) (amount string, asset string, err error) {
+	_, _, claimed := tipPayment(tip)
+	var total *big.Int
+	var token string
+	var decimals int
+	for _, t := range result.Transfers {
+		if t.Amount == nil || !sameAddress(t.Recipient, tip.DestAddress) {
+			continue
+		}
+		if !strings.EqualFold(t.Asset, claimed) && (t.Token == "" || !sameAddress(t.Token, claimed)) {
+			continue
+		}
+		if total == nil {
+			total, asset, token, decimals = new(big.Int), t.Asset, t.Token, t.Decimals
+		} else if t.Token != token {
+			continue // Same symbol, different token (e.g. SOL and wrapped SOL)
+		}
+		total.Add(total, t.Amount)
+	}
+
+	if total == nil {
+		return "", "", fmt.Errorf("%w: no %s sent to %s", ErrRecipientMismatch, claimed, tip.DestAddress)
+	}
+	return formatUnits(total, decimals), asset, nil
+}
+
+// sameAddress compares addresses, ignoring case only for hex / bech32 formats
+// (base58 addresses are case sensitive)
+func sameAddress(a, b string) bool {
+	lower := strings.ToLower(a)
+	if strings.HasPrefix(lower, "0x") || strings.HasPrefix(lower, "bc1") {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// formatUnits renders base units as a decimal string, e.g. 1500000 (6) -> "1.5"
+func formatUnits(amount *big.Int, decimals int) string {
+	if decimals <= 0 {
+		return amount.String()
+	}
+	s := new(big.Rat).SetFrac(amount, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)).FloatString(decimals)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// sameAmount compares two decimal strings numerically ("0.10" == "0.1")
+func sameAmount(a, b string) bool {
+	x, ok := new(big.Rat).SetString(strings.TrimSpace(a))
+	if !ok {
+		return false
+	}
+	y, ok := new(big.Rat).SetString(strings.TrimSpace(b))
+	if !ok {
+		return false
+	}
+	return x.Cmp(y) == 0
+}
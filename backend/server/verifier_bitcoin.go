@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultBitcoinAPI = "https://mempool.space/api"
+
+// BitcoinVerifier verifies transactions via an Esplora compatible API (mempool.space)
+type BitcoinVerifier struct {
+	apiURL string
+}
+
+func NewBitcoinVerifier(apiURL string) *BitcoinVerifier {
+	return &BitcoinVerifier{apiURL: strings.TrimSuffix(apiURL, "/")}
+}
+
+func (v *BitcoinVerifier) VerifyTx(ctx context.Context, txHash string, expectedSender string) (*TxResult, error) {
+	// GET /tx/:txid (Full details)
+	resp, err := v.get(ctx, "/tx/"+txHash)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrTxNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+	}
+
+	var tx struct {
+		TxID   string `json:"txid"`
+		Status struct {
+			Confirmed   bool   `json:"confirmed"`
+			BlockHeight uint64 `json:"block_height"`
+		} `json:"status"`
+		Vin []struct {
+			Prevout struct {
+				ScriptPubKeyAddress string `json:"scriptpubkey_address"`
+				Value               int64  `json:"value"`
+			} `json:"prevout"`
+		} `json:"vin"`
+		Vout []struct {
+			ScriptPubKeyAddress string `json:"scriptpubkey_address"`
+			Value               int64  `json:"value"`
+		} `json:"vout"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&tx); err != nil {
+		return nil, err
+	}
+
+	if !tx.Status.Confirmed {
+		return nil, ErrTxNotFound // Pending
+	}
+
+	// Verify Sender
+	senderFound := expectedSender == ""
+	for _, input := range tx.Vin {
+		if strings.EqualFold(input.Prevout.ScriptPubKeyAddress, expectedSender) {
+			senderFound = true
+			break
+		}
+	}
+
+	if !senderFound {
+		return nil, fmt.Errorf("%w: %s not found in transaction inputs", ErrSenderMismatch, expectedSender)
+	}
+
+	height, err := v.tipHeight(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TxResult{Sender: expectedSender}
+	if height >= tx.Status.BlockHeight {
+		result.Confirmations = height - tx.Status.BlockHeight + 1
+	}
+
+	for _, out := range tx.Vout {
+		if out.ScriptPubKeyAddress == "" || out.Value <= 0 {
+			continue
+		}
+		result.Transfers = append(result.Transfers, Transfer{
+			Recipient: out.ScriptPubKeyAddress,
+			Asset:     "BTC",
+			Amount:    big.NewInt(out.Value),
+			Decimals:  8,
+		})
+	}
+
+	return result, nil
+}
+
+// tipHeight returns the height of the current best block
+func (v *BitcoinVerifier) tipHeight(ctx context.Context) (uint64, error) {
+	resp, err := v.get(ctx, "/blocks/tip/height")
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("API error: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(body)), 10, 64)
+}
+
+func (v *BitcoinVerifier) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.apiURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return verifierHTTPClient.Do(req)
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ERC-20 Transfer(address,address,uint256) event topic
+var erc20TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// Native gas token symbols for EVM chains that don't use ETH
+var evmNativeSymbols = map[string]string{
+	"56":    "BNB",
+	"137":   "POL",
+	"43114": "AVAX",
+	"100":   "XDAI",
+	"1088":  "METIS",
+	"122":   "FUSE",
+	"1284":  "GLMR",
+	"1329":  "SEI",
+	"14":    "FLR",
+	"146":   "S",
+	"143":   "MON",
+	"999":   "HYPE",
+}
+
+// Symbols of well-known ERC-20 contracts per chain, keyed by lowercase
+// address. Tokens are never named by their own symbol(), which anyone can
+// set, so others are reported by contract address.
+var evmTokenSymbols = map[string]map[string]string{
+	"1": {
+		"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48": "USDC",
+		"0xdac17f958d2ee523a2206206994597c13d831ec7": "USDT",
+		"0x6b175474e89094c44da98b954eedeac495271d0f": "DAI",
+		"0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2": "WETH",
+		"0x2260fac5e5542a773aa44fbcfedf7c193bc2c599": "WBTC",
+	},
+	"42161": {
+		"0xaf88d065e77c8cc2239327c5edb3a432268e5831": "USDC",
+		"0xfd086bc7cd5c481dcc9c85ebe478a1c0b69fcbb9": "USDT",
+		"0xda10009cbd5d07dd0cecc66161fc93d7c9000da1": "DAI",
+		"0x82af49447d8a07e3bd95bd0d56f35241523fbab1": "WETH",
+	},
+	"8453": {
+		"0x833589fcd6edb6e08f4c7c32d4f71b54bda02913": "USDC",
+		"0x50c5725949a6f0c72e6c4a641f24049a917db0cb": "DAI",
+		"0x4200000000000000000000000000000000000006": "WETH",
+	},
+	"10": {
+		"0x0b2c639c533813f4aa9d7837caf62653d097ff85": "USDC",
+		"0x94b008aa00579c1307b0ef2c499ad98a8ce58e58": "USDT",
+		"0xda10009cbd5d07dd0cecc66161fc93d7c9000da1": "DAI",
+		"0x4200000000000000000000000000000000000006": "WETH",
+	},
+	"137": {
+		"0x3c499c542cef5e3811e1192ce70d8cc03d5c3359": "USDC",
+		"0xc2132d05d31c914a87c6611c10748aeb04b58e8f": "USDT",
+		"0x7ceb23fd6bc0add59e62ac25578270cff1b9f619": "WETH",
+	},
+	"56": {
+		"0x8ac76a51cc950d9822d68b83fe1ad97b32cd580d": "USDC",
+		"0x55d398326f99059ff775485246999027b3197955": "USDT",
+	},
+	"43114": {
+		"0xb97ef9ef8734c71904d8002f8b6bc66dd9c48a6e": "USDC",
+		"0x9702230a8ea53601f5cd2dc00fdbc13d4df4a8c7": "USDT",
+	},
+}
+
+func evmNativeSymbol(chainID string) string {
+	if symbol, ok := evmNativeSymbols[chainID]; ok {
+		return symbol
+	}
+	return "ETH"
+}
+
+// EVMVerifier verifies transactions on any EVM chain via JSON-RPC
+type EVMVerifier struct {
+	rpcURL       string
+	nativeSymbol string
+	tokenSymbols map[string]string // Lowercase contract address -> symbol
+	decimals     sync.Map          // common.Address -> int
+}
+
+// NewEVMVerifier reports ERC-20 transfers by their symbol in tokenSymbols,
+// others by contract address
+func NewEVMVerifier(rpcURL, nativeSymbol string, tokenSymbols map[string]string) *EVMVerifier {
+	return &EVMVerifier{rpcURL: rpcURL, nativeSymbol: nativeSymbol, tokenSymbols: tokenSymbols}
+}
+
+func (v *EVMVerifier) VerifyTx(ctx context.Context, txHash string, expectedSender string) (*TxResult, error) {
+	client, err := ethclient.DialContext(ctx, v.rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RPC: %v", err)
+	}
+	defer client.Close()
+
+	hash := common.HexToHash(txHash)
+
+	// 1. Check Receipt Status
+	receipt, err := client.TransactionReceipt(ctx, hash)
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return nil, ErrTxNotFound
+		}
+		return nil, fmt.Errorf("failed to get receipt: %v", err)
+	}
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("%w (status: 0)", ErrTxFailed)
+	}
+
+	// 2. Check Sender
+	tx, _, err := client.TransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx details: %v", err)
+	}
+
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %v", err)
+	}
+
+	if expectedSender != "" && !strings.EqualFold(from.Hex(), expectedSender) {
+		return nil, fmt.Errorf("%w: tx.from=%s, expected=%s", ErrSenderMismatch, from.Hex(), expectedSender)
+	}
+
+	// 3. Confirmations
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block number: %v", err)
+	}
+
+	result := &TxResult{Sender: from.Hex()}
+	included := receipt.BlockNumber.Uint64()
+	if head >= included {
+		result.Confirmations = head - included + 1
+	}
+
+	// Chains without the "finalized" block tag just never report finality
+	if finalized, err := client.HeaderByNumber(ctx, big.NewInt(int64(rpc.FinalizedBlockNumber))); err == nil {
+		result.Finalized = finalized.Number.Uint64() >= included
+	}
+
+	// 4. Native value transfer
+	if tx.To() != nil && tx.Value().Sign() > 0 {
+		result.Transfers = append(result.Transfers, Transfer{
+			Recipient: tx.To().Hex(),
+			Asset:     v.nativeSymbol,
+			Amount:    tx.Value(),
+			Decimals:  18,
+		})
+	}
+
+	// 5. ERC-20 Transfer logs
+	for _, l := range receipt.Logs {
+		if len(l.Topics) != 3 || l.Topics[0] != erc20TransferTopic || len(l.Data) != 32 {
+			continue
+		}
+		decimals, err := v.tokenDecimals(ctx, client, l.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token decimals for %s: %v", l.Address.Hex(), err)
+		}
+		asset, ok := v.tokenSymbols[strings.ToLower(l.Address.Hex())]
+		if !ok {
+			asset = l.Address.Hex()
+		}
+		result.Transfers = append(result.Transfers, Transfer{
+			Recipient: common.BytesToAddress(l.Topics[2].Bytes()).Hex(),
+			Asset:     asset,
+			Token:     l.Address.Hex(),
+			Amount:    new(big.Int).SetBytes(l.Data),
+			Decimals:  decimals,
+		})
+	}
+
+	return result, nil
+}
+
+// tokenDecimals reads (and caches) decimals() of an ERC-20 contract
+func (v *EVMVerifier) tokenDecimals(ctx context.Context, client *ethclient.Client, token common.Address) (int, error) {
+	if cached, ok := v.decimals.Load(token); ok {
+		return cached.(int), nil
+	}
+
+	// decimals() = 0x313ce567
+	res, err := client.CallContract(ctx, ethereum.CallMsg{To: &token, Data: common.Hex2Bytes("313ce567")}, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(res) < 32 {
+		return 0, fmt.Errorf("invalid decimals response")
+	}
+	decimals := new(big.Int).SetBytes(res[:32])
+	if !decimals.IsInt64() || decimals.Int64() > 77 {
+		return 0, fmt.Errorf("invalid decimals %s", decimals)
+	}
+
+	v.decimals.Store(token, int(decimals.Int64()))
+	return int(decimals.Int64()), nil
+}
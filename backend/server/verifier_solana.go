@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+const (
+	defaultSolanaRPC = "https://api.mainnet-beta.solana.com"
+
+	// getSignatureStatuses stops counting at the finalized (max lockout) depth
+	solanaMaxConfirmations = 32
+)
+
+// Symbols of well-known SPL mints, others are reported by mint address
+var splTokenSymbols = map[string]string{
+	"So11111111111111111111111111111111111111112":  "SOL",
+	"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v": "USDC",
+	"Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB": "USDT",
+	"DezXAZ8z7PnrnRJjz3wXBoRgixCa6xjnB7YaB1pPB263": "Bonk",
+	"JUPyiwrYJFskUPiHa7hkeR8VUtkq41erho11Y65V8qV":  "JUP",
+}
+
+// splTokenBalance is an entry of meta.preTokenBalances / meta.postTokenBalances
+type splTokenBalance struct {
+	AccountIndex  int    `json:"accountIndex"`
+	Mint          string `json:"mint"`
+	Owner         string `json:"owner"`
+	UITokenAmount struct {
+		Amount   string `json:"amount"`
+		Decimals int    `json:"decimals"`
+	} `json:"uiTokenAmount"`
+}
+
+// SolanaVerifier verifies transactions via Solana JSON-RPC
+type SolanaVerifier struct {
+	rpcURL string
+}
+
+func NewSolanaVerifier(rpcURL string) *SolanaVerifier {
+	return &SolanaVerifier{rpcURL: rpcURL}
+}
+
+func (v *SolanaVerifier) VerifyTx(ctx context.Context, txHash string, expectedSender string) (*TxResult, error) {
+	params := []interface{}{
+		txHash,
+		map[string]interface{}{
+			"encoding":                       "jsonParsed",
+			"maxSupportedTransactionVersion": 0,
+			"commitment":                     "confirmed", // Finality is checked separately
+		},
+	}
+
+	var result struct {
+		Result *struct {
+			Meta *struct {
+				Err               interface{}       `json:"err"`
+				PreBalances       []uint64          `json:"preBalances"`
+				PostBalances      []uint64          `json:"postBalances"`
+				PreTokenBalances  []splTokenBalance `json:"preTokenBalances"`
+				PostTokenBalances []splTokenBalance `json:"postTokenBalances"`
+			} `json:"meta"`
+			Transaction *struct {
+				Message *struct {
+					AccountKeys []struct {
+						Pubkey string `json:"pubkey"`
+						Signer bool   `json:"signer"`
+					} `json:"accountKeys"`
+				} `json:"message"`
+			} `json:"transaction"`
+		} `json:"result"`
+		Error *rpcError `json:"error"`
+	}
+
+	if err := callJSONRPC(ctx, v.rpcURL, "getTransaction", params, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("RPC error: %s", result.Error.Message)
+	}
+
+	if result.Result == nil || result.Result.Meta == nil {
+		return nil, ErrTxNotFound
+	}
+
+	meta := result.Result.Meta
+	if meta.Err != nil {
+		return nil, fmt.Errorf("%w on-chain", ErrTxFailed)
+	}
+
+	txResult := &TxResult{Sender: expectedSender}
+	if err := v.signatureStatus(ctx, txHash, txResult); err != nil {
+		return nil, err
+	}
+
+	if result.Result.Transaction == nil || result.Result.Transaction.Message == nil {
+		return txResult, nil
+	}
+	accountKeys := result.Result.Transaction.Message.AccountKeys
+
+	// Check Sender (any signer)
+	senderFound := expectedSender == ""
+	for _, key := range accountKeys {
+		if key.Signer && key.Pubkey == expectedSender {
+			senderFound = true
+			break
+		}
+	}
+	if !senderFound {
+		return nil, fmt.Errorf("%w: expected %s", ErrSenderMismatch, expectedSender)
+	}
+
+	// Native SOL received, from the lamport balance delta of each account
+	for i, key := range accountKeys {
+		if i >= len(meta.PreBalances) || i >= len(meta.PostBalances) {
+			break
+		}
+		if meta.PostBalances[i] <= meta.PreBalances[i] {
+			continue
+		}
+		delta := new(big.Int).SetUint64(meta.PostBalances[i] - meta.PreBalances[i])
+		txResult.Transfers = append(txResult.Transfers, Transfer{
+			Recipient: key.Pubkey,
+			Asset:     "SOL",
+			Amount:    delta,
+			Decimals:  9,
+		})
+	}
+
+	txResult.Transfers = append(txResult.Transfers, splTransfers(meta.PreTokenBalances, meta.PostTokenBalances)...)
+
+	return txResult, nil
+}
+
+// splTransfers derives SPL tokens received per owner from the token balance delta
+func splTransfers(pre, post []splTokenBalance) []Transfer {
+	before := make(map[int]*big.Int, len(pre))
+	for _, b := range pre {
+		if amount, ok := new(big.Int).SetString(b.UITokenAmount.Amount, 10); ok {
+			before[b.AccountIndex] = amount
+		}
+	}
+
+	var transfers []Transfer
+	for _, b := range post {
+		after, ok := new(big.Int).SetString(b.UITokenAmount.Amount, 10)
+		if !ok || b.Owner == "" {
+			continue
+		}
+		delta := after
+		if prev, ok := before[b.AccountIndex]; ok {
+			delta = new(big.Int).Sub(after, prev)
+		}
+		if delta.Sign() <= 0 {
+			continue
+		}
+
+		symbol, ok := splTokenSymbols[b.Mint]
+		if !ok {
+			symbol = b.Mint
+		}
+		transfers = append(transfers, Transfer{
+			Recipient: b.Owner,
+			Asset:     symbol,
+			Token:     b.Mint,
+			Amount:    delta,
+			Decimals:  b.UITokenAmount.Decimals,
+		})
+	}
+	return transfers
+}
+
+// signatureStatus fills confirmations and finality from getSignatureStatuses
+func (v *SolanaVerifier) signatureStatus(ctx context.Context, txHash string, txResult *TxResult) error {
+	params := []interface{}{
+		[]string{txHash},
+		map[string]interface{}{"searchTransactionHistory": true},
+	}
+
+	var result struct {
+		Result *struct {
+			Value []*struct {
+				Confirmations      *uint64 `json:"confirmations"` // null once finalized
+				ConfirmationStatus string  `json:"confirmationStatus"`
+			} `json:"value"`
+		} `json:"result"`
+		Error *rpcError `json:"error"`
+	}
+
+	if err := callJSONRPC(ctx, v.rpcURL, "getSignatureStatuses", params, &result); err != nil {
+		return err
+	}
+	if result.Error != nil {
+		return fmt.Errorf("RPC error: %s", result.Error.Message)
+	}
+	if result.Result == nil || len(result.Result.Value) == 0 || result.Result.Value[0] == nil {
+		return ErrTxNotFound
+	}
+
+	status := result.Result.Value[0]
+	txResult.Finalized = status.ConfirmationStatus == "finalized"
+	if status.Confirmations != nil {
+		txResult.Confirmations = *status.Confirmations + 1
+	} else if txResult.Finalized {
+		txResult.Confirmations = solanaMaxConfirmations
+	}
+	return nil
+}
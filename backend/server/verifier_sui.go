@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+const (
+	defaultSuiRPC = "https://fullnode.mainnet.sui.io:443"
+	suiCoinType   = "0x2::sui::SUI"
+)
+
+// Symbols of well-known coin types, others are reported by coin type since
+// anyone can publish coin metadata with any symbol
+var suiCoinSymbols = map[string]string{
+	"0xdba34672e30cb065b1f93e3ab55318768fd6fef66c15942c9f7cb846e2f900e7::usdc::USDC": "USDC",
+}
+
+// SuiVerifier verifies transaction blocks via Sui JSON-RPC
+type SuiVerifier struct {
+	rpcURL   string
+	decimals sync.Map // coin type -> int
+}
+
+func NewSuiVerifier(rpcURL string) *SuiVerifier {
+	return &SuiVerifier{rpcURL: rpcURL}
+}
+
+func (v *SuiVerifier) VerifyTx(ctx context.Context, txHash string, expectedSender string) (*TxResult, error) {
+	params := []interface{}{
+		txHash,
+		map[string]interface{}{
+			"showEffects":        true,
+			"showInput":          true,
+			"showBalanceChanges": true,
+		},
+	}
+
+	var result struct {
+		Result *struct {
+			Checkpoint string `json:"checkpoint"`
+			Effects    *struct {
+				Status *struct {
+					Status string `json:"status"`
+					Error  string `json:"error"`
+				} `json:"status"`
+			} `json:"effects"`
+			Transaction *struct {
+				Data *struct {
+					Sender string `json:"sender"`
+				} `json:"data"`
+			} `json:"transaction"`
+			BalanceChanges []struct {
+				Owner struct {
+					AddressOwner string `json:"AddressOwner"`
+				} `json:"owner"`
+				CoinType string `json:"coinType"`
+				Amount   string `json:"amount"`
+			} `json:"balanceChanges"`
+		} `json:"result"`
+		Error *rpcError `json:"error"`
+	}
+
+	if err := callJSONRPC(ctx, v.rpcURL, "sui_getTransactionBlock", params, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Error != nil {
+		return nil, ErrTxNotFound
+	}
+
+	if result.Result == nil {
+		return nil, ErrTxNotFound // Pending or invalid
+	}
+
+	// 1. Verify Status
+	if result.Result.Effects == nil || result.Result.Effects.Status == nil || result.Result.Effects.Status.Status != "success" {
+		return nil, fmt.Errorf("%w on-chain", ErrTxFailed)
+	}
+
+	// 2. Verify Sender
+	if result.Result.Transaction == nil || result.Result.Transaction.Data == nil {
+		return nil, fmt.Errorf("transaction data missing")
+	}
+
+	sender := result.Result.Transaction.Data.Sender
+	if expectedSender != "" && sender != expectedSender {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrSenderMismatch, expectedSender, sender)
+	}
+
+	// 3. Coins received by each address owner
+	// Executed transactions are final once included in a checkpoint
+	txResult := &TxResult{Sender: sender, Confirmations: 1, Finalized: result.Result.Checkpoint != ""}
+	for _, change := range result.Result.BalanceChanges {
+		if change.Owner.AddressOwner == "" {
+			continue
+		}
+		amount, ok := new(big.Int).SetString(change.Amount, 10)
+		if !ok || amount.Sign() <= 0 {
+			continue
+		}
+
+		transfer := Transfer{
+			Recipient: change.Owner.AddressOwner,
+			Asset:     "SUI",
+			Amount:    amount,
+			Decimals:  9,
+		}
+		if change.CoinType != suiCoinType {
+			decimals, err := v.coinDecimals(ctx, change.CoinType)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get coin metadata for %s: %v", change.CoinType, err)
+			}
+			transfer.Asset = change.CoinType
+			if symbol, ok := suiCoinSymbols[change.CoinType]; ok {
+				transfer.Asset = symbol
+			}
+			transfer.Token = change.CoinType
+			transfer.Decimals = decimals
+		}
+		txResult.Transfers = append(txResult.Transfers, transfer)
+	}
+
+	return txResult, nil
+}
+
+// coinDecimals reads (and caches) the decimals of a Sui coin type
+func (v *SuiVerifier) coinDecimals(ctx context.Context, coinType string) (int, error) {
+	if cached, ok := v.decimals.Load(coinType); ok {
+		return cached.(int), nil
+	}
+
+	var result struct {
+		Result *struct {
+			Decimals int `json:"decimals"`
+		} `json:"result"`
+		Error *rpcError `json:"error"`
+	}
+	if err := callJSONRPC(ctx, v.rpcURL, "suix_getCoinMetadata", []interface{}{coinType}, &result); err != nil {
+		return 0, err
+	}
+	if result.Error != nil {
+		return 0, fmt.Errorf("RPC error: %s", result.Error.Message)
+	}
+	decimals := 0 // Coins without metadata are shown in base units
+	if result.Result != nil {
+		decimals = result.Result.Decimals
+	}
+
+	v.decimals.Store(coinType, decimals)
+	return decimals, nil
+}
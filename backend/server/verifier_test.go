@@ -0,0 +1,363 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	dbmodel "github.com/patiee/backend/db/model"
+)
+
+// rpcHandler answers a JSON-RPC method with the result to encode
+type rpcHandler func(params json.RawMessage) interface{}
+
+// newJSONRPCServer is a local stand-in for a chain's JSON-RPC endpoint.
+// Unknown methods fail the test.
+func newJSONRPCServer(t *testing.T, handlers map[string]rpcHandler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("bad JSON-RPC request: %v", err)
+			return
+		}
+		handler, ok := handlers[req.Method]
+		if !ok {
+			t.Errorf("unexpected JSON-RPC method %s", req.Method)
+			json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{"code": -32601, "message": "method not found"}})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": handler(req.Params)})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func result(v interface{}) rpcHandler {
+	return func(json.RawMessage) interface{} { return v }
+}
+
+func findTransfer(t *testing.T, transfers []Transfer, recipient, asset string) Transfer {
+	t.Helper()
+	for _, tr := range transfers {
+		if tr.Recipient == recipient && tr.Asset == asset {
+			return tr
+		}
+	}
+	t.Fatalf("no %s transfer to %s in %+v", asset, recipient, transfers)
+	return Transfer{}
+}
+
+func TestEVMVerifier(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	streamer := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	usdc := common.HexToAddress("0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913")
+	fake := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	chainID := big.NewInt(8453)
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		To:        &streamer,
+		Value:     big.NewInt(1e17),
+		Gas:       100000,
+		GasFeeCap: big.NewInt(1),
+		GasTipCap: big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transferLog := func(token common.Address, amount int64) *types.Log {
+		return &types.Log{
+			Address: token,
+			Topics:  []common.Hash{erc20TransferTopic, common.BytesToHash(sender.Bytes()), common.BytesToHash(streamer.Bytes())},
+			Data:    common.LeftPadBytes(big.NewInt(amount).Bytes(), 32),
+			TxHash:  tx.Hash(),
+		}
+	}
+	receipt := &types.Receipt{
+		Type:        types.DynamicFeeTxType,
+		Status:      types.ReceiptStatusSuccessful,
+		TxHash:      tx.Hash(),
+		BlockNumber: big.NewInt(100),
+		Logs:        []*types.Log{transferLog(usdc, 5_000_000), transferLog(fake, 7_000_000)},
+	}
+
+	srv := newJSONRPCServer(t, map[string]rpcHandler{
+		"eth_getTransactionReceipt": result(receipt),
+		"eth_getTransactionByHash":  result(tx),
+		"eth_blockNumber":           result(hexutil.Uint64(104)),
+		"eth_getBlockByNumber":      result(&types.Header{Number: big.NewInt(90), Difficulty: big.NewInt(0)}),
+		"eth_call": func(params json.RawMessage) interface{} {
+			return hexutil.Bytes(common.LeftPadBytes([]byte{6}, 32))
+		},
+	})
+	v := NewEVMVerifier(srv.URL, "ETH", evmTokenSymbols["8453"])
+
+	res, err := v.VerifyTx(context.Background(), tx.Hash().Hex(), sender.Hex())
+	if err != nil {
+		t.Fatalf("VerifyTx: %v", err)
+	}
+	if res.Sender != sender.Hex() || res.Confirmations != 5 || res.Finalized {
+		t.Fatalf("got sender %s, %d confirmations, finalized %v", res.Sender, res.Confirmations, res.Finalized)
+	}
+	if native := findTransfer(t, res.Transfers, streamer.Hex(), "ETH"); native.Amount.Int64() != 1e17 || native.Decimals != 18 {
+		t.Fatalf("native transfer = %+v", native)
+	}
+	if token := findTransfer(t, res.Transfers, streamer.Hex(), "USDC"); token.Amount.Int64() != 5_000_000 || token.Decimals != 6 || token.Token != usdc.Hex() {
+		t.Fatalf("USDC transfer = %+v", token)
+	}
+	// Unlisted tokens are reported by address, whatever their symbol() says
+	findTransfer(t, res.Transfers, streamer.Hex(), fake.Hex())
+
+	if _, err := v.VerifyTx(context.Background(), tx.Hash().Hex(), streamer.Hex()); !errors.Is(err, ErrSenderMismatch) {
+		t.Fatalf("wrong sender: err = %v, want ErrSenderMismatch", err)
+	}
+
+	receipt.Status = types.ReceiptStatusFailed
+	if _, err := v.VerifyTx(context.Background(), tx.Hash().Hex(), ""); !errors.Is(err, ErrTxFailed) {
+		t.Fatalf("reverted tx: err = %v, want ErrTxFailed", err)
+	}
+}
+
+func TestEVMVerifierNotFound(t *testing.T) {
+	srv := newJSONRPCServer(t, map[string]rpcHandler{
+		"eth_getTransactionReceipt": result(nil),
+	})
+	_, err := NewEVMVerifier(srv.URL, "ETH", nil).VerifyTx(context.Background(), common.Hash{}.Hex(), "")
+	if !errors.Is(err, ErrTxNotFound) {
+		t.Fatalf("err = %v, want ErrTxNotFound", err)
+	}
+}
+
+func TestBitcoinVerifier(t *testing.T) {
+	const (
+		sender   = "bc1qsender"
+		streamer = "bc1qstreamer"
+	)
+	confirmed := true
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tx/abc", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"txid":   "abc",
+			"status": map[string]interface{}{"confirmed": confirmed, "block_height": 800000},
+			"vin":    []interface{}{map[string]interface{}{"prevout": map[string]interface{}{"scriptpubkey_address": sender, "value": 100000}}},
+			"vout": []interface{}{
+				map[string]interface{}{"scriptpubkey_address": streamer, "value": 60000},
+				map[string]interface{}{"scriptpubkey_address": sender, "value": 39000},
+				map[string]interface{}{"scriptpubkey_address": "", "value": 0}, // OP_RETURN
+			},
+		})
+	})
+	mux.HandleFunc("/blocks/tip/height", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "800002")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	v := NewBitcoinVerifier(srv.URL + "/")
+
+	res, err := v.VerifyTx(context.Background(), "abc", sender)
+	if err != nil {
+		t.Fatalf("VerifyTx: %v", err)
+	}
+	if res.Confirmations != 3 || len(res.Transfers) != 2 {
+		t.Fatalf("got %d confirmations, transfers %+v", res.Confirmations, res.Transfers)
+	}
+	if tr := findTransfer(t, res.Transfers, streamer, "BTC"); tr.Amount.Int64() != 60000 || tr.Decimals != 8 {
+		t.Fatalf("transfer = %+v", tr)
+	}
+
+	if _, err := v.VerifyTx(context.Background(), "abc", "bc1qother"); !errors.Is(err, ErrSenderMismatch) {
+		t.Fatalf("wrong sender: err = %v, want ErrSenderMismatch", err)
+	}
+	if _, err := v.VerifyTx(context.Background(), "missing", sender); !errors.Is(err, ErrTxNotFound) {
+		t.Fatalf("unknown tx: err = %v, want ErrTxNotFound", err)
+	}
+	confirmed = false
+	if _, err := v.VerifyTx(context.Background(), "abc", sender); !errors.Is(err, ErrTxNotFound) {
+		t.Fatalf("mempool tx: err = %v, want ErrTxNotFound", err)
+	}
+}
+
+func TestSolanaVerifier(t *testing.T) {
+	const (
+		sender   = "SenderPubkey111111111111111111111111111111"
+		streamer = "StreamerPubkey1111111111111111111111111111"
+		fakeMint = "FakeUSDCMint11111111111111111111111111111111"
+		usdcMint = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	)
+	tokenBalance := func(index int, mint, amount string) map[string]interface{} {
+		return map[string]interface{}{
+			"accountIndex":  index,
+			"mint":          mint,
+			"owner":         streamer,
+			"uiTokenAmount": map[string]interface{}{"amount": amount, "decimals": 6},
+		}
+	}
+	var txErr interface{}
+	srv := newJSONRPCServer(t, map[string]rpcHandler{
+		"getTransaction": func(json.RawMessage) interface{} {
+			return map[string]interface{}{
+				"meta": map[string]interface{}{
+					"err":               txErr,
+					"preBalances":       []uint64{5_000_000_000, 1_000_000_000, 0, 0},
+					"postBalances":      []uint64{4_499_995_000, 1_500_000_000, 0, 0},
+					"preTokenBalances":  []interface{}{tokenBalance(2, usdcMint, "1000000")},
+					"postTokenBalances": []interface{}{tokenBalance(2, usdcMint, "3500000"), tokenBalance(3, fakeMint, "9000000")},
+				},
+				"transaction": map[string]interface{}{
+					"message": map[string]interface{}{
+						"accountKeys": []interface{}{
+							map[string]interface{}{"pubkey": sender, "signer": true},
+							map[string]interface{}{"pubkey": streamer, "signer": false},
+							map[string]interface{}{"pubkey": "StreamerUSDCAccount", "signer": false},
+							map[string]interface{}{"pubkey": "StreamerFakeAccount", "signer": false},
+						},
+					},
+				},
+			}
+		},
+		"getSignatureStatuses": result(map[string]interface{}{
+			"value": []interface{}{map[string]interface{}{"confirmations": nil, "confirmationStatus": "finalized"}},
+		}),
+	})
+	v := NewSolanaVerifier(srv.URL)
+
+	res, err := v.VerifyTx(context.Background(), "sig", sender)
+	if err != nil {
+		t.Fatalf("VerifyTx: %v", err)
+	}
+	if !res.Finalized || res.Confirmations != solanaMaxConfirmations {
+		t.Fatalf("got finalized %v, %d confirmations", res.Finalized, res.Confirmations)
+	}
+	if tr := findTransfer(t, res.Transfers, streamer, "SOL"); tr.Amount.Int64() != 500_000_000 || tr.Decimals != 9 {
+		t.Fatalf("SOL transfer = %+v", tr)
+	}
+	if tr := findTransfer(t, res.Transfers, streamer, "USDC"); tr.Amount.Int64() != 2_500_000 || tr.Token != usdcMint {
+		t.Fatalf("USDC transfer = %+v", tr)
+	}
+	findTransfer(t, res.Transfers, streamer, fakeMint)
+
+	if _, err := v.VerifyTx(context.Background(), "sig", streamer); !errors.Is(err, ErrSenderMismatch) {
+		t.Fatalf("non-signer sender: err = %v, want ErrSenderMismatch", err)
+	}
+	txErr = map[string]interface{}{"InstructionError": []interface{}{0, "Custom"}}
+	if _, err := v.VerifyTx(context.Background(), "sig", sender); !errors.Is(err, ErrTxFailed) {
+		t.Fatalf("failed tx: err = %v, want ErrTxFailed", err)
+	}
+}
+
+func TestSuiVerifier(t *testing.T) {
+	const (
+		sender   = "0xsender"
+		streamer = "0xstreamer"
+		usdc     = "0xdba34672e30cb065b1f93e3ab55318768fd6fef66c15942c9f7cb846e2f900e7::usdc::USDC"
+		fakeCoin = "0xbad::usdc::USDC"
+	)
+	change := func(owner, coinType, amount string) map[string]interface{} {
+		return map[string]interface{}{"owner": map[string]interface{}{"AddressOwner": owner}, "coinType": coinType, "amount": amount}
+	}
+	metadataCalls := 0
+	srv := newJSONRPCServer(t, map[string]rpcHandler{
+		"sui_getTransactionBlock": result(map[string]interface{}{
+			"checkpoint":  "123",
+			"effects":     map[string]interface{}{"status": map[string]interface{}{"status": "success"}},
+			"transaction": map[string]interface{}{"data": map[string]interface{}{"sender": sender}},
+			"balanceChanges": []interface{}{
+				change(sender, suiCoinType, "-1200000000"),
+				change(streamer, suiCoinType, "1000000000"),
+				change(streamer, usdc, "2000000"),
+				change(streamer, fakeCoin, "3000000"),
+			},
+		}),
+		"suix_getCoinMetadata": func(json.RawMessage) interface{} {
+			metadataCalls++
+			return map[string]interface{}{"decimals": 6, "symbol": "USDC"}
+		},
+	})
+	v := NewSuiVerifier(srv.URL)
+
+	res, err := v.VerifyTx(context.Background(), "digest", sender)
+	if err != nil {
+		t.Fatalf("VerifyTx: %v", err)
+	}
+	if !res.Finalized || len(res.Transfers) != 3 {
+		t.Fatalf("got finalized %v, transfers %+v", res.Finalized, res.Transfers)
+	}
+	if tr := findTransfer(t, res.Transfers, streamer, "SUI"); tr.Amount.Int64() != 1e9 || tr.Decimals != 9 {
+		t.Fatalf("SUI transfer = %+v", tr)
+	}
+	if tr := findTransfer(t, res.Transfers, streamer, "USDC"); tr.Token != usdc || tr.Decimals != 6 {
+		t.Fatalf("USDC transfer = %+v", tr)
+	}
+	// A coin calling itself USDC is still reported by its coin type
+	findTransfer(t, res.Transfers, streamer, fakeCoin)
+
+	if _, err := v.VerifyTx(context.Background(), "digest", sender); err != nil {
+		t.Fatalf("VerifyTx: %v", err)
+	}
+	if metadataCalls != 2 {
+		t.Fatalf("coin metadata fetched %d times, want once per coin type", metadataCalls)
+	}
+	if _, err := v.VerifyTx(context.Background(), "digest", "0xother"); !errors.Is(err, ErrSenderMismatch) {
+		t.Fatalf("wrong sender: err = %v, want ErrSenderMismatch", err)
+	}
+}
+
+func TestMatchTipTransfer(t *testing.T) {
+	const streamer = "0x1111111111111111111111111111111111111111"
+	usdc := "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"
+	fake := "0x2222222222222222222222222222222222222222"
+	res := &TxResult{Transfers: []Transfer{
+		{Recipient: streamer, Asset: fake, Token: fake, Amount: big.NewInt(9_000_000), Decimals: 6},
+		{Recipient: streamer, Asset: "USDC", Token: usdc, Amount: big.NewInt(1_500_000), Decimals: 6},
+		{Recipient: streamer, Asset: "USDC", Token: usdc, Amount: big.NewInt(500_000), Decimals: 6},
+		{Recipient: "0x3333333333333333333333333333333333333333", Asset: "ETH", Amount: big.NewInt(1e18), Decimals: 18},
+	}}
+
+	tests := []struct {
+		name    string
+		asset   string
+		amount  string
+		symbol  string
+		wantErr bool
+	}{
+		{name: "by symbol", asset: "USDC", amount: "2", symbol: "USDC"},
+		{name: "by contract", asset: usdc, amount: "2", symbol: "USDC"},
+		{name: "unlisted token by contract", asset: fake, amount: "9", symbol: fake},
+		{name: "asset not paid", asset: "USDT", wantErr: true},
+		{name: "paid to someone else", asset: "ETH", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, asset, err := matchTipTransfer(&dbmodel.Tip{DestAddress: streamer, Asset: tt.asset}, res)
+			if tt.wantErr {
+				if !errors.Is(err, ErrRecipientMismatch) {
+					t.Fatalf("err = %v, want ErrRecipientMismatch", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("matchTipTransfer: %v", err)
+			}
+			if !sameAmount(amount, tt.amount) || asset != tt.symbol {
+				t.Fatalf("got %s %s, want %s %s", amount, asset, tt.amount, tt.symbol)
+			}
+		})
+	}
+}
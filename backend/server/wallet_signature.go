@@ -0,0 +1,266 @@
+package server
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secpecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// Sui signature scheme flags, the first byte of a serialized signature
+const (
+	suiFlagEd25519   = 0x00
+	suiFlagSecp256k1 = 0x01
+	suiFlagSecp256r1 = 0x02
+)
+
+// verifyWalletSignature checks signature over message by the account of
+// accountType, as named in the sign-in message
+func verifyWalletSignature(accountType, address, message, signature string) (bool, error) {
+	switch accountType {
+	case "Bitcoin":
+		return verifyBitcoinSignature(address, message, signature)
+	case "Sui":
+		return verifySuiSignature(address, message, signature)
+	}
+	return verifySignature(address, message, signature)
+}
+
+// verifyBitcoinSignature checks a BIP-137 "Bitcoin Signed Message" signature.
+// Wallets don't agree on the header byte for segwit addresses, so every
+// address type of the recovered key is accepted.
+func verifyBitcoinSignature(address, message, signatureStr string) (bool, error) {
+	sig, err := base64.StdEncoding.DecodeString(signatureStr)
+	if err != nil || len(sig) != 65 {
+		return false, errors.New("invalid bitcoin signature, expected 65 bytes in base64")
+	}
+	switch header := sig[0]; {
+	case header >= 27 && header <= 34:
+	case header >= 35 && header <= 42:
+		// Segwit headers always use a compressed key
+		sig[0] = 31 + (header-35)%4
+	default:
+		return false, fmt.Errorf("invalid bitcoin signature header %d", header)
+	}
+
+	key, compressed, err := secpecdsa.RecoverCompact(sig, bitcoinMessageHash(message))
+	if err != nil {
+		return false, err
+	}
+	if !compressed {
+		return p2pkhAddress(key.SerializeUncompressed()) == address, nil
+	}
+
+	pubKey := key.SerializeCompressed()
+	candidates := []string{p2pkhAddress(pubKey), p2shP2wpkhAddress(pubKey), p2wpkhAddress(pubKey), p2trAddress(key)}
+	for _, candidate := range candidates {
+		if candidate == strings.ToLower(address) || candidate == address {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func bitcoinMessageHash(message string) []byte {
+	const magic = "Bitcoin Signed Message:\n"
+	var buf bytes.Buffer
+	writeCompactSize(&buf, len(magic))
+	buf.WriteString(magic)
+	writeCompactSize(&buf, len(message))
+	buf.WriteString(message)
+	return doubleSHA256(buf.Bytes())
+}
+
+func writeCompactSize(buf *bytes.Buffer, n int) {
+	switch {
+	case n < 0xFD:
+		buf.WriteByte(byte(n))
+	case n <= 0xFFFF:
+		buf.WriteByte(0xFD)
+		binary.Write(buf, binary.LittleEndian, uint16(n))
+	default:
+		buf.WriteByte(0xFE)
+		binary.Write(buf, binary.LittleEndian, uint32(n))
+	}
+}
+
+func doubleSHA256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+func hash160(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	h := ripemd160.New()
+	h.Write(sum[:])
+	return h.Sum(nil)
+}
+
+func base58Check(version byte, payload []byte) string {
+	data := append([]byte{version}, payload...)
+	return base58.Encode(append(data, doubleSHA256(data)[:4]...))
+}
+
+func p2pkhAddress(pubKey []byte) string {
+	return base58Check(0x00, hash160(pubKey))
+}
+
+// p2shP2wpkhAddress is the P2WPKH output of pubKey nested in P2SH
+func p2shP2wpkhAddress(pubKey []byte) string {
+	redeemScript := append([]byte{0x00, 0x14}, hash160(pubKey)...)
+	return base58Check(0x05, hash160(redeemScript))
+}
+
+func p2wpkhAddress(pubKey []byte) string {
+	return segwitAddress("bc", 0, hash160(pubKey))
+}
+
+// p2trAddress is the BIP-86 key path only taproot address of key
+func p2trAddress(key *secp256k1.PublicKey) string {
+	var p secp256k1.JacobianPoint
+	key.AsJacobian(&p)
+	// The internal key is used with an even Y coordinate
+	if p.Y.IsOdd() {
+		p.Y.Negate(1).Normalize()
+	}
+	x := key.SerializeCompressed()[1:]
+
+	tag := sha256.Sum256([]byte("TapTweak"))
+	tweakHash := sha256.Sum256(append(append(tag[:], tag[:]...), x...))
+	var tweak secp256k1.ModNScalar
+	tweak.SetBytes(&tweakHash)
+
+	var tweakPoint, output secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&tweak, &tweakPoint)
+	secp256k1.AddNonConst(&p, &tweakPoint, &output)
+	output.ToAffine()
+	return segwitAddress("bc", 1, output.X.Bytes()[:])
+}
+
+// segwitAddress encodes a witness program as bech32 (version 0) or bech32m
+func segwitAddress(hrp string, version byte, program []byte) string {
+	const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	data := []byte{version}
+	// Regroup the program's 8 bit bytes into 5 bit groups
+	acc, bits := 0, 0
+	for _, b := range program {
+		acc = acc<<8 | int(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			data = append(data, byte(acc>>bits&31))
+		}
+	}
+	if bits > 0 {
+		data = append(data, byte(acc<<(5-bits)&31))
+	}
+
+	checksumConst := 1
+	if version > 0 {
+		checksumConst = 0x2bc830a3
+	}
+	values := make([]byte, 0, len(hrp)*2+1+len(data)+6)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+	values = append(values, data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	polymod := bech32Polymod(values) ^ checksumConst
+
+	var sb strings.Builder
+	sb.WriteString(hrp + "1")
+	for _, d := range data {
+		sb.WriteByte(charset[d])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(charset[polymod>>(5*(5-i))&31])
+	}
+	return sb.String()
+}
+
+func bech32Polymod(values []byte) int {
+	generator := [5]int{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := 1
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ int(v)
+		for i := 0; i < 5; i++ {
+			if top>>i&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// verifySuiSignature checks a Sui personal message signature: the scheme
+// flag, signature and public key in base64, over the Blake2b digest of the
+// message with its intent prefix
+func verifySuiSignature(address, message, signatureStr string) (bool, error) {
+	serialized, err := base64.StdEncoding.DecodeString(signatureStr)
+	if err != nil || len(serialized) < 1+64 {
+		return false, errors.New("invalid sui signature")
+	}
+	flag, sig, pubKey := serialized[0], serialized[1:65], serialized[65:]
+
+	// The address is the Blake2b hash of the flag and public key
+	addressHash := blake2b.Sum256(append([]byte{flag}, pubKey...))
+	if "0x"+hex.EncodeToString(addressHash[:]) != strings.ToLower(address) {
+		return false, nil
+	}
+
+	// PersonalMessage intent, then the message as a BCS byte vector
+	intent := []byte{3, 0, 0}
+	intent = binary.AppendUvarint(intent, uint64(len(message)))
+	digest := blake2b.Sum256(append(intent, message...))
+
+	switch flag {
+	case suiFlagEd25519:
+		if len(pubKey) != ed25519.PublicKeySize {
+			return false, errors.New("invalid ed25519 public key")
+		}
+		return ed25519.Verify(ed25519.PublicKey(pubKey), digest[:], sig), nil
+
+	case suiFlagSecp256k1:
+		key, err := secp256k1.ParsePubKey(pubKey)
+		if err != nil {
+			return false, err
+		}
+		var r, s secp256k1.ModNScalar
+		if r.SetByteSlice(sig[:32]) || s.SetByteSlice(sig[32:]) || s.IsOverHalfOrder() {
+			return false, errors.New("invalid secp256k1 signature")
+		}
+		hash := sha256.Sum256(digest[:])
+		return secpecdsa.NewSignature(&r, &s).Verify(hash[:], key), nil
+
+	case suiFlagSecp256r1:
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), pubKey)
+		if x == nil {
+			return false, errors.New("invalid secp256r1 public key")
+		}
+		hash := sha256.Sum256(digest[:])
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		return ecdsa.Verify(key, hash[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])), nil
+	}
+	return false, fmt.Errorf("unsupported sui signature scheme %d", flag)
+}
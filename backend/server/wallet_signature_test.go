@@ -0,0 +1,130 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secpecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestBitcoinAddresses(t *testing.T) {
+	// BIP-173: the generator point's P2WPKH address
+	g, _ := hex.DecodeString("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	if got := p2wpkhAddress(g); got != "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4" {
+		t.Errorf("p2wpkhAddress = %s", got)
+	}
+
+	// BIP-341 wallet test vector without scripts
+	internal, _ := hex.DecodeString("02d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d")
+	key, err := secp256k1.ParsePubKey(internal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p2trAddress(key); got != "bc1p2wsldez5mud2yam29q22wgfh9439spgduvct83k3pm50fcxa5dps59h4z5" {
+		t.Errorf("p2trAddress = %s", got)
+	}
+}
+
+func TestVerifyBitcoinSignature(t *testing.T) {
+	// bitcoinjs-message example
+	const (
+		message   = "This is an example of a signed message."
+		address   = "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV"
+		signature = "H9L5yLFjti0QTHhPyFrZCT1V/MMnBtXKmoiKDZ78NDBjERki6ZTQZdSMCtkgoNmp17By9ItJr8o7ChX0XxY91nk="
+	)
+	if ok, err := verifyWalletSignature("Bitcoin", address, message, signature); !ok || err != nil {
+		t.Fatalf("example signature rejected: %v", err)
+	}
+	if ok, _ := verifyWalletSignature("Bitcoin", address, message+"!", signature); ok {
+		t.Fatal("accepted a signature over another message")
+	}
+	if ok, _ := verifyWalletSignature("Bitcoin", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", message, signature); ok {
+		t.Fatal("accepted a signature for another address")
+	}
+
+	// The same key signs for its segwit and taproot addresses, whatever
+	// header the wallet picked
+	priv, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := priv.PubKey()
+	sig := secpecdsa.SignCompact(priv, bitcoinMessageHash(message), true)
+	for _, addr := range []string{p2pkhAddress(pub.SerializeCompressed()), p2shP2wpkhAddress(pub.SerializeCompressed()), p2wpkhAddress(pub.SerializeCompressed()), p2trAddress(pub)} {
+		for _, header := range []byte{sig[0], sig[0] + 4, sig[0] + 8} {
+			signed := append([]byte{header}, sig[1:]...)
+			if ok, err := verifyBitcoinSignature(addr, message, base64.StdEncoding.EncodeToString(signed)); !ok || err != nil {
+				t.Errorf("%s with header %d rejected: %v", addr, header, err)
+			}
+		}
+	}
+
+	if _, err := verifyBitcoinSignature(address, message, base64.StdEncoding.EncodeToString(append([]byte{43}, sig[1:]...))); err == nil {
+		t.Error("accepted an unknown header")
+	}
+}
+
+func TestVerifySuiSignature(t *testing.T) {
+	const message = "example.com wants you to sign in with your Sui account:"
+	intent := binary.AppendUvarint([]byte{3, 0, 0}, uint64(len(message)))
+	digest := blake2b.Sum256(append(intent, message...))
+
+	suiAddress := func(flag byte, pubKey []byte) string {
+		sum := blake2b.Sum256(append([]byte{flag}, pubKey...))
+		return "0x" + hex.EncodeToString(sum[:])
+	}
+	serialize := func(flag byte, sig, pubKey []byte) string {
+		return base64.StdEncoding.EncodeToString(append(append([]byte{flag}, sig...), pubKey...))
+	}
+
+	t.Run("ed25519", func(t *testing.T) {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		address := suiAddress(suiFlagEd25519, pub)
+		signature := serialize(suiFlagEd25519, ed25519.Sign(priv, digest[:]), pub)
+
+		if ok, err := verifyWalletSignature("Sui", address, message, signature); !ok || err != nil {
+			t.Fatalf("signature rejected: %v", err)
+		}
+		if ok, _ := verifyWalletSignature("Sui", address, message+"!", signature); ok {
+			t.Fatal("accepted a signature over another message")
+		}
+		if ok, _ := verifyWalletSignature("Sui", "0x"+hex.EncodeToString(make([]byte, 32)), message, signature); ok {
+			t.Fatal("accepted a key that doesn't own the address")
+		}
+		// Signing the raw message skips the intent prefix
+		if ok, _ := verifySuiSignature(address, message, serialize(suiFlagEd25519, ed25519.Sign(priv, []byte(message)), pub)); ok {
+			t.Fatal("accepted a signature without the intent")
+		}
+	})
+
+	t.Run("secp256k1", func(t *testing.T) {
+		priv, err := secp256k1.GeneratePrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub := priv.PubKey().SerializeCompressed()
+		hash := sha256.Sum256(digest[:])
+		compact := secpecdsa.SignCompact(priv, hash[:], true)
+
+		if ok, err := verifySuiSignature(suiAddress(suiFlagSecp256k1, pub), message, serialize(suiFlagSecp256k1, compact[1:], pub)); !ok || err != nil {
+			t.Fatalf("signature rejected: %v", err)
+		}
+	})
+
+	t.Run("unsupported scheme", func(t *testing.T) {
+		pub := make([]byte, 32)
+		if _, err := verifySuiSignature(suiAddress(0x05, pub), message, serialize(0x05, make([]byte, 64), pub)); err == nil {
+			t.Fatal("accepted a zkLogin signature")
+		}
+	})
+}
@@ -0,0 +1,786 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+	"gorm.io/gorm"
+)
+
+// Webhook event types
+const (
+	WebhookEventTipPending   = "tip.pending"
+	WebhookEventTipConfirmed = "tip.confirmed"
+	WebhookEventTipFailed    = "tip.failed"
+	WebhookEventTest         = "webhook.test" // Only sent by the test endpoint
+)
+
+var webhookEvents = []string{WebhookEventTipPending, WebhookEventTipConfirmed, WebhookEventTipFailed}
+
+const (
+	webhookMaxPerUser     = 10
+	webhookMaxURLLength   = 500
+	webhookWorkers        = 2
+	webhookLease          = time.Minute
+	webhookPoll           = 2 * time.Second
+	webhookTimeout        = 10 * time.Second
+	webhookBaseDelay      = 30 * time.Second
+	webhookMaxDelay       = 6 * time.Hour
+	webhookMaxAttempts    = 12 // About 14 hours of retries
+	webhookLogLimit       = 50
+	webhookLogRetention   = 30 * 24 * time.Hour
+	webhookMaxErrorLength = 300
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrInvalidWebhook  = errors.New("invalid webhook")
+)
+
+// newWebhookClient sends deliveries without following redirects. Unless
+// allowPrivate is set it refuses to connect to loopback, private, link local
+// and other non-public addresses, so a webhook can't reach services next to
+// the backend. The address is checked after resolving, on the connection
+// itself, so a hostname can't pass the check and then rebind.
+func newWebhookClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: webhookTimeout}
+	dial := dialer.DialContext
+	if !allowPrivate {
+		dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, err
+			}
+			addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+			if err != nil {
+				return nil, err
+			}
+			var lastErr error
+			for _, addr := range addrs {
+				if isInternalIP(addr) {
+					lastErr = fmt.Errorf("webhook address %s of %s is not public", addr, host)
+					continue
+				}
+				conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr.Unmap().String(), port))
+				if err == nil {
+					return conn, nil
+				}
+				lastErr = err
+			}
+			if lastErr == nil {
+				lastErr = fmt.Errorf("no address found for %s", host)
+			}
+			return nil, lastErr
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dial
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Special purpose ranges webhooks may not reach, besides the ones netip
+// classifies as loopback, private, link local, multicast or unspecified
+var webhookDeniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "This network"
+	netip.MustParsePrefix("100.64.0.0/10"),   // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // Documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // Benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // Documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // Documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // Reserved, and broadcast
+	netip.MustParsePrefix("::/96"),           // IPv4-compatible
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // Local NAT64
+	netip.MustParsePrefix("100::/64"),        // Discard
+	netip.MustParsePrefix("2001::/23"),       // IETF protocol assignments, incl. Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // Documentation
+}
+
+// IPv6 ranges that embed an IPv4 address, which must be public itself
+var (
+	nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFour   = netip.MustParsePrefix("2002::/16")
+)
+
+func isInternalIP(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return true
+	}
+	for _, prefix := range webhookDeniedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	b := addr.As16()
+	switch {
+	case nat64Prefix.Contains(addr):
+		return isInternalIP(netip.AddrFrom4([4]byte(b[12:16])))
+	case sixToFour.Contains(addr):
+		return isInternalIP(netip.AddrFrom4([4]byte(b[2:6])))
+	}
+	return false
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature value of body sent at
+// timestamp: "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">"
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// webhookBackoff doubles the delay after every attempt, up to webhookMaxDelay
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookBaseDelay
+	for i := 1; i < attempts && delay < webhookMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxDelay {
+		delay = webhookMaxDelay
+	}
+	return delay
+}
+
+func webhookEventList(w *dbmodel.Webhook) []string {
+	if w.Events == "" {
+		return []string{}
+	}
+	return strings.Split(w.Events, ",")
+}
+
+func webhookItem(w *dbmodel.Webhook) model.WebhookItem {
+	return model.WebhookItem{
+		ID:        w.ID,
+		URL:       w.URL,
+		Secret:    w.Secret,
+		Events:    webhookEventList(w),
+		Enabled:   w.Enabled,
+		CreatedAt: w.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func webhookDeliveryItem(d *dbmodel.WebhookDelivery) model.WebhookDeliveryItem {
+	item := model.WebhookDeliveryItem{
+		ID:             d.ID,
+		EventID:        d.EventID,
+		Event:          d.Event,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		Error:          d.LastError,
+		DurationMs:     d.DurationMs,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+	if d.Status == dbmodel.WebhookDeliveryPending && d.Attempts > 0 {
+		item.NextAttemptAt = d.NextRunAt.Format(time.RFC3339)
+	}
+	if d.DeliveredAt != nil {
+		item.DeliveredAt = d.DeliveredAt.Format(time.RFC3339)
+	}
+	return item
+}
+
+// webhookTipEvent builds the payload of a tip event. Messages hidden from
+// the alert by the streamer's tip rules are left out.
+func webhookTipEvent(eventType string, tip *dbmodel.Tip, showMessage bool) model.WebhookEvent {
+	data := model.WebhookTipData{
+		TipID:       tip.ID,
+		Streamer:    tip.StreamerID,
+		Sender:      tip.Sender,
+		Amount:      tip.Amount,
+		Asset:       tip.Asset,
+		USDValue:    tip.USDValue,
+		ChainID:     tip.ChainID,
+		TxHash:      tip.TxHash,
+		SourceChain: tip.SourceChain,
+		DestChain:   tip.DestChain,
+		DestTxHash:  tip.DestTxHash,
+		Status:      tip.Status,
+		CreatedAt:   tip.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if showMessage {
+		data.Message = tip.Message
+	}
+	return model.WebhookEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Data:      data,
+	}
+}
+
+// EmitTipWebhook queues eventType for every enabled webhook of the tip's
+// streamer subscribed to it
+func (s *Service) EmitTipWebhook(tip *dbmodel.Tip, eventType string) {
+	user, err := s.db.GetUserByUsername(tip.StreamerID)
+	if err != nil {
+		s.logger.Printf("Failed to find streamer %s: %v", tip.StreamerID, err)
+		return
+	}
+	webhooks, err := s.db.GetEnabledWebhooks(user.ID)
+	if err != nil {
+		s.logger.Printf("Failed to fetch webhooks of %s: %v", tip.StreamerID, err)
+		return
+	}
+
+	var targets []dbmodel.Webhook
+	for _, w := range webhooks {
+		if subscribed(webhookEventList(&w), eventType) {
+			targets = append(targets, w)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	event := webhookTipEvent(eventType, tip, tipRulesFor(user).AlertFlags(tip).ShowMessage)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Printf("Failed to encode %s webhook of tip %d: %v", eventType, tip.ID, err)
+		return
+	}
+
+	deliveries := make([]dbmodel.WebhookDelivery, 0, len(targets))
+	for _, w := range targets {
+		deliveries = append(deliveries, dbmodel.WebhookDelivery{
+			WebhookID: w.ID,
+			EventID:   event.ID,
+			Event:     eventType,
+			Payload:   string(payload),
+			Status:    dbmodel.WebhookDeliveryPending,
+			NextRunAt: time.Now(),
+		})
+	}
+	if err := s.db.CreateWebhookDeliveries(deliveries); err != nil {
+		s.logger.Printf("Failed to queue %s webhooks of tip %d: %v", eventType, tip.ID, err)
+	}
+}
+
+// StartWebhookWorkers starts the workers sending queued webhook deliveries
+// and trims old entries from the delivery log
+func (s *Service) StartWebhookWorkers(ctx context.Context) {
+	hostname, _ := os.Hostname()
+	for i := 0; i < webhookWorkers; i++ {
+		owner := fmt.Sprintf("%s-webhook-%d-%s", hostname, i, uuid.New().String()[:8])
+		go s.runWebhookWorker(ctx, owner)
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			if n, err := s.db.DeleteWebhookDeliveriesBefore(time.Now().Add(-webhookLogRetention)); err != nil {
+				s.logger.Printf("Failed to trim webhook delivery log: %v", err)
+			} else if n > 0 {
+				s.logger.Printf("Trimmed %d old webhook deliveries", n)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *Service) runWebhookWorker(ctx context.Context, owner string) {
+	for {
+		delivery, err := s.db.LeaseWebhookDelivery(owner, webhookLease)
+		if err != nil {
+			s.logger.Printf("Failed to lease webhook delivery: %v", err)
+		}
+
+		if delivery == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(webhookPoll):
+			}
+			continue
+		}
+
+		s.processWebhookDelivery(ctx, delivery)
+	}
+}
+
+func (s *Service) processWebhookDelivery(ctx context.Context, delivery *dbmodel.WebhookDelivery) {
+	webhook, err := s.db.GetWebhookByID(delivery.WebhookID)
+	if err != nil || !webhook.Enabled {
+		// Deleted or disabled since the event was queued
+		delivery.Status = dbmodel.WebhookDeliveryFailed
+		delivery.LastError = "webhook disabled"
+		if err := s.db.FinishWebhookAttempt(delivery); err != nil {
+			s.logger.Printf("Failed to update webhook delivery %d: %v", delivery.ID, err)
+		}
+		return
+	}
+
+	s.attemptWebhookDelivery(ctx, webhook, delivery)
+	if delivery.Status == dbmodel.WebhookDeliveryPending {
+		if delivery.Attempts >= webhookMaxAttempts {
+			s.logger.Printf("Giving up on webhook delivery %d to %s after %d attempts: %s", delivery.ID, webhook.URL, delivery.Attempts, delivery.LastError)
+			delivery.Status = dbmodel.WebhookDeliveryFailed
+		} else {
+			delivery.NextRunAt = time.Now().Add(webhookBackoff(delivery.Attempts))
+		}
+	}
+
+	if err := s.db.FinishWebhookAttempt(delivery); err != nil {
+		s.logger.Printf("Failed to update webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// attemptWebhookDelivery posts the delivery's payload once and records the
+// outcome on delivery. Any 2xx response counts as delivered.
+func (s *Service) attemptWebhookDelivery(ctx context.Context, webhook *dbmodel.Webhook, delivery *dbmodel.WebhookDelivery) {
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.LastError = ""
+
+	start := time.Now()
+	status, err := s.postWebhook(ctx, webhook, delivery)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	delivery.ResponseStatus = status
+
+	if err != nil {
+		delivery.LastError = err.Error()
+		if len(delivery.LastError) > webhookMaxErrorLength {
+			delivery.LastError = delivery.LastError[:webhookMaxErrorLength]
+		}
+		return
+	}
+	now := time.Now()
+	delivery.Status = dbmodel.WebhookDeliveryDelivered
+	delivery.DeliveredAt = &now
+}
+
+func (s *Service) postWebhook(ctx context.Context, webhook *dbmodel.Webhook, delivery *dbmodel.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TipsWebhooks/1.0")
+	req.Header.Set("X-Webhook-Id", delivery.EventID)
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Attempt", strconv.Itoa(delivery.Attempts))
+	req.Header.Set("X-Webhook-Signature", SignWebhookPayload(webhook.Secret, time.Now().Unix(), body))
+
+	resp, err := s.webhooks.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg := fmt.Sprintf("endpoint responded %s", resp.Status)
+		if text := strings.TrimSpace(string(snippet)); text != "" {
+			msg += ": " + text
+		}
+		return resp.StatusCode, errors.New(msg)
+	}
+	return resp.StatusCode, nil
+}
+
+// validateWebhookURL allows absolute http(s) URLs without credentials. Where
+// they may connect to is checked when delivering.
+func validateWebhookURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > webhookMaxURLLength {
+		return "", fmt.Errorf("%w: url is required and must be at most %d characters", ErrInvalidWebhook, webhookMaxURLLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", fmt.Errorf("%w: url must be an http or https URL", ErrInvalidWebhook)
+	}
+	if u.User != nil {
+		return "", fmt.Errorf("%w: url must not contain credentials", ErrInvalidWebhook)
+	}
+	u.Fragment = ""
+	return u.String(), nil
+}
+
+// applyWebhookRequest validates req and copies it onto webhook. Omitted
+// fields keep their current value, a new webhook gets every tip event.
+func applyWebhookRequest(webhook *dbmodel.Webhook, req model.WebhookRequest) error {
+	if req.URL != "" || webhook.URL == "" {
+		u, err := validateWebhookURL(req.URL)
+		if err != nil {
+			return err
+		}
+		webhook.URL = u
+	}
+
+	if req.Events != nil {
+		seen := make(map[string]bool)
+		events := make([]string, 0, len(req.Events))
+		for _, event := range req.Events {
+			event = strings.ToLower(strings.TrimSpace(event))
+			known := false
+			for _, e := range webhookEvents {
+				known = known || e == event
+			}
+			if !known {
+				return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+			}
+			if !seen[event] {
+				seen[event] = true
+				events = append(events, event)
+			}
+		}
+		if len(events) == 0 {
+			return fmt.Errorf("%w: subscribe to at least one event", ErrInvalidWebhook)
+		}
+		webhook.Events = strings.Join(events, ",")
+	} else if webhook.Events == "" {
+		webhook.Events = strings.Join(webhookEvents, ",")
+	}
+
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+func (s *Service) GetWebhooks(userID uint) ([]model.WebhookItem, error) {
+	webhooks, err := s.db.GetWebhooks(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.WebhookItem, 0, len(webhooks))
+	for i := range webhooks {
+		items = append(items, webhookItem(&webhooks[i]))
+	}
+	return items, nil
+}
+
+func (s *Service) CreateWebhook(userID uint, req model.WebhookRequest) (*model.WebhookItem, error) {
+	count, err := s.db.CountWebhooks(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= webhookMaxPerUser {
+		return nil, fmt.Errorf("%w: at most %d webhooks are allowed", ErrInvalidWebhook, webhookMaxPerUser)
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	webhook := &dbmodel.Webhook{UserID: userID, Secret: secret, Enabled: true}
+	if err := applyWebhookRequest(webhook, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.CreateWebhook(webhook); err != nil {
+		return nil, err
+	}
+
+	item := webhookItem(webhook)
+	return &item, nil
+}
+
+func (s *Service) UpdateWebhook(userID, webhookID uint, req model.WebhookRequest) (*model.WebhookItem, error) {
+	webhook, err := s.getWebhook(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyWebhookRequest(webhook, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.UpdateWebhook(webhook); err != nil {
+		return nil, err
+	}
+
+	item := webhookItem(webhook)
+	return &item, nil
+}
+
+func (s *Service) DeleteWebhook(userID, webhookID uint) error {
+	ok, err := s.db.DeleteWebhook(userID, webhookID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// RotateWebhookSecret replaces the webhook's signing secret. Deliveries
+// still queued are signed with the new one.
+func (s *Service) RotateWebhookSecret(userID, webhookID uint) (*model.WebhookItem, error) {
+	webhook, err := s.getWebhook(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.Secret, err = newWebhookSecret(); err != nil {
+		return nil, err
+	}
+	if err := s.db.UpdateWebhook(webhook); err != nil {
+		return nil, err
+	}
+
+	item := webhookItem(webhook)
+	return &item, nil
+}
+
+func (s *Service) GetWebhookDeliveries(userID, webhookID uint) ([]model.WebhookDeliveryItem, error) {
+	if _, err := s.getWebhook(userID, webhookID); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.db.GetWebhookDeliveries(webhookID, webhookLogLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.WebhookDeliveryItem, 0, len(deliveries))
+	for i := range deliveries {
+		items = append(items, webhookDeliveryItem(&deliveries[i]))
+	}
+	return items, nil
+}
+
+// RedeliverWebhook queues a logged delivery to be sent again with its
+// original event ID, so receivers can tell it's a duplicate
+func (s *Service) RedeliverWebhook(userID, webhookID, deliveryID uint) error {
+	if _, err := s.getWebhook(userID, webhookID); err != nil {
+		return err
+	}
+	delivery, err := s.db.GetWebhookDelivery(webhookID, deliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWebhookNotFound
+		}
+		return err
+	}
+	if delivery.Status == dbmodel.WebhookDeliveryPending {
+		return fmt.Errorf("%w: delivery is still being retried", ErrInvalidWebhook)
+	}
+	return s.db.RequeueWebhookDelivery(delivery.ID)
+}
+
+// SendTestWebhook delivers a sample tip.confirmed payload with the
+// webhook.test event type right away, without retries, and logs the result
+func (s *Service) SendTestWebhook(ctx context.Context, userID, webhookID uint) (*model.WebhookDeliveryItem, error) {
+	webhook, err := s.getWebhook(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tip := &dbmodel.Tip{
+		StreamerID: user.Username,
+		Sender:     "TestUser",
+		Message:    "This is a test webhook",
+		Amount:     "10",
+		Asset:      "USDC",
+		USDValue:   "10.00",
+		ChainID:    "1",
+		Status:     dbmodel.TipStatusConfirmed,
+		CreatedAt:  time.Now(),
+	}
+	payload, err := json.Marshal(webhookTipEvent(WebhookEventTest, tip, true))
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &dbmodel.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   uuid.New().String(),
+		Event:     WebhookEventTest,
+		Payload:   string(payload),
+		Status:    dbmodel.WebhookDeliveryFailed, // Unless the attempt succeeds
+		NextRunAt: time.Now(),
+	}
+	s.attemptWebhookDelivery(ctx, webhook, delivery)
+	if err := s.db.CreateWebhookDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	item := webhookDeliveryItem(delivery)
+	return &item, nil
+}
+
+func (s *Service) getWebhook(userID, webhookID uint) (*dbmodel.Webhook, error) {
+	webhook, err := s.db.GetWebhook(userID, webhookID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (s *Server) HandleGetWebhooks(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	webhooks, err := s.service.GetWebhooks(claims.UserID)
+	if err != nil {
+		s.logger.Printf("Failed to fetch webhooks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks, "events": webhookEvents})
+}
+
+func (s *Server) HandleCreateWebhook(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	var req model.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	webhook, err := s.service.CreateWebhook(claims.UserID, req)
+	if err != nil {
+		s.writeWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+func (s *Server) HandleUpdateWebhook(c *gin.Context) {
+	s.handleWebhook(c, func(userID, webhookID uint) (any, error) {
+		var req model.WebhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, fmt.Errorf("%w: invalid request body", ErrInvalidWebhook)
+		}
+		return s.service.UpdateWebhook(userID, webhookID, req)
+	})
+}
+
+func (s *Server) HandleDeleteWebhook(c *gin.Context) {
+	s.handleWebhook(c, func(userID, webhookID uint) (any, error) {
+		if err := s.service.DeleteWebhook(userID, webhookID); err != nil {
+			return nil, err
+		}
+		return gin.H{"message": "Webhook deleted"}, nil
+	})
+}
+
+func (s *Server) HandleRotateWebhookSecret(c *gin.Context) {
+	s.handleWebhook(c, func(userID, webhookID uint) (any, error) {
+		return s.service.RotateWebhookSecret(userID, webhookID)
+	})
+}
+
+func (s *Server) HandleTestWebhook(c *gin.Context) {
+	s.handleWebhook(c, func(userID, webhookID uint) (any, error) {
+		return s.service.SendTestWebhook(c.Request.Context(), userID, webhookID)
+	})
+}
+
+func (s *Server) HandleGetWebhookDeliveries(c *gin.Context) {
+	s.handleWebhook(c, func(userID, webhookID uint) (any, error) {
+		deliveries, err := s.service.GetWebhookDeliveries(userID, webhookID)
+		if err != nil {
+			return nil, err
+		}
+		return gin.H{"deliveries": deliveries}, nil
+	})
+}
+
+func (s *Server) HandleRedeliverWebhook(c *gin.Context) {
+	s.handleWebhook(c, func(userID, webhookID uint) (any, error) {
+		deliveryID, err := strconv.ParseUint(c.Param("delivery"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid delivery ID", ErrInvalidWebhook)
+		}
+		if err := s.service.RedeliverWebhook(userID, webhookID, uint(deliveryID)); err != nil {
+			return nil, err
+		}
+		return gin.H{"message": "Delivery queued"}, nil
+	})
+}
+
+// handleWebhook authenticates the streamer and applies action to the :id webhook
+func (s *Server) handleWebhook(c *gin.Context, action func(userID, webhookID uint) (any, error)) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	webhookID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	resp, err := action(claims.UserID, uint(webhookID))
+	if err != nil {
+		s.writeWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) writeWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrWebhookNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		s.logger.Printf("Failed to update webhook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+	}
+}
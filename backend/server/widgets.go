@@ -0,0 +1,484 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbmodel "github.com/patiee/backend/db/model"
+	"github.com/patiee/backend/server/model"
+	"gorm.io/gorm"
+)
+
+const (
+	widgetMaxNameLength    = 50
+	widgetMaxSubscriptions = 20
+)
+
+var (
+	ErrWidgetNotFound = errors.New("widget not found")
+	ErrInvalidWidget  = errors.New("invalid widget")
+)
+
+// Events each widget type receives unless it lists its own subscriptions
+var widgetDefaultSubscriptions = map[string][]string{
+	dbmodel.WidgetTypeAlert: {"TIP*", "ALERT_*"},
+	dbmodel.WidgetTypeGoal:  {"GOAL_*"},
+}
+
+var widgetDefaultNames = map[string]string{
+	dbmodel.WidgetTypeAlert: "Alerts",
+	dbmodel.WidgetTypeGoal:  "Goal",
+}
+
+// Event type, optionally ending in * to match a prefix
+var subscriptionRegex = regexp.MustCompile(`^[A-Z][A-Z_]*\*?$`)
+
+func defaultWidgetConfig() model.WidgetConfig {
+	theme := defaultWidgetTheme()
+	return model.WidgetConfig{TTS: true, Theme: &theme}
+}
+
+// storedWidgetConfig also reads configs saved before themes, which kept the
+// colors at the top level
+type storedWidgetConfig struct {
+	model.WidgetConfig
+	BgColor      string `json:"background_color"`
+	UserColor    string `json:"user_color"`
+	AmountColor  string `json:"amount_color"`
+	MessageColor string `json:"message_color"`
+}
+
+// widgetConfig decodes the stored config, upgrading a config without a theme
+func widgetConfig(w *dbmodel.Widget) model.WidgetConfig {
+	stored := storedWidgetConfig{WidgetConfig: model.WidgetConfig{TTS: true}}
+	if w.Config != "" {
+		_ = json.Unmarshal([]byte(w.Config), &stored)
+	}
+
+	config := stored.WidgetConfig
+	if config.Theme == nil {
+		theme := legacyWidgetTheme(stored.BgColor, stored.UserColor, stored.AmountColor, stored.MessageColor)
+		config.Theme = &theme
+	}
+	return config
+}
+
+// widgetSubscriptions returns the event types the widget receives
+func widgetSubscriptions(w *dbmodel.Widget) []string {
+	if w.Subscriptions == "" {
+		return widgetDefaultSubscriptions[w.Type]
+	}
+	return strings.Split(w.Subscriptions, ",")
+}
+
+const dashboardWSProtocol = "bearer"
+
+// Events the dashboard follows, it moderates the alert queue over REST
+var dashboardSubscriptions = []string{"ALERT_QUEUE", "ALERT_SKIP"}
+
+// subscribed reports whether eventType matches one of subscriptions
+func subscribed(subscriptions []string, eventType string) bool {
+	for _, sub := range subscriptions {
+		if prefix, ok := strings.CutSuffix(sub, "*"); ok {
+			if strings.HasPrefix(eventType, prefix) {
+				return true
+			}
+		} else if sub == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func widgetItem(w *dbmodel.Widget) model.WidgetItem {
+	return model.WidgetItem{
+		ID:            w.ID,
+		Type:          w.Type,
+		Name:          w.Name,
+		Token:         w.Token,
+		Config:        widgetConfig(w),
+		Enabled:       w.Enabled,
+		Subscriptions: widgetSubscriptions(w),
+		CreatedAt:     w.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// MigrateWidgets moves the widget settings users had before the widgets table
+// into a default alert widget, keeping its token so overlay URLs keep working
+func (s *Service) MigrateWidgets() error {
+	rows, err := s.db.GetLegacyWidgetSettings()
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		theme := legacyWidgetTheme(row.WidgetBgColor, row.WidgetUserColor, row.WidgetAmountColor, row.WidgetMessageColor)
+		config := model.WidgetConfig{TTS: row.WidgetTTS, Theme: &theme}
+
+		data, err := json.Marshal(config)
+		if err != nil {
+			return err
+		}
+		if err := s.db.CreateWidgetWithToken(&dbmodel.Widget{
+			UserID:  row.UserID,
+			Type:    dbmodel.WidgetTypeAlert,
+			Name:    widgetDefaultNames[dbmodel.WidgetTypeAlert],
+			Token:   row.WidgetToken,
+			Config:  string(data),
+			Enabled: true,
+		}); err != nil {
+			return fmt.Errorf("failed to migrate widget of user %d: %v", row.UserID, err)
+		}
+	}
+
+	if len(rows) > 0 {
+		s.logger.Printf("Migrated widget settings of %d users", len(rows))
+	}
+	return nil
+}
+
+// DefaultWidget returns the user's first alert widget, creating it if needed.
+// The single-widget endpoints (/me/widget, /widget/regenerate) act on it.
+func (s *Service) DefaultWidget(userID uint) (*dbmodel.Widget, error) {
+	widget, err := s.db.GetFirstWidget(userID, dbmodel.WidgetTypeAlert)
+	if err == nil {
+		return widget, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	data, err := json.Marshal(defaultWidgetConfig())
+	if err != nil {
+		return nil, err
+	}
+	widget = &dbmodel.Widget{
+		UserID:  userID,
+		Type:    dbmodel.WidgetTypeAlert,
+		Name:    widgetDefaultNames[dbmodel.WidgetTypeAlert],
+		Config:  string(data),
+		Enabled: true,
+	}
+	if err := s.db.CreateWidget(widget); err != nil {
+		return nil, err
+	}
+	return widget, nil
+}
+
+// ResolveWidget authenticates an overlay by its widget token
+func (s *Service) ResolveWidget(token string) (*dbmodel.Widget, *dbmodel.User, error) {
+	widget, err := s.db.GetWidgetByToken(token)
+	if err != nil || !widget.Enabled {
+		return nil, nil, ErrWidgetNotFound
+	}
+	user, err := s.db.GetUserByID(widget.UserID)
+	if err != nil {
+		return nil, nil, ErrWidgetNotFound
+	}
+	return widget, user, nil
+}
+
+func (s *Service) GetWidgets(userID uint) ([]model.WidgetItem, error) {
+	// Make sure a user from before widgets has their alert widget
+	if _, err := s.DefaultWidget(userID); err != nil {
+		return nil, err
+	}
+	widgets, err := s.db.GetWidgets(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.WidgetItem, 0, len(widgets))
+	for i := range widgets {
+		items = append(items, widgetItem(&widgets[i]))
+	}
+	return items, nil
+}
+
+func (s *Service) CreateWidget(userID uint, req model.WidgetRequest) (*model.WidgetItem, error) {
+	if _, ok := widgetDefaultSubscriptions[req.Type]; !ok {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidWidget, req.Type)
+	}
+
+	widget := &dbmodel.Widget{UserID: userID, Type: req.Type, Enabled: true}
+	if err := applyWidgetRequest(widget, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.CreateWidget(widget); err != nil {
+		return nil, err
+	}
+
+	item := widgetItem(widget)
+	return &item, nil
+}
+
+func (s *Service) UpdateWidget(userID, widgetID uint, req model.WidgetRequest) (*model.WidgetItem, error) {
+	widget, err := s.getWidget(userID, widgetID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Type != "" && req.Type != widget.Type {
+		return nil, fmt.Errorf("%w: type can't be changed", ErrInvalidWidget)
+	}
+	if err := applyWidgetRequest(widget, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.UpdateWidget(widget); err != nil {
+		return nil, err
+	}
+	if !widget.Enabled {
+		s.closeWidgetConnections(userID, widget.ID)
+	}
+
+	item := widgetItem(widget)
+	return &item, nil
+}
+
+func (s *Service) DeleteWidget(userID, widgetID uint) error {
+	ok, err := s.db.DeleteWidget(userID, widgetID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWidgetNotFound
+	}
+
+	s.closeWidgetConnections(userID, widgetID)
+	return nil
+}
+
+// closeWidgetConnections disconnects the widget's open connections on every
+// replica, after its token stopped being valid
+func (s *Service) closeWidgetConnections(userID, widgetID uint) {
+	s.publishWidgetEvent(userID, model.WidgetClosedNotification{Type: widgetClosedEvent, WidgetID: widgetID})
+}
+
+// RegenerateWidgetToken replaces a widget's token, the default widget's when widgetID is 0
+func (s *Service) RegenerateWidgetToken(userID, widgetID uint) (string, error) {
+	if widgetID == 0 {
+		widget, err := s.DefaultWidget(userID)
+		if err != nil {
+			return "", err
+		}
+		widgetID = widget.ID
+	}
+
+	token, err := s.db.RefreshWidgetToken(userID, widgetID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrWidgetNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	s.closeWidgetConnections(userID, widgetID)
+	return token, nil
+}
+
+// UpdateWidgetConfig updates the default widget's TTS and theme colors
+func (s *Service) UpdateWidgetConfig(userID uint, req model.UpdateWidgetRequest) error {
+	widget, err := s.DefaultWidget(userID)
+	if err != nil {
+		return err
+	}
+
+	config := widgetConfig(widget)
+	config.TTS = req.WaitTTS
+	for _, color := range []struct {
+		value  string
+		target *string
+	}{
+		{req.BgColor, &config.Theme.Colors.Background},
+		{req.UserColor, &config.Theme.Colors.User},
+		{req.AmountColor, &config.Theme.Colors.Amount},
+		{req.MessageColor, &config.Theme.Colors.Message},
+	} {
+		if color.value != "" {
+			*color.target = color.value
+		}
+	}
+	if err := validateTheme(config.Theme); err != nil {
+		return err
+	}
+	return s.UpdateWidgetSettings(widget, config)
+}
+
+// UpdateWidgetSettings stores a new config for widget
+func (s *Service) UpdateWidgetSettings(widget *dbmodel.Widget, config model.WidgetConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	widget.Config = string(data)
+	return s.db.UpdateWidget(widget)
+}
+
+func (s *Service) getWidget(userID, widgetID uint) (*dbmodel.Widget, error) {
+	widget, err := s.db.GetWidget(userID, widgetID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWidgetNotFound
+		}
+		return nil, err
+	}
+	return widget, nil
+}
+
+// applyWidgetRequest validates req and copies it onto widget
+func applyWidgetRequest(widget *dbmodel.Widget, req model.WidgetRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = widget.Name
+	}
+	if name == "" {
+		name = widgetDefaultNames[widget.Type]
+	}
+	if len([]rune(name)) > widgetMaxNameLength {
+		return fmt.Errorf("%w: name must be at most %d characters", ErrInvalidWidget, widgetMaxNameLength)
+	}
+
+	if len(req.Subscriptions) > widgetMaxSubscriptions {
+		return fmt.Errorf("%w: at most %d subscriptions are allowed", ErrInvalidWidget, widgetMaxSubscriptions)
+	}
+	if req.Subscriptions != nil {
+		subscriptions := make([]string, 0, len(req.Subscriptions))
+		for _, sub := range req.Subscriptions {
+			sub = strings.ToUpper(strings.TrimSpace(sub))
+			if !subscriptionRegex.MatchString(sub) {
+				return fmt.Errorf("%w: invalid subscription %q", ErrInvalidWidget, sub)
+			}
+			subscriptions = append(subscriptions, sub)
+		}
+		widget.Subscriptions = strings.Join(subscriptions, ",")
+	}
+
+	if req.Config != nil {
+		// A config without a theme keeps the current one
+		if req.Config.Theme == nil {
+			req.Config.Theme = widgetConfig(widget).Theme
+		} else if err := validateTheme(req.Config.Theme); err != nil {
+			return err
+		}
+		data, err := json.Marshal(req.Config)
+		if err != nil {
+			return err
+		}
+		widget.Config = string(data)
+	}
+
+	widget.Name = name
+	if req.Enabled != nil {
+		widget.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+func (s *Server) HandleGetWidgets(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	widgets, err := s.service.GetWidgets(claims.UserID)
+	if err != nil {
+		s.logger.Printf("Failed to fetch widgets: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch widgets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"widgets": widgets})
+}
+
+func (s *Server) HandleCreateWidget(c *gin.Context) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	var req model.WidgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	widget, err := s.service.CreateWidget(claims.UserID, req)
+	if err != nil {
+		s.writeWidgetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, widget)
+}
+
+func (s *Server) HandleUpdateWidgetByID(c *gin.Context) {
+	s.handleWidget(c, func(userID, widgetID uint) (any, error) {
+		var req model.WidgetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, fmt.Errorf("%w: invalid request body", ErrInvalidWidget)
+		}
+		return s.service.UpdateWidget(userID, widgetID, req)
+	})
+}
+
+func (s *Server) HandleDeleteWidget(c *gin.Context) {
+	s.handleWidget(c, func(userID, widgetID uint) (any, error) {
+		if err := s.service.DeleteWidget(userID, widgetID); err != nil {
+			return nil, err
+		}
+		return gin.H{"message": "Widget deleted"}, nil
+	})
+}
+
+func (s *Server) HandleRegenerateWidgetToken(c *gin.Context) {
+	s.handleWidget(c, func(userID, widgetID uint) (any, error) {
+		token, err := s.service.RegenerateWidgetToken(userID, widgetID)
+		if err != nil {
+			return nil, err
+		}
+		return gin.H{"message": "Token regenerated", "token": token}, nil
+	})
+}
+
+// handleWidget authenticates the streamer and applies action to the :id widget
+func (s *Server) handleWidget(c *gin.Context, action func(userID, widgetID uint) (any, error)) {
+	claims, ok := s.requireSession(c)
+	if !ok {
+		return
+	}
+
+	widgetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid widget ID"})
+		return
+	}
+
+	resp, err := action(claims.UserID, uint(widgetID))
+	if err != nil {
+		s.writeWidgetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) writeWidgetError(c *gin.Context, err error) {
+	var themeErr *ThemeError
+	switch {
+	case errors.As(err, &themeErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "fields": themeErr.Fields})
+	case errors.Is(err, ErrInvalidWidget):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrWidgetNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		s.logger.Printf("Failed to update widget: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update widget"})
+	}
+}
@@ -16,6 +16,8 @@ import type { Token } from "@/hooks/useTokenList";
 import { WalletSelectionModal } from "@/components/WalletSelectionModal";
 import { WalletConnectButton } from "@/components/WalletConnectButton";
 import { WalletConnectionModals } from "@/components/WalletConnectionModals";
+import { storeSession } from "@/config/session";
+import { createSiweMessage, SiweAccountType, BITCOIN_MAINNET } from "@/config/siwe";
 // import { normalize } from 'viem/ens' // Removed as it causes error
 
 export default function AuthPage() {
@@ -78,9 +80,17 @@ function FieldSettings({ label, hasDNS, useDNS, onToggle }: { label: string, has
     );
 }
 
+// Only follow redirects to paths on this site
+function safeRedirect(path: string | null) {
+    if (!path || !path.startsWith("/") || path.startsWith("//") || path.includes("\\")) return "/me";
+    return path;
+}
+
 function AuthContent() {
     const router = useRouter();
     const searchParams = useSearchParams();
+    const redirectTo = safeRedirect(searchParams.get("redirect"));
+    const exchangedCode = useRef<string | null>(null);
     const [step, setStep] = useState(1);
     const [formData, setFormData] = useState({
         username: "",
@@ -158,34 +168,50 @@ function AuthContent() {
             return;
         }
 
-        const token = searchParams.get("token");
-        if (token) {
-            localStorage.setItem("user_token", token);
-            router.push("/me");
-            return;
-        }
+        // The OAuth callback hands over a one-time code, exchanged here so
+        // tokens never appear in the URL
+        const code = searchParams.get("code");
+        if (code) {
+            if (exchangedCode.current === code) return;
+            exchangedCode.current = code;
 
-        const storedToken = localStorage.getItem("user_token");
-        const signupToken = searchParams.get("signup_token");
-        const urlStep = searchParams.get("step");
-
-        if (storedToken && !signupToken && !error && !token) {
-            router.replace("/me");
+            fetch(`${process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080'}/api/auth/exchange`, {
+                method: "POST",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify({ code })
+            })
+                .then(async res => {
+                    const data = await res.json();
+                    if (!res.ok) throw new Error(data.error || "exchange_failed");
+                    if (data.token) {
+                        storeSession(data);
+                        router.replace(safeRedirect(searchParams.get("redirect")));
+                    } else if (data.signup_token) {
+                        setFormData(prev => ({ ...prev, signup_token: data.signup_token }));
+                        setStep(2);
+                        const redirect = searchParams.get("redirect");
+                        router.replace(redirect ? `/auth?redirect=${encodeURIComponent(safeRedirect(redirect))}` : "/auth");
+                    }
+                })
+                .catch(e => {
+                    console.error("Auth error:", e);
+                    alert(`Authentication Failed: ${e.message}`);
+                    router.replace("/auth");
+                });
             return;
         }
 
-        if (signupToken) {
-            setFormData(prev => ({ ...prev, signup_token: signupToken }));
-            if (urlStep === "2") {
-                setStep(2);
-            } else {
-                setStep(2); // Default to step 2 if token present
-            }
+        // Already signed in, unless a provider signup is in progress
+        const storedToken = localStorage.getItem("user_token");
+        if (storedToken && !exchangedCode.current) {
+            router.replace(safeRedirect(searchParams.get("redirect")));
         }
     }, [searchParams, router]);
 
     const handleSocialLogin = (provider: string) => {
-        window.location.href = `${process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080'}/auth/${provider}/login`;
+        const redirect = searchParams.get("redirect");
+        const query = redirect ? `?redirect=${encodeURIComponent(safeRedirect(redirect))}` : "";
+        window.location.href = `${process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080'}/auth/${provider}/login${query}`;
     };
 
     const validateUsername = (username: string) => {
@@ -399,8 +425,8 @@ function AuthContent() {
 
             const data = await res.json();
             if (res.ok) {
-                localStorage.setItem("user_token", data.token);
-                router.push("/me");
+                storeSession(data);
+                router.push(redirectTo);
             } else {
                 setUsernameError(data.error || "Registration failed");
             }
@@ -499,6 +525,7 @@ function AuthContent() {
                             <WalletLoginButton
                                 setStep={setStep}
                                 setFormData={setFormData}
+                                redirectTo={redirectTo}
                                 onOpenEVM={() => setIsEVMModalOpen(true)}
                                 onOpenSolana={() => setIsSolanaModalOpen(true)}
                                 onOpenBitcoin={() => setIsBitcoinModalOpen(true)}
@@ -750,7 +777,7 @@ function AuthContent() {
 }
 
 // ... (WalletLoginButton remains similar but we might need to adjust props if needed, mostly kept for Step 1)
-function WalletLoginButton({ setStep, setFormData, onOpenEVM, onOpenSolana, onOpenBitcoin, onOpenSui }: any) {
+function WalletLoginButton({ setStep, setFormData, redirectTo, onOpenEVM, onOpenSolana, onOpenBitcoin, onOpenSui }: any) {
     // Keep existing implementation
     // ...
     // But when login succeeds with "signup_needed", it sets step 2.
@@ -760,7 +787,7 @@ function WalletLoginButton({ setStep, setFormData, onOpenEVM, onOpenSolana, onOp
     // Since I am replacing the whole file, I MUST include WalletLoginButton.
 
     // EVM
-    const { address: evmAddress, isConnected: isEVMConnected } = useAccount();
+    const { address: evmAddress, isConnected: isEVMConnected, chainId: evmChainId } = useAccount();
     const { signMessageAsync: signEVM } = useSignMessage();
     const router = useRouter();
 
@@ -779,41 +806,34 @@ function WalletLoginButton({ setStep, setFormData, onOpenEVM, onOpenSolana, onOp
     const [error, setError] = useState("");
     const [isSelectionOpen, setIsSelectionOpen] = useState(false);
 
+    // Signs a Sign-In With Ethereum message, in its CAIP-122 form for Solana,
+    // Bitcoin and Sui wallets
     const performLogin = async (
         address: string,
-        chainFamily: ChainFamily,
-        signFn: () => Promise<string>
+        accountType: SiweAccountType,
+        chainId: number | string,
+        signFn: (message: string) => Promise<string>
     ) => {
         setLoading(true);
         setError("");
         try {
-            const timestamp = Math.floor(Date.now() / 1000);
-            let signature = "";
-
-            if (chainFamily === ChainFamily.EVM || chainFamily === ChainFamily.BITCOIN) {
-                signature = await signFn();
-            } else if (chainFamily === ChainFamily.SOLANA) {
-                signature = await signFn();
-            } else if (chainFamily === ChainFamily.SUI) {
-                signature = await signFn();
-            }
+            const message = await createSiweMessage(address, accountType, chainId);
+            const signature = await signFn(message);
 
             const res = await fetch(`${process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080'}/api/auth/wallet/login`, {
                 method: "POST",
                 headers: { "Content-Type": "application/json" },
                 body: JSON.stringify({
-                    address,
-                    timestamp,
-                    signature,
-                    chain_family: chainFamily
+                    message,
+                    signature
                 })
             });
 
             const data = await res.json();
             if (res.ok) {
                 if (data.status === "success") {
-                    localStorage.setItem("user_token", data.token);
-                    router.push("/me");
+                    storeSession(data);
+                    router.push(redirectTo);
                 } else if (data.status === "signup_needed") {
                     setFormData((prev: any) => ({ ...prev, signup_token: data.signup_token }));
                     setStep(2);
@@ -837,38 +857,27 @@ function WalletLoginButton({ setStep, setFormData, onOpenEVM, onOpenSolana, onOp
 
     const handleLoginEVM = async () => {
         if (!isEVMConnected || !evmAddress) return onOpenEVM();
-        await performLogin(evmAddress, ChainFamily.EVM, async () => {
-            const timestamp = Math.floor(Date.now() / 1000);
-            return await signEVM({ message: `{"address":"${evmAddress}","timestamp":${timestamp}}` });
-        });
+        await performLogin(evmAddress, "Ethereum", evmChainId ?? 1, message => signEVM({ message }));
     };
 
     const handleLoginSolana = async () => {
         if (!isSolanaConnected || !solanaPublicKey || !signSolana) return onOpenSolana();
-        await performLogin(solanaPublicKey.toBase58(), ChainFamily.SOLANA, async () => {
-            const timestamp = Math.floor(Date.now() / 1000);
-            const messageStr = `{"address":"${solanaPublicKey.toBase58()}","timestamp":${timestamp}}`;
-            const message = new TextEncoder().encode(messageStr);
-            const signatureBytes = await signSolana(message);
+        await performLogin(solanaPublicKey.toBase58(), "Solana", "mainnet", async message => {
+            const signatureBytes = await signSolana(new TextEncoder().encode(message));
             return Array.from(signatureBytes).map(b => b.toString(16).padStart(2, '0')).join('');
         });
     };
 
     const handleLoginBitcoin = async () => {
         if (!isBtcConnected || !btcAddress || !signBitcoin) return onOpenBitcoin();
-        await performLogin(btcAddress, ChainFamily.BITCOIN, async () => {
-            const timestamp = Math.floor(Date.now() / 1000);
-            return await signBitcoin(`{"address":"${btcAddress}","timestamp":${timestamp}}`);
-        });
+        // BIP-137 message signature, base64
+        await performLogin(btcAddress, "Bitcoin", BITCOIN_MAINNET, message => signBitcoin(message));
     };
 
     const handleLoginSui = async () => {
         if (!isSuiConnected || !suiAccount) return onOpenSui();
-        await performLogin(suiAccount.address, ChainFamily.SUI, async () => {
-            const timestamp = Math.floor(Date.now() / 1000);
-            const messageStr = `{"address":"${suiAccount.address}","timestamp":${timestamp}}`;
-            const msgBytes = new TextEncoder().encode(messageStr);
-            const result = await signSui({ message: msgBytes });
+        await performLogin(suiAccount.address, "Sui", "mainnet", async message => {
+            const result = await signSui({ message: new TextEncoder().encode(message) });
             return result.signature;
         });
     };
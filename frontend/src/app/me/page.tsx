@@ -4,6 +4,7 @@ import { useEffect, useState, Suspense } from "react";
 import { useRouter, useSearchParams } from "next/navigation";
 import { Wallet, User as UserIcon, Copy, Check, DollarSign, TrendingUp, ExternalLink, ChevronRight } from "lucide-react";
 import Link from "next/link";
+import { logout } from "@/config/session";
 
 export type UserProfile = {
     username: string;
@@ -25,16 +26,6 @@ function DashboardContent() {
 
     useEffect(() => {
         console.log("Dashboard mounted");
-        const urlToken = searchParams.get("token");
-
-        // If token is in URL (from Login redirect), save it and clean URL
-        if (urlToken) {
-            console.log("New token detected, saving and refreshing...");
-            localStorage.setItem("user_token", urlToken);
-            router.replace("/me"); // This will trigger useEffect again with clean URL
-            return;
-        }
-
         const token = localStorage.getItem("user_token");
 
         if (!token) {
@@ -131,7 +122,7 @@ function DashboardContent() {
                         Test Connection
                     </a>
                     <button
-                        onClick={() => { localStorage.removeItem("user_token"); router.push("/auth"); }}
+                        onClick={async () => { await logout(); router.push("/auth"); }}
                         className="px-4 py-2 bg-red-600 rounded hover:bg-red-500 text-sm font-bold"
                     >
                         Log Out
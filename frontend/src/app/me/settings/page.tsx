@@ -5,6 +5,7 @@ import { useRouter } from "next/navigation";
 import Link from "next/link";
 import { useAccount, useEnsName, useEnsAvatar as useEnsAvatarHook, useEnsText } from "wagmi";
 import { User, Image as ImageIcon, FileText, Check, Save, Loader2, Twitch, Monitor, Chrome, AlertTriangle, ArrowLeft, Upload, Settings } from "lucide-react";
+import { SessionsPanel } from "@/components/SessionsPanel";
 
 function FieldSettings({ label, hasDNS, useDNS, onToggle }: { label: string, hasDNS: boolean, useDNS: boolean, onToggle: (useDNS: boolean) => void }) {
     const [isOpen, setIsOpen] = useState(false);
@@ -127,7 +128,7 @@ export default function SettingsPage() {
             })
             .catch(() => {
                 localStorage.removeItem("user_token");
-                router.push("/auth");
+                router.push("/auth?redirect=/me/settings");
             })
             .finally(() => setLoading(false));
     }, []);
@@ -174,26 +175,25 @@ export default function SettingsPage() {
         }
     };
 
-    const handleSocialConnect = async (provider: string) => {
+    const handleSocialConnect = (provider: string) => {
         const token = localStorage.getItem("user_token");
         if (!token) return;
 
-        try {
-            const apiUrl = process.env.NEXT_PUBLIC_API_URL || '';
-            const res = await fetch(`${apiUrl}/auth/${provider}/login?link=true`, {
-                headers: {
-                    "Authorization": `Bearer ${token}`
-                }
-            });
-            const data = await res.json();
-            if (data.url) {
-                window.location.href = data.url;
-            } else {
-                alert("Failed to initiate linking: " + (data.error || "Unknown error"));
-            }
-        } catch (e) {
-            alert("Error connecting to server");
+        // A top level form POST lets the backend bind the link to this
+        // browser with a cookie before redirecting to the provider
+        const apiUrl = process.env.NEXT_PUBLIC_API_URL || '';
+        const form = document.createElement("form");
+        form.method = "POST";
+        form.action = `${apiUrl}/auth/${provider}/link`;
+        for (const [name, value] of Object.entries({ token, redirect: "/me/settings" })) {
+            const input = document.createElement("input");
+            input.type = "hidden";
+            input.name = name;
+            input.value = value;
+            form.appendChild(input);
         }
+        document.body.appendChild(form);
+        form.submit();
     };
 
     const isConnected = (p: string) => profile?.connected_providers?.includes(p);
@@ -507,6 +507,8 @@ export default function SettingsPage() {
                         </div>
                     </div>
                 </div>
+
+                <SessionsPanel />
             </div>
         </div>
     );
@@ -33,7 +33,7 @@ function TipsContent() {
         setLoading(true);
         const token = localStorage.getItem("user_token");
         if (!token) {
-            router.push("/auth");
+            router.push("/auth?redirect=/me/tips");
             return;
         }
 
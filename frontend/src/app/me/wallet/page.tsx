@@ -59,7 +59,7 @@ function WalletsContent() {
     useEffect(() => {
         const token = localStorage.getItem("user_token");
         if (!token) {
-            router.push("/auth");
+            router.push("/auth?redirect=/me/wallet");
             return;
         }
 
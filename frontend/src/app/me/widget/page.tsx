@@ -5,6 +5,16 @@ import { useRouter } from "next/navigation";
 import { ArrowLeft, Save, CheckCircle, AlertTriangle, RefreshCw, Volume2, Monitor } from "lucide-react";
 import Link from "next/link";
 import { TipWidget } from "@/components/TipWidget";
+import { AlertQueuePanel } from "@/components/AlertQueuePanel";
+import { MessageFilterPanel } from "@/components/MessageFilterPanel";
+import { TipRulesPanel } from "@/components/TipRulesPanel";
+import { TTSSettingsPanel } from "@/components/TTSSettingsPanel";
+import { ChatSettingsPanel } from "@/components/ChatSettingsPanel";
+import { WebhooksPanel } from "@/components/WebhooksPanel";
+import { GoalsPanel } from "@/components/GoalsPanel";
+import { WidgetsPanel } from "@/components/WidgetsPanel";
+import { ThemePanel } from "@/components/ThemePanel";
+import { MediaTiersPanel } from "@/components/MediaTiersPanel";
 
 interface WidgetSettings {
     tts_enabled: boolean;
@@ -87,7 +97,7 @@ function WidgetSettingsContent() {
         // ... (keep useEffect) ...
         const token = localStorage.getItem("user_token");
         if (!token) {
-            router.push("/auth");
+            router.push("/auth?redirect=/me/widget");
             return;
         }
 
@@ -160,6 +170,37 @@ function WidgetSettingsContent() {
         }
     };
 
+    const handleReplayLastAlert = async () => {
+        setMessage("");
+        setError("");
+
+        const token = localStorage.getItem("user_token");
+        if (!token) return;
+
+        try {
+            const res = await fetch(`${process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080'}/api/widget/replay`, {
+                method: "POST",
+                headers: {
+                    "Authorization": `Bearer ${token}`
+                }
+            });
+
+            if (res.status === 401) {
+                localStorage.removeItem("user_token");
+                router.push("/");
+                return;
+            }
+
+            const data = await res.json();
+            if (!res.ok) throw new Error(data.error || "Failed to replay alert");
+
+            setMessage("Last alert replayed!");
+            setTimeout(() => setMessage(""), 2000);
+        } catch (e: any) {
+            setError(e.message);
+        }
+    };
+
     const handleSave = async () => {
         // ... (keep existing logic, assuming it's unchanged) ...
         setSaving(true);
@@ -286,6 +327,12 @@ function WidgetSettingsContent() {
                         >
                             Copy URL
                         </button>
+                        <button
+                            onClick={handleReplayLastAlert}
+                            className="flex items-center gap-2 px-4 py-2 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-white transition-all font-semibold text-sm whitespace-nowrap border border-zinc-700 hover:border-zinc-600"
+                        >
+                            Replay Last Alert
+                        </button>
                         <button
                             onClick={handleRegenerateClick}
                             disabled={generating}
@@ -297,6 +344,26 @@ function WidgetSettingsContent() {
                     </div>
                 </div>
 
+                <AlertQueuePanel />
+
+                <MessageFilterPanel />
+
+                <TipRulesPanel />
+
+                <TTSSettingsPanel />
+
+                <ChatSettingsPanel />
+
+                <WebhooksPanel />
+
+                <GoalsPanel />
+
+                <WidgetsPanel />
+
+                <ThemePanel />
+
+                <MediaTiersPanel />
+
                 <div className="grid grid-cols-1 lg:grid-cols-2 gap-8 items-start">
 
                     {/* Left Column: Configuration */}
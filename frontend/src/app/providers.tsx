@@ -9,6 +9,7 @@ import { createNetworkConfig, SuiClientProvider, WalletProvider as SuiWalletProv
 
 import { config } from "@/config/wagmi";
 import { BitcoinWalletProvider } from "@/contexts/BitcoinWalletContext";
+import { installSessionRefresh } from "@/config/session";
 
 import dynamic from "next/dynamic";
 
@@ -20,6 +21,9 @@ const networkConfig = {
     testnet: { url: "https://fullnode.testnet.sui.io", network: "testnet" as const },
 };
 
+// Before any component fetches with a stored token
+installSessionRefresh();
+
 export function Providers({ children }: { children: React.ReactNode }) {
     const [queryClient] = useState(() => new QueryClient());
 
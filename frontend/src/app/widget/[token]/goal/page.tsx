@@ -0,0 +1,139 @@
+"use client";
+
+import { useEffect, useState, Suspense } from "react";
+import { useParams, useSearchParams } from "next/navigation";
+import { motion } from "framer-motion";
+
+type Goal = {
+    id: number;
+    title: string;
+    current: string;
+    target_amount: string;
+    target_asset: string;
+    percent: number;
+};
+
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+export default function GoalWidgetPage() {
+    return (
+        <Suspense fallback={null}>
+            <GoalWidget />
+        </Suspense>
+    );
+}
+
+// Donation goal overlay: a progress bar updated live by GOAL_PROGRESS and GOAL_REMOVED events
+function GoalWidget() {
+    const params = useParams();
+    const searchParams = useSearchParams();
+    const token = params.token as string;
+    const goalParam = searchParams.get("goal"); // Pin a goal, otherwise the newest running one
+
+    const [goal, setGoal] = useState<Goal | null>(null);
+    const [config, setConfig] = useState({
+        background_color: "#000000",
+        user_color: "#ffffff",
+        amount_color: "#22c55e"
+    });
+
+    // Fetch Goal Config
+    useEffect(() => {
+        const query = goalParam ? `?goal=${goalParam}` : "";
+        fetch(`${API_URL}/api/widget/${token}/goal${query}`)
+            .then(res => res.json())
+            .then(data => {
+                if (!data.username) return;
+                setConfig({
+                    background_color: data.widget_bg_color || "#000000",
+                    user_color: data.widget_user_color || "#ffffff",
+                    amount_color: data.widget_amount_color || "#22c55e"
+                });
+                setGoal(data.goal || null);
+            })
+            .catch(console.error);
+    }, [token, goalParam]);
+
+    // WebSocket Connection - progress updates
+    useEffect(() => {
+        const wsUrl = API_URL.replace("http", "ws") + `/ws/${token}`;
+        let socket: WebSocket | null = null;
+        let retryTimeout: NodeJS.Timeout;
+        let isMounted = true;
+
+        const connect = () => {
+            if (!isMounted) return;
+            socket = new WebSocket(wsUrl);
+            socket.onmessage = (event) => {
+                try {
+                    const data = JSON.parse(event.data);
+                    if (data.type === "GOAL_REMOVED") {
+                        // Deleted or paused, the next running goal to report takes its place
+                        setGoal(prev => prev?.id === data.goalId ? null : prev);
+                        return;
+                    }
+                    if (data.type !== "GOAL_PROGRESS") return;
+                    setGoal(prev => {
+                        // Follow the pinned goal, or the one shown (or the first to report if none is)
+                        const wanted = goalParam ? Number(goalParam) : prev?.id;
+                        if (wanted && data.goalId !== wanted) return prev;
+                        return {
+                            id: data.goalId,
+                            title: data.title,
+                            current: data.current,
+                            target_amount: data.targetAmount,
+                            target_asset: data.targetAsset,
+                            percent: data.percent
+                        };
+                    });
+                } catch (e) {
+                    console.error("WS Parse Error", e);
+                }
+            };
+            socket.onclose = () => {
+                if (isMounted) retryTimeout = setTimeout(connect, 3000);
+            };
+        };
+
+        connect();
+
+        return () => {
+            isMounted = false;
+            clearTimeout(retryTimeout);
+            socket?.close();
+        };
+    }, [token, goalParam]);
+
+    if (!goal) return null;
+
+    const isUSD = goal.target_asset === "USD";
+    const format = (value: string) => isUSD ? `$${value}` : `${value} ${goal.target_asset}`;
+
+    return (
+        <div className="min-h-screen bg-transparent flex items-end justify-center p-8 font-sans">
+            <div
+                className="w-full max-w-xl rounded-2xl p-4 shadow-2xl border border-white/10"
+                style={{ backgroundColor: config.background_color }}
+            >
+                <div className="flex items-baseline justify-between gap-4 mb-2">
+                    <span className="font-bold truncate" style={{ color: config.user_color }}>{goal.title}</span>
+                    <span className="font-mono text-sm shrink-0" style={{ color: config.amount_color }}>
+                        {format(goal.current)} / {format(goal.target_amount)}
+                    </span>
+                </div>
+                <div className="h-4 rounded-full bg-white/10 overflow-hidden">
+                    <motion.div
+                        className="h-full rounded-full"
+                        style={{ backgroundColor: config.amount_color }}
+                        initial={false}
+                        animate={{ width: `${Math.min(goal.percent, 100)}%` }}
+                        transition={{ type: "spring", stiffness: 60, damping: 15 }}
+                    />
+                </div>
+                <div className="text-right text-xs mt-1 opacity-70" style={{ color: config.user_color }}>
+                    {Math.floor(goal.percent)}%
+                </div>
+            </div>
+        </div>
+    );
+}
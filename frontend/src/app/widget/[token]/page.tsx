@@ -3,14 +3,20 @@
 import { useEffect, useState, useRef } from "react";
 import { useParams } from "next/navigation";
 import { AnimatePresence } from "framer-motion";
-import { TipWidget } from "@/components/TipWidget";
+import { TipWidget, WidgetTheme } from "@/components/TipWidget";
 import { franc } from "franc";
 
 type Tip = {
+    alertId?: number; // Server alert queue ID, reported back with ALERT_DONE
     sender: string;
     amount: string;
     message: string;
     asset: string;
+    usdValue?: string;
+    soundUrl?: string; // From the streamer's media tiers
+    mediaUrl?: string;
+    tts?: boolean; // Per the streamer's tip rules, absent for older servers
+    ttsAudioUrl?: string; // Rendered by the server, browser speech otherwise
     language?: string;
     actionText?: string;
     avatarUrl?: string; // Added for ENS/Custom Avatars
@@ -18,6 +24,8 @@ type Tip = {
     twitterHandle?: string; // Added for ENS Twitter
 };
 
+const POSITION_CLASSES = { top: "items-start", center: "items-center", bottom: "items-end" };
+
 export default function WidgetPage() {
     const params = useParams();
     const token = params.token as string;
@@ -32,6 +40,9 @@ export default function WidgetPage() {
     const queueRef = useRef(queue);
     useEffect(() => { queueRef.current = queue; }, [queue]);
 
+    // Socket Ref to report finished alerts to the server queue
+    const socketRef = useRef<WebSocket | null>(null);
+
     // Config State
     const [config, setConfig] = useState({
         tts_enabled: false,
@@ -40,6 +51,7 @@ export default function WidgetPage() {
         amount_color: "#22c55e",
         message_color: "#ffffff"
     });
+    const [theme, setTheme] = useState<WidgetTheme | undefined>(undefined);
 
     // Fetch Widget Config
     useEffect(() => {
@@ -54,6 +66,7 @@ export default function WidgetPage() {
                         amount_color: data.widget_amount_color || "#22c55e",
                         message_color: data.widget_message_color || "#ffffff"
                     });
+                    setTheme(data.widget_config?.theme);
                 }
             })
             .catch(console.error);
@@ -61,7 +74,10 @@ export default function WidgetPage() {
 
     // WebSocket Connection - Ingests into Queue
     useEffect(() => {
-        const wsUrl = (process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080').replace("http", "ws") + `/ws/${token}`;
+        const wsBaseUrl = (process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080').replace("http", "ws") + `/ws/${token}`;
+        // Last alert shown, persisted so a reloaded browser source catches up on missed tips
+        const lastEventKey = `widget:${token}:lastEventId`;
+        let lastEventId = Number(localStorage.getItem(lastEventKey)) || 0;
         let socket: WebSocket | null = null;
         let retryTimeout: NodeJS.Timeout;
         let isMounted = true;
@@ -69,8 +85,10 @@ export default function WidgetPage() {
         const connect = () => {
             if (!isMounted) return;
 
+            const wsUrl = lastEventId > 0 ? `${wsBaseUrl}?since=${lastEventId}` : wsBaseUrl;
             console.log("Connecting to WS...", wsUrl);
             socket = new WebSocket(wsUrl);
+            socketRef.current = socket;
 
             socket.onopen = () => {
                 console.log("Widget connected to WS");
@@ -80,12 +98,32 @@ export default function WidgetPage() {
             socket.onmessage = (event) => {
                 try {
                     const data = JSON.parse(event.data);
+                    if (data.type === "ALERT_SKIP") {
+                        // Streamer skipped the alert from the dashboard
+                        setQueue(prev => prev.filter(t => t.alertId !== data.alertId));
+                        setCurrentTip(prev => (prev && prev.alertId === data.alertId ? null : prev));
+                        return;
+                    }
+
                     if (data.type === "TIP") {
+                        // Skip alerts already shown (catch-up may overlap live events), unless replayed on purpose
+                        if (data.eventId && !data.replay) {
+                            if (data.eventId <= lastEventId) return;
+                            lastEventId = data.eventId;
+                            localStorage.setItem(lastEventKey, String(lastEventId));
+                        }
+
                         const newTip: Tip = {
+                            alertId: data.alertId,
                             sender: data.sender || "Anonymous",
                             amount: data.amount || "0",
-                            message: data.message || "",
+                            message: data.showMessage === false ? "" : (data.message || ""), // Below the message minimum
                             asset: data.asset || "ETH",
+                            usdValue: data.usdValue,
+                            soundUrl: data.showMedia === false ? undefined : data.soundUrl,
+                            mediaUrl: data.showMedia === false ? undefined : data.mediaUrl,
+                            tts: data.tts,
+                            ttsAudioUrl: data.ttsAudioUrl,
                             avatarUrl: data.avatarUrl || data.avatar_url, // Support both cases
                             backgroundUrl: data.backgroundUrl || data.background_url, // Support both cases
                             twitterHandle: data.twitterHandle || data.twitter_handle, // Support both cases
@@ -212,6 +250,9 @@ export default function WidgetPage() {
             // Only clear if BOTH conditions are met
             if (tipFinishedSpeaking && tipMinDurationPassed) {
                 console.log("Tip finished, clearing...");
+                if (currentTip.alertId && socketRef.current?.readyState === WebSocket.OPEN) {
+                    socketRef.current.send(JSON.stringify({ type: "ALERT_DONE", alertId: currentTip.alertId }));
+                }
                 // Small buffer to ensure visual smoothness
                 setTimeout(() => {
                     if (!isCleanedUp) setCurrentTip(null);
@@ -219,14 +260,36 @@ export default function WidgetPage() {
             }
         };
 
-        // 1. Minimum Duration Timer (10s)
+        // 1. Minimum Duration Timer (theme duration, 10s by default)
         setTimeout(() => {
             tipMinDurationPassed = true;
             attemptFinish();
-        }, 10000);
+        }, theme?.animation.duration_ms || 10000);
+
+        // Alert sound of the tip's media tier, or the theme's
+        const soundUrl = currentTip.soundUrl || theme?.sound.url;
+        if (soundUrl) {
+            const sound = new Audio(soundUrl);
+            sound.volume = (theme?.sound.volume ?? 50) / 100;
+            sound.play().catch(() => setInteractionNeeded(true));
+        }
 
         // 2. TTS Logic
-        if (config.tts_enabled && currentTip.message) {
+        let ttsAudio: HTMLAudioElement | null = null;
+        if (config.tts_enabled && currentTip.tts !== false && currentTip.message && currentTip.ttsAudioUrl) {
+            // Rendered by the server in the streamer's voice
+            const finishSpeaking = () => {
+                tipFinishedSpeaking = true;
+                attemptFinish();
+            };
+            ttsAudio = new Audio(currentTip.ttsAudioUrl);
+            ttsAudio.onended = finishSpeaking;
+            ttsAudio.onerror = finishSpeaking;
+            ttsAudio.play().catch(() => {
+                setInteractionNeeded(true);
+                finishSpeaking();
+            });
+        } else if (config.tts_enabled && currentTip.tts !== false && currentTip.message) {
             const targetLang = currentTip.language || 'en';
             const saysMap: Record<string, string> = {
                 'pl': 'mówi',
@@ -300,21 +363,21 @@ export default function WidgetPage() {
             attemptFinish();
         }
 
-        return () => { isCleanedUp = true; window.speechSynthesis.cancel(); };
-    }, [currentTip, config.tts_enabled]);
+        return () => { isCleanedUp = true; ttsAudio?.pause(); window.speechSynthesis.cancel(); };
+    }, [currentTip, config.tts_enabled, theme]);
 
     return (
         <div
-            className="min-h-screen bg-transparent flex items-end justify-center p-8 overflow-hidden font-sans relative"
+            className={`min-h-screen bg-transparent flex ${POSITION_CLASSES[theme?.layout.position || "bottom"]} justify-center p-8 overflow-hidden font-sans relative`}
             onClick={() => setInteractionNeeded(false)} // Any click enables checks
         >
 
 
             {/* Interaction Overlay for Audio Context */}
-            {interactionNeeded && config.tts_enabled && (
+            {interactionNeeded && (config.tts_enabled || theme?.sound.url || currentTip?.soundUrl) && (
                 <div className="fixed inset-0 bg-black/50 flex items-center justify-center z-50 cursor-pointer backdrop-blur-sm">
                     <div className="bg-black border border-white/20 px-8 py-4 rounded-full animate-pulse">
-                        <span className="text-white font-bold tracking-widest uppercase text-sm">Click anywhere to enable TTS</span>
+                        <span className="text-white font-bold tracking-widest uppercase text-sm">Click anywhere to enable audio</span>
                     </div>
                 </div>
             )}
@@ -324,6 +387,7 @@ export default function WidgetPage() {
                     <TipWidget
                         tip={currentTip}
                         config={config}
+                        theme={theme}
                         isPreview={false}
                     />
                 )}
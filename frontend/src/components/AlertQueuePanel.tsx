@@ -0,0 +1,186 @@
+"use client";
+
+import { useCallback, useEffect, useState } from "react";
+import { Pause, Play, SkipForward, Check, X, ListOrdered } from "lucide-react";
+
+type AlertItem = {
+    id: number;
+    tip_id: number;
+    status: "queued" | "approved" | "playing";
+    requires_approval: boolean;
+    sender: string;
+    message: string;
+    original_message?: string; // Before the message filter
+    amount: string;
+    asset: string;
+    usd_value?: string;
+    created_at: string;
+};
+
+type AlertQueue = {
+    paused: boolean;
+    require_approval: boolean;
+    alerts: AlertItem[];
+};
+
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+// Live view of the server side alert queue with moderation controls
+export function AlertQueuePanel() {
+    const [queue, setQueue] = useState<AlertQueue | null>(null);
+    const [error, setError] = useState("");
+
+    const request = useCallback(async (path: string, method = "GET", body?: unknown) => {
+        const token = localStorage.getItem("user_token");
+        if (!token) return null;
+
+        const res = await fetch(`${API_URL}/api/me/alerts${path}`, {
+            method,
+            headers: {
+                "Content-Type": "application/json",
+                "Authorization": `Bearer ${token}`
+            },
+            body: body ? JSON.stringify(body) : undefined
+        });
+        const data = await res.json();
+        if (!res.ok) throw new Error(data.error || "Alert queue request failed");
+        return data;
+    }, []);
+
+    const refresh = useCallback(async () => {
+        try {
+            const data = await request("");
+            if (data) setQueue(data);
+            setError("");
+        } catch (e: any) {
+            setError(e.message);
+        }
+    }, [request]);
+
+    const act = async (path: string, method = "POST", body?: unknown) => {
+        try {
+            await request(path, method, body);
+            await refresh();
+        } catch (e: any) {
+            setError(e.message);
+        }
+    };
+
+    useEffect(() => { refresh(); }, [refresh]);
+
+    // Refetch whenever the server reports a queue change (from any dashboard or overlay).
+    // The socket only listens, changes go through the REST endpoints above.
+    useEffect(() => {
+        const wsUrl = API_URL.replace("http", "ws") + "/ws/me";
+        let socket: WebSocket | null = null;
+        let retryTimeout: NodeJS.Timeout;
+        let isMounted = true;
+
+        const connect = () => {
+            if (!isMounted) return;
+            // Browsers can't send an Authorization header, the access token rides as a subprotocol
+            const token = localStorage.getItem("user_token");
+            if (!token) return;
+            socket = new WebSocket(wsUrl, ["bearer", token]);
+            socket.onmessage = (event) => {
+                try {
+                    const data = JSON.parse(event.data);
+                    if (data.type === "ALERT_QUEUE" || data.type === "ALERT_SKIP") refresh();
+                } catch (e) {
+                    console.error("WS Parse Error", e);
+                }
+            };
+            socket.onclose = () => {
+                if (isMounted) retryTimeout = setTimeout(connect, 3000);
+            };
+        };
+
+        connect();
+
+        return () => {
+            isMounted = false;
+            clearTimeout(retryTimeout);
+            socket?.close();
+        };
+    }, [refresh]);
+
+    if (!queue) return null;
+
+    return (
+        <div className="bg-zinc-900/40 backdrop-blur-xl border border-white/5 rounded-3xl p-6 shadow-2xl space-y-4">
+            <div className="flex flex-col sm:flex-row sm:items-center justify-between gap-4">
+                <h2 className="text-lg font-bold flex items-center gap-2">
+                    <ListOrdered className="text-blue-400" /> Alert Queue
+                    {queue.paused && <span className="text-xs font-semibold text-yellow-400 bg-yellow-500/10 border border-yellow-500/20 px-2 py-0.5 rounded-full">Paused</span>}
+                </h2>
+                <div className="flex items-center gap-2">
+                    <label className="flex items-center gap-2 text-sm text-zinc-400 mr-2 cursor-pointer">
+                        <input
+                            type="checkbox"
+                            checked={queue.require_approval}
+                            onChange={(e) => act("/settings", "PUT", { require_approval: e.target.checked })}
+                        />
+                        Require approval
+                    </label>
+                    <button
+                        onClick={() => act(queue.paused ? "/resume" : "/pause")}
+                        className="flex items-center gap-2 px-3 py-2 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-white transition-all font-semibold text-sm border border-zinc-700"
+                    >
+                        {queue.paused ? <Play size={16} /> : <Pause size={16} />}
+                        {queue.paused ? "Resume" : "Pause"}
+                    </button>
+                    <button
+                        onClick={() => act("/skip")}
+                        disabled={!queue.alerts.some(a => a.status === "playing")}
+                        className="flex items-center gap-2 px-3 py-2 rounded-lg bg-zinc-800 hover:bg-zinc-700 disabled:opacity-40 text-white transition-all font-semibold text-sm border border-zinc-700"
+                    >
+                        <SkipForward size={16} /> Skip
+                    </button>
+                </div>
+            </div>
+
+            {error && <p className="text-sm text-red-400">{error}</p>}
+
+            {queue.alerts.length === 0 ? (
+                <p className="text-sm text-zinc-500">No alerts waiting.</p>
+            ) : (
+                <ul className="space-y-2">
+                    {queue.alerts.map(alert => {
+                        const awaitingApproval = alert.status === "queued" && alert.requires_approval;
+                        return (
+                            <li key={alert.id} className="flex items-center justify-between gap-4 p-3 bg-black/20 rounded-xl border border-white/5">
+                                <div className="min-w-0">
+                                    <div className="text-sm font-semibold text-white truncate">
+                                        {alert.sender || "Anonymous"} · {alert.amount} {alert.asset}
+                                        {alert.usd_value && <span className="text-zinc-500 font-normal"> (${alert.usd_value})</span>}
+                                    </div>
+                                    {alert.message && <div className="text-xs text-zinc-400 truncate" title={alert.original_message ? `Original: ${alert.original_message}` : undefined}>{alert.message}</div>}
+                                </div>
+                                <div className="flex items-center gap-2 shrink-0">
+                                    <span className={`text-xs uppercase tracking-wider ${alert.status === "playing" ? "text-green-400" : awaitingApproval ? "text-yellow-400" : "text-zinc-500"}`}>
+                                        {awaitingApproval ? "needs approval" : alert.status}
+                                    </span>
+                                    {awaitingApproval && (
+                                        <>
+                                            <button onClick={() => act(`/${alert.id}/approve`)} title="Approve" className="p-1.5 rounded-lg bg-green-500/10 hover:bg-green-500/20 text-green-400 border border-green-500/20">
+                                                <Check size={14} />
+                                            </button>
+                                            <button onClick={() => act(`/${alert.id}/reject`)} title="Reject" className="p-1.5 rounded-lg bg-red-500/10 hover:bg-red-500/20 text-red-400 border border-red-500/20">
+                                                <X size={14} />
+                                            </button>
+                                        </>
+                                    )}
+                                    {!awaitingApproval && (
+                                        <button onClick={() => act(`/${alert.id}/skip`)} title="Skip" className="p-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-zinc-300 border border-zinc-700">
+                                            <SkipForward size={14} />
+                                        </button>
+                                    )}
+                                </div>
+                            </li>
+                        );
+                    })}
+                </ul>
+            )}
+        </div>
+    );
+}
@@ -0,0 +1,101 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import { MessageSquare } from "lucide-react";
+
+type ChatSettings = {
+    enabled: boolean;
+    template: string;
+    channel: string;
+    available: boolean;
+};
+
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+// Tip announcements posted to the streamer's Twitch chat by the bot
+export function ChatSettingsPanel() {
+    const [settings, setSettings] = useState<ChatSettings | null>(null);
+    const [saving, setSaving] = useState(false);
+    const [status, setStatus] = useState("");
+
+    useEffect(() => {
+        const token = localStorage.getItem("user_token");
+        if (!token) return;
+
+        fetch(`${API_URL}/api/me/chat`, { headers: { "Authorization": `Bearer ${token}` } })
+            .then(res => res.json())
+            .then(data => {
+                if (!data.error) setSettings(data);
+            })
+            .catch(console.error);
+    }, []);
+
+    const save = async () => {
+        if (!settings) return;
+        const token = localStorage.getItem("user_token");
+        if (!token) return;
+
+        setSaving(true);
+        setStatus("");
+        try {
+            const res = await fetch(`${API_URL}/api/me/chat`, {
+                method: "PUT",
+                headers: {
+                    "Content-Type": "application/json",
+                    "Authorization": `Bearer ${token}`
+                },
+                body: JSON.stringify({ enabled: settings.enabled, template: settings.template })
+            });
+            const data = await res.json();
+            if (!res.ok) throw new Error(data.error || "Failed to save chat settings");
+            setSettings(data);
+            setStatus("Chat settings saved!");
+        } catch (e: any) {
+            setStatus(e.message);
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    if (!settings || !settings.available) return null;
+
+    return (
+        <div className="bg-zinc-900/40 backdrop-blur-xl border border-white/5 rounded-3xl p-6 shadow-2xl space-y-4">
+            <h2 className="text-lg font-bold flex items-center gap-2">
+                <MessageSquare className="text-blue-400" /> Twitch Chat
+            </h2>
+
+            {settings.channel ? (
+                <label className="flex items-center gap-2 text-sm text-zinc-300">
+                    <input
+                        type="checkbox"
+                        checked={settings.enabled}
+                        onChange={(e) => setSettings({ ...settings, enabled: e.target.checked })}
+                        className="accent-blue-500"
+                    />
+                    Announce confirmed tips in #{settings.channel}
+                </label>
+            ) : (
+                <p className="text-sm text-zinc-500">Link your Twitch account to announce tips in chat.</p>
+            )}
+
+            <input
+                value={settings.template}
+                onChange={(e) => setSettings({ ...settings, template: e.target.value })}
+                className="w-full bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800 text-sm font-mono"
+            />
+            <p className="text-xs text-zinc-500">Placeholders: {"{sender}"}, {"{amount}"}, {"{asset}"}, {"{usd}"}, {"{message}"}</p>
+
+            <div className="flex items-center gap-4">
+                <button
+                    onClick={save}
+                    disabled={saving}
+                    className="px-4 py-2 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-white transition-all font-semibold text-sm border border-zinc-700"
+                >
+                    {saving ? "Saving..." : "Save Chat"}
+                </button>
+                {status && <span className="text-sm text-zinc-400">{status}</span>}
+            </div>
+        </div>
+    );
+}
@@ -0,0 +1,185 @@
+"use client";
+
+import { useCallback, useEffect, useState } from "react";
+import { Target, Plus, Trash2, Copy, Power } from "lucide-react";
+import { Widget, widgetRequest, widgetUrl } from "@/components/WidgetsPanel";
+
+type Goal = {
+    id: number;
+    title: string;
+    target_amount: string;
+    target_asset: string;
+    current: string;
+    percent: number;
+    tip_count: number;
+    start_at: string;
+    end_at?: string;
+    active: boolean;
+};
+
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+// Donation goals and the URL of their overlay
+export function GoalsPanel() {
+    const [goals, setGoals] = useState<Goal[]>([]);
+    const [title, setTitle] = useState("");
+    const [targetAmount, setTargetAmount] = useState("");
+    const [targetAsset, setTargetAsset] = useState("USD");
+    const [endAt, setEndAt] = useState("");
+    const [status, setStatus] = useState("");
+
+    const request = useCallback(async (path: string, method = "GET", body?: unknown) => {
+        const token = localStorage.getItem("user_token");
+        if (!token) return null;
+
+        const res = await fetch(`${API_URL}/api/me/goals${path}`, {
+            method,
+            headers: {
+                "Content-Type": "application/json",
+                "Authorization": `Bearer ${token}`
+            },
+            body: body ? JSON.stringify(body) : undefined
+        });
+        const data = await res.json();
+        if (!res.ok) throw new Error(data.error || "Goal request failed");
+        return data;
+    }, []);
+
+    const refresh = useCallback(async () => {
+        try {
+            const data = await request("");
+            if (data) setGoals(data.goals || []);
+        } catch (e: any) {
+            setStatus(e.message);
+        }
+    }, [request]);
+
+    useEffect(() => { refresh(); }, [refresh]);
+
+    const act = async (path: string, method: string, body?: unknown) => {
+        setStatus("");
+        try {
+            await request(path, method, body);
+            await refresh();
+            return true;
+        } catch (e: any) {
+            setStatus(e.message);
+            return false;
+        }
+    };
+
+    const create = async () => {
+        const ok = await act("", "POST", {
+            title,
+            target_amount: targetAmount,
+            target_asset: targetAsset,
+            end_at: endAt ? new Date(endAt).toISOString() : undefined
+        });
+        if (ok) {
+            setTitle("");
+            setTargetAmount("");
+            setEndAt("");
+        }
+    };
+
+    const toggle = (goal: Goal) => act(`/${goal.id}`, "PUT", {
+        title: goal.title,
+        target_amount: goal.target_amount,
+        target_asset: goal.target_asset,
+        start_at: goal.start_at,
+        end_at: goal.end_at || undefined,
+        active: !goal.active
+    });
+
+    // Each goal is shown by its own goal widget, created on first copy
+    const copyGoalUrl = async (goal: Goal) => {
+        setStatus("");
+        try {
+            const data = await widgetRequest("");
+            let widget: Widget | undefined = (data?.widgets || []).find((w: Widget) => w.type === "goal" && w.config.goal_id === goal.id);
+            if (!widget) {
+                widget = await widgetRequest("", "POST", { type: "goal", name: goal.title, config: { goal_id: goal.id } });
+            }
+            if (widget) navigator.clipboard.writeText(widgetUrl(widget));
+        } catch (e: any) {
+            setStatus(e.message);
+        }
+    };
+
+    return (
+        <div className="bg-zinc-900/40 backdrop-blur-xl border border-white/5 rounded-3xl p-6 shadow-2xl space-y-4">
+            <h2 className="text-lg font-bold flex items-center gap-2">
+                <Target className="text-blue-400" /> Goals
+            </h2>
+
+            <div className="flex flex-wrap items-center gap-2 text-sm">
+                <input
+                    value={title}
+                    onChange={(e) => setTitle(e.target.value)}
+                    placeholder="New microphone"
+                    className="flex-1 min-w-40 bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800"
+                />
+                <input
+                    value={targetAmount}
+                    onChange={(e) => setTargetAmount(e.target.value)}
+                    placeholder="100"
+                    inputMode="decimal"
+                    className="w-24 bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800"
+                />
+                <input
+                    value={targetAsset}
+                    onChange={(e) => setTargetAsset(e.target.value.toUpperCase())}
+                    placeholder="USD"
+                    className="w-20 bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800 uppercase"
+                />
+                <input
+                    type="datetime-local"
+                    value={endAt}
+                    onChange={(e) => setEndAt(e.target.value)}
+                    title="Ends (optional)"
+                    className="bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800"
+                />
+                <button
+                    onClick={create}
+                    disabled={!title || !targetAmount}
+                    className="flex items-center gap-1 px-3 py-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 disabled:opacity-40 text-white font-semibold border border-zinc-700"
+                >
+                    <Plus size={14} /> Add Goal
+                </button>
+            </div>
+
+            {status && <p className="text-sm text-red-400">{status}</p>}
+
+            {goals.length === 0 ? (
+                <p className="text-sm text-zinc-500">No goals yet.</p>
+            ) : (
+                <ul className="space-y-2">
+                    {goals.map(goal => (
+                        <li key={goal.id} className="p-3 bg-black/20 rounded-xl border border-white/5 space-y-2">
+                            <div className="flex items-center justify-between gap-4">
+                                <div className="min-w-0 text-sm">
+                                    <span className={`font-semibold ${goal.active ? "text-white" : "text-zinc-500"}`}>{goal.title}</span>
+                                    <span className="text-zinc-400"> · {goal.current} / {goal.target_amount} {goal.target_asset} ({goal.tip_count} tips)</span>
+                                </div>
+                                <div className="flex items-center gap-2 shrink-0">
+                                    <button onClick={() => copyGoalUrl(goal)} title="Copy widget URL" className="p-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-zinc-300 border border-zinc-700">
+                                        <Copy size={14} />
+                                    </button>
+                                    <button onClick={() => toggle(goal)} title={goal.active ? "Deactivate" : "Activate"} className={`p-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 border border-zinc-700 ${goal.active ? "text-green-400" : "text-zinc-500"}`}>
+                                        <Power size={14} />
+                                    </button>
+                                    <button onClick={() => act(`/${goal.id}`, "DELETE")} title="Delete" className="p-1.5 rounded-lg bg-red-500/10 hover:bg-red-500/20 text-red-400 border border-red-500/20">
+                                        <Trash2 size={14} />
+                                    </button>
+                                </div>
+                            </div>
+                            <div className="h-2 rounded-full bg-white/10 overflow-hidden">
+                                <div className="h-full bg-green-500 rounded-full" style={{ width: `${Math.min(goal.percent, 100)}%` }} />
+                            </div>
+                        </li>
+                    ))}
+                </ul>
+            )}
+        </div>
+    );
+}
@@ -20,6 +20,15 @@ import { TipWidget } from "./TipWidget";
 // Constants
 const PRESET_AMOUNTS = ["0.001", "0.01", "0.05", "0.1"];
 
+// Native assets are claimed by symbol, tokens by contract / mint / coin type
+// since their symbols can be spoofed. The server names the tokens it knows.
+const NATIVE_TOKEN_ADDRESSES = ["0x0000000000000000000000000000000000000000", "11111111111111111111111111111111", "0x2::sui::SUI"];
+
+function tipAsset(token: Token | null, nativeSymbol: string) {
+    if (!token || NATIVE_TOKEN_ADDRESSES.includes(token.address)) return token?.symbol || nativeSymbol;
+    return token.address;
+}
+
 interface LifiTipProps {
     recipientAddress: string;
     onSuccess: (data: {
@@ -361,7 +370,7 @@ export function LifiTip({ recipientAddress, onSuccess, onStatus, preferredChainI
                 amount: amount,
                 message: message,
                 senderName: useEnsNameFlag && ensName ? ensName : (senderName || "Anonymous"),
-                asset: selectedAsset?.symbol || "SOL",
+                asset: tipAsset(selectedAsset, "SOL"),
                 sourceChain: "solana",
                 destChain: targetChainId.toString(),
                 sourceAddress: solanaPublicKey.toBase58(),
@@ -434,7 +443,7 @@ export function LifiTip({ recipientAddress, onSuccess, onStatus, preferredChainI
                 useEnsNameFlag && ensName
                     ? ensName
                     : senderName || "Anonymous",
-            asset: selectedAsset?.symbol || "SUI",
+            asset: tipAsset(selectedAsset, "SUI"),
             sourceChain: String(selectedChainId),
             destChain: String(selectedChainId),
             sourceAddress: currentAddress || "",
@@ -530,7 +539,7 @@ export function LifiTip({ recipientAddress, onSuccess, onStatus, preferredChainI
             amount: amount,
             message: message,
             senderName: useEnsNameFlag && ensName ? ensName : (senderName || "Anonymous"),
-            asset: selectedAsset?.symbol || "ETH",
+            asset: tipAsset(selectedAsset, "ETH"),
             sourceChain: String(selectedChainId),
             destChain: String(selectedChainId),
             sourceAddress: currentAddress || "",
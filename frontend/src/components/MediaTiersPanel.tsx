@@ -0,0 +1,203 @@
+"use client";
+
+import { useCallback, useEffect, useRef, useState } from "react";
+import { Music, Plus, Trash2, Upload, Save } from "lucide-react";
+
+type Media = {
+    id: number;
+    kind: "audio" | "animation";
+    name: string;
+    url: string;
+    duration_ms: number;
+};
+
+type MediaTier = {
+    min_usd: string;
+    max_usd: string;
+    sound_url: string;
+    media_url: string;
+};
+
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+// Alert sounds and animations per USD amount tier
+export function MediaTiersPanel() {
+    const [media, setMedia] = useState<Media[]>([]);
+    const [tiers, setTiers] = useState<MediaTier[]>([]);
+    const [status, setStatus] = useState("");
+    const [busy, setBusy] = useState(false);
+    const fileRef = useRef<HTMLInputElement>(null);
+
+    const request = useCallback(async (path: string, init: RequestInit = {}) => {
+        const token = localStorage.getItem("user_token");
+        if (!token) return null;
+
+        const res = await fetch(`${API_URL}/api/me/${path}`, {
+            ...init,
+            headers: { ...init.headers, "Authorization": `Bearer ${token}` }
+        });
+        const data = await res.json();
+        if (!res.ok) throw new Error(data.error || "Media request failed");
+        return data;
+    }, []);
+
+    useEffect(() => {
+        Promise.all([request("media"), request("media-tiers")])
+            .then(([m, t]) => {
+                if (m) setMedia(m.media || []);
+                if (t) setTiers(t.tiers || []);
+            })
+            .catch((e) => setStatus(e.message));
+    }, [request]);
+
+    const upload = async (file?: File) => {
+        if (!file) return;
+        setBusy(true);
+        setStatus("");
+        try {
+            const form = new FormData();
+            form.append("file", file);
+            const item = await request("media", { method: "POST", body: form });
+            if (item) setMedia(prev => [item, ...prev]);
+            setStatus("Uploaded!");
+        } catch (e: any) {
+            setStatus(e.message);
+        } finally {
+            setBusy(false);
+            if (fileRef.current) fileRef.current.value = "";
+        }
+    };
+
+    const save = async () => {
+        setBusy(true);
+        setStatus("");
+        try {
+            const data = await request("media-tiers", {
+                method: "PUT",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify({ tiers })
+            });
+            if (data) setTiers(data.tiers || []);
+            setStatus("Tiers saved!");
+        } catch (e: any) {
+            setStatus(e.message);
+        } finally {
+            setBusy(false);
+        }
+    };
+
+    const remove = async (item: Media) => {
+        if (!confirm(`Delete ${item.name}? Tiers using it will stop playing it.`)) return;
+        setBusy(true);
+        setStatus("");
+        try {
+            await request(`media/${item.id}`, { method: "DELETE" });
+            setMedia(prev => prev.filter(m => m.id !== item.id));
+            const t = await request("media-tiers");
+            if (t) setTiers(t.tiers || []);
+            setStatus("Deleted!");
+        } catch (e: any) {
+            setStatus(e.message);
+        } finally {
+            setBusy(false);
+        }
+    };
+
+    const updateTier = (index: number, patch: Partial<MediaTier>) =>
+        setTiers(prev => prev.map((t, i) => (i === index ? { ...t, ...patch } : t)));
+
+    const sounds = media.filter(m => m.kind === "audio");
+    const animations = media.filter(m => m.kind === "animation");
+
+    return (
+        <div className="bg-zinc-900/40 backdrop-blur-xl border border-white/5 rounded-3xl p-6 shadow-2xl space-y-4">
+            <div className="flex items-center justify-between">
+                <h2 className="text-lg font-bold flex items-center gap-2">
+                    <Music className="text-blue-400" /> Alert Media
+                </h2>
+                <button
+                    onClick={() => fileRef.current?.click()}
+                    disabled={busy}
+                    className="flex items-center gap-1 px-3 py-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 disabled:opacity-40 text-white text-sm font-semibold border border-zinc-700"
+                >
+                    <Upload size={14} /> Upload
+                </button>
+                <input ref={fileRef} type="file" accept=".mp3,.ogg,.wav,.gif,.webp" className="hidden" onChange={(e) => upload(e.target.files?.[0])} />
+            </div>
+            <p className="text-xs text-zinc-500">Sounds: mp3, ogg or wav up to 2MB. Animations: gif or webp up to 5MB. At most 20 seconds each, 50 uploads in total.</p>
+
+            {media.length > 0 && (
+                <ul className="flex flex-wrap gap-2">
+                    {media.map(m => (
+                        <li key={m.id} className="flex items-center gap-1 pl-3 pr-1 py-1 rounded-lg bg-zinc-950 border border-zinc-800 text-xs">
+                            <span className="max-w-40 truncate">{m.name}</span>
+                            <button onClick={() => remove(m)} disabled={busy} title="Delete" className="p-1 rounded text-red-400 hover:bg-red-500/20 disabled:opacity-40">
+                                <Trash2 size={12} />
+                            </button>
+                        </li>
+                    ))}
+                </ul>
+            )}
+
+            <ul className="space-y-2">
+                {tiers.map((tier, i) => (
+                    <li key={i} className="flex flex-wrap items-center gap-2 text-sm">
+                        <span className="text-zinc-500">$</span>
+                        <input
+                            value={tier.min_usd}
+                            onChange={(e) => updateTier(i, { min_usd: e.target.value })}
+                            placeholder="0"
+                            inputMode="decimal"
+                            className="w-20 bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800"
+                        />
+                        <span className="text-zinc-500">to $</span>
+                        <input
+                            value={tier.max_usd}
+                            onChange={(e) => updateTier(i, { max_usd: e.target.value })}
+                            placeholder="∞"
+                            inputMode="decimal"
+                            className="w-20 bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800"
+                        />
+                        <select
+                            value={tier.sound_url}
+                            onChange={(e) => updateTier(i, { sound_url: e.target.value })}
+                            className="flex-1 min-w-32 bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800"
+                        >
+                            <option value="">No sound</option>
+                            {sounds.map(m => <option key={m.id} value={m.url}>{m.name}</option>)}
+                        </select>
+                        <select
+                            value={tier.media_url}
+                            onChange={(e) => updateTier(i, { media_url: e.target.value })}
+                            className="flex-1 min-w-32 bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800"
+                        >
+                            <option value="">No animation</option>
+                            {animations.map(m => <option key={m.id} value={m.url}>{m.name}</option>)}
+                        </select>
+                        <button onClick={() => setTiers(prev => prev.filter((_, j) => j !== i))} title="Remove" className="p-1.5 rounded-lg bg-red-500/10 hover:bg-red-500/20 text-red-400 border border-red-500/20">
+                            <Trash2 size={14} />
+                        </button>
+                    </li>
+                ))}
+            </ul>
+
+            <div className="flex items-center gap-2">
+                <button
+                    onClick={() => setTiers(prev => [...prev, { min_usd: "", max_usd: "", sound_url: "", media_url: "" }])}
+                    className="flex items-center gap-1 px-3 py-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-white text-sm font-semibold border border-zinc-700"
+                >
+                    <Plus size={14} /> Add Tier
+                </button>
+                <button
+                    onClick={save}
+                    disabled={busy}
+                    className="flex items-center gap-1 px-3 py-1.5 rounded-lg bg-blue-600 hover:bg-blue-500 disabled:opacity-40 text-white text-sm font-semibold"
+                >
+                    <Save size={14} /> Save Tiers
+                </button>
+            </div>
+
+            {status && <p className="text-sm text-zinc-400">{status}</p>}
+        </div>
+    );
+}
@@ -0,0 +1,127 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import { ShieldCheck } from "lucide-react";
+
+type MessageFilter = {
+    banned_words: string[];
+    strip_links: boolean;
+    max_length: number;
+    collapse_repeats: boolean;
+    action: "replace" | "reject";
+};
+
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+// Streamer settings for filtering tip messages before they are shown and read by TTS
+export function MessageFilterPanel() {
+    const [filter, setFilter] = useState<MessageFilter | null>(null);
+    const [bannedWords, setBannedWords] = useState("");
+    const [saving, setSaving] = useState(false);
+    const [status, setStatus] = useState("");
+
+    useEffect(() => {
+        const token = localStorage.getItem("user_token");
+        if (!token) return;
+
+        fetch(`${API_URL}/api/me/message-filter`, { headers: { "Authorization": `Bearer ${token}` } })
+            .then(res => res.json())
+            .then(data => {
+                if (data.error) return;
+                setFilter({ ...data, action: data.action || "replace", banned_words: data.banned_words || [] });
+                setBannedWords((data.banned_words || []).join("\n"));
+            })
+            .catch(console.error);
+    }, []);
+
+    const save = async () => {
+        if (!filter) return;
+        const token = localStorage.getItem("user_token");
+        if (!token) return;
+
+        setSaving(true);
+        setStatus("");
+        try {
+            const res = await fetch(`${API_URL}/api/me/message-filter`, {
+                method: "PUT",
+                headers: {
+                    "Content-Type": "application/json",
+                    "Authorization": `Bearer ${token}`
+                },
+                body: JSON.stringify({ ...filter, banned_words: bannedWords.split(/[\n,]/).map(w => w.trim()).filter(Boolean) })
+            });
+            const data = await res.json();
+            if (!res.ok) throw new Error(data.error || "Failed to save message filter");
+            setStatus("Message filter saved!");
+        } catch (e: any) {
+            setStatus(e.message);
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    if (!filter) return null;
+
+    return (
+        <div className="bg-zinc-900/40 backdrop-blur-xl border border-white/5 rounded-3xl p-6 shadow-2xl space-y-4">
+            <h2 className="text-lg font-bold flex items-center gap-2">
+                <ShieldCheck className="text-blue-400" /> Message Filter
+            </h2>
+
+            <div className="space-y-2">
+                <label className="text-sm font-bold text-zinc-400 uppercase tracking-wider">Banned words</label>
+                <textarea
+                    value={bannedWords}
+                    onChange={(e) => setBannedWords(e.target.value)}
+                    rows={4}
+                    placeholder={"One per line, * matches any letters (e.g. scam*)"}
+                    className="w-full bg-zinc-950 p-3 rounded-xl border border-zinc-800 text-sm font-mono text-zinc-300 outline-none"
+                />
+            </div>
+
+            <div className="grid grid-cols-1 sm:grid-cols-2 gap-4 text-sm text-zinc-300">
+                <label className="flex items-center gap-2 cursor-pointer">
+                    <input type="checkbox" checked={filter.strip_links} onChange={(e) => setFilter({ ...filter, strip_links: e.target.checked })} />
+                    Remove links
+                </label>
+                <label className="flex items-center gap-2 cursor-pointer">
+                    <input type="checkbox" checked={filter.collapse_repeats} onChange={(e) => setFilter({ ...filter, collapse_repeats: e.target.checked })} />
+                    Collapse repeated characters
+                </label>
+                <label className="flex items-center gap-2">
+                    Max length
+                    <input
+                        type="number"
+                        min={0}
+                        value={filter.max_length}
+                        onChange={(e) => setFilter({ ...filter, max_length: Math.max(0, Number(e.target.value) || 0) })}
+                        className="w-20 bg-zinc-950 px-2 py-1 rounded-lg border border-zinc-800"
+                    />
+                    <span className="text-zinc-500">(0 = no limit)</span>
+                </label>
+                <label className="flex items-center gap-2">
+                    When matched
+                    <select
+                        value={filter.action}
+                        onChange={(e) => setFilter({ ...filter, action: e.target.value as MessageFilter["action"] })}
+                        className="bg-zinc-950 px-2 py-1 rounded-lg border border-zinc-800"
+                    >
+                        <option value="replace">Replace with ***</option>
+                        <option value="reject">Reject the tip</option>
+                    </select>
+                </label>
+            </div>
+
+            <div className="flex items-center gap-4">
+                <button
+                    onClick={save}
+                    disabled={saving}
+                    className="px-4 py-2 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-white transition-all font-semibold text-sm border border-zinc-700"
+                >
+                    {saving ? "Saving..." : "Save Filter"}
+                </button>
+                {status && <span className="text-sm text-zinc-400">{status}</span>}
+            </div>
+        </div>
+    );
+}
@@ -4,6 +4,7 @@ import { useState, useEffect } from "react";
 import Link from "next/link";
 import { usePathname, useRouter } from "next/navigation";
 import { User, Wallet, LayoutGrid, DollarSign, LogOut, ExternalLink, Menu, X, ChevronRight, ArrowLeft } from "lucide-react";
+import { logout } from "@/config/session";
 
 export function ProfileMenu() {
     const [isOpen, setIsOpen] = useState(false);
@@ -30,8 +31,8 @@ export function ProfileMenu() {
         }
     }, [pathname]);
 
-    const handleLogout = () => {
-        localStorage.removeItem("user_token");
+    const handleLogout = async () => {
+        await logout();
         setProfile(null);
         setIsOpen(false);
         router.push("/");
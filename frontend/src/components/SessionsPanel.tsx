@@ -0,0 +1,133 @@
+"use client";
+
+import { useCallback, useEffect, useState } from "react";
+import { useRouter } from "next/navigation";
+import { MonitorSmartphone, LogOut } from "lucide-react";
+import { clearSession } from "@/config/session";
+
+type Session = {
+    id: number;
+    ip: string;
+    user_agent: string;
+    current: boolean;
+    created_at: string;
+    last_seen_at: string;
+    expires_at: string;
+};
+
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+// A short "Browser on OS" name for a user agent
+function describeDevice(userAgent: string) {
+    if (!userAgent) return "Unknown device";
+    const browser = /Edg\//.test(userAgent) ? "Edge"
+        : /OPR\//.test(userAgent) ? "Opera"
+        : /Firefox\//.test(userAgent) ? "Firefox"
+        : /Chrome\//.test(userAgent) ? "Chrome"
+        : /Safari\//.test(userAgent) ? "Safari"
+        : null;
+    const os = /Windows/.test(userAgent) ? "Windows"
+        : /Android/.test(userAgent) ? "Android"
+        : /iPhone|iPad/.test(userAgent) ? "iOS"
+        : /Mac OS X/.test(userAgent) ? "macOS"
+        : /Linux/.test(userAgent) ? "Linux"
+        : null;
+    if (browser && os) return `${browser} on ${os}`;
+    return browser || os || userAgent.slice(0, 40);
+}
+
+// Signed in devices, with sign out for each or all the others
+export function SessionsPanel() {
+    const router = useRouter();
+    const [sessions, setSessions] = useState<Session[]>([]);
+    const [status, setStatus] = useState("");
+
+    const request = useCallback(async (path: string, method = "GET") => {
+        const token = localStorage.getItem("user_token");
+        if (!token) return null;
+
+        const res = await fetch(`${API_URL}/api/me/sessions${path}`, {
+            method,
+            headers: { "Authorization": `Bearer ${token}` }
+        });
+        const data = await res.json();
+        if (!res.ok) throw new Error(data.error || "Session request failed");
+        return data;
+    }, []);
+
+    const refresh = useCallback(async () => {
+        try {
+            const data = await request("");
+            if (data) setSessions(data.sessions || []);
+        } catch (e: any) {
+            setStatus(e.message);
+        }
+    }, [request]);
+
+    useEffect(() => { refresh(); }, [refresh]);
+
+    const revoke = async (session: Session) => {
+        setStatus("");
+        try {
+            await request(`/${session.id}`, "DELETE");
+            if (session.current) {
+                clearSession();
+                router.push("/auth");
+                return;
+            }
+            await refresh();
+        } catch (e: any) {
+            setStatus(e.message);
+        }
+    };
+
+    const revokeOthers = async () => {
+        setStatus("");
+        try {
+            const data = await request("/revoke-others", "POST");
+            if (data) setStatus(`Signed out ${data.revoked} other ${data.revoked === 1 ? "session" : "sessions"}`);
+            await refresh();
+        } catch (e: any) {
+            setStatus(e.message);
+        }
+    };
+
+    return (
+        <div className="bg-zinc-900/40 backdrop-blur-xl border border-white/5 rounded-3xl p-6 shadow-2xl space-y-4">
+            <div className="flex items-center justify-between gap-4">
+                <h2 className="text-xl font-bold flex items-center gap-2">
+                    <MonitorSmartphone className="text-blue-400" size={20} /> Active Sessions
+                </h2>
+                {sessions.some(s => !s.current) && (
+                    <button
+                        onClick={revokeOthers}
+                        className="flex items-center gap-1 px-3 py-1.5 rounded-lg bg-red-500/10 hover:bg-red-500/20 text-red-400 text-sm font-semibold border border-red-500/20"
+                    >
+                        <LogOut size={14} /> Sign out other sessions
+                    </button>
+                )}
+            </div>
+
+            {status && <p className="text-sm text-zinc-400">{status}</p>}
+
+            <ul className="space-y-2">
+                {sessions.map(session => (
+                    <li key={session.id} className="flex items-center justify-between gap-4 p-3 bg-black/20 rounded-xl border border-white/5">
+                        <div className="min-w-0 text-sm">
+                            <p className="truncate text-white" title={session.user_agent}>
+                                {describeDevice(session.user_agent)}
+                                {session.current && <span className="ml-2 text-xs font-semibold text-green-400 bg-green-500/10 border border-green-500/20 px-2 py-0.5 rounded-full">This device</span>}
+                            </p>
+                            <p className="truncate text-xs text-zinc-500">
+                                {session.ip || "Unknown IP"} · Signed in {new Date(session.created_at).toLocaleDateString()} · Last active {new Date(session.last_seen_at).toLocaleString()}
+                            </p>
+                        </div>
+                        <button onClick={() => revoke(session)} title="Sign out" className="shrink-0 p-1.5 rounded-lg bg-red-500/10 hover:bg-red-500/20 text-red-400 border border-red-500/20">
+                            <LogOut size={14} />
+                        </button>
+                    </li>
+                ))}
+            </ul>
+        </div>
+    );
+}
@@ -0,0 +1,117 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import { Volume2 } from "lucide-react";
+
+type TTSSettings = {
+    voice: string;
+    language: string;
+    rate: number;
+    server_tts: boolean;
+};
+
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+// Voice of the messages the backend renders to audio
+export function TTSSettingsPanel() {
+    const [settings, setSettings] = useState<TTSSettings | null>(null);
+    const [saving, setSaving] = useState(false);
+    const [status, setStatus] = useState("");
+
+    useEffect(() => {
+        const token = localStorage.getItem("user_token");
+        if (!token) return;
+
+        fetch(`${API_URL}/api/me/tts`, { headers: { "Authorization": `Bearer ${token}` } })
+            .then(res => res.json())
+            .then(data => {
+                if (!data.error) setSettings(data);
+            })
+            .catch(console.error);
+    }, []);
+
+    const save = async () => {
+        if (!settings) return;
+        const token = localStorage.getItem("user_token");
+        if (!token) return;
+
+        setSaving(true);
+        setStatus("");
+        try {
+            const res = await fetch(`${API_URL}/api/me/tts`, {
+                method: "PUT",
+                headers: {
+                    "Content-Type": "application/json",
+                    "Authorization": `Bearer ${token}`
+                },
+                body: JSON.stringify(settings)
+            });
+            const data = await res.json();
+            if (!res.ok) throw new Error(data.error || "Failed to save TTS settings");
+            setSettings(data);
+            setStatus("Voice saved!");
+        } catch (e: any) {
+            setStatus(e.message);
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    if (!settings) return null;
+
+    return (
+        <div className="bg-zinc-900/40 backdrop-blur-xl border border-white/5 rounded-3xl p-6 shadow-2xl space-y-4">
+            <h2 className="text-lg font-bold flex items-center gap-2">
+                <Volume2 className="text-blue-400" /> TTS Voice
+            </h2>
+
+            {!settings.server_tts && (
+                <p className="text-xs text-zinc-500">Server TTS is not enabled, messages are read by the browser source.</p>
+            )}
+
+            <div className="grid grid-cols-1 sm:grid-cols-3 gap-4 text-sm text-zinc-300">
+                <label className="flex flex-col gap-1">
+                    Language
+                    <input
+                        value={settings.language}
+                        placeholder="en"
+                        onChange={(e) => setSettings({ ...settings, language: e.target.value })}
+                        className="bg-zinc-950 px-2 py-1 rounded-lg border border-zinc-800"
+                    />
+                </label>
+                <label className="flex flex-col gap-1">
+                    Voice
+                    <input
+                        value={settings.voice}
+                        placeholder="Language default"
+                        onChange={(e) => setSettings({ ...settings, voice: e.target.value })}
+                        className="bg-zinc-950 px-2 py-1 rounded-lg border border-zinc-800"
+                    />
+                </label>
+                <label className="flex flex-col gap-1">
+                    Rate ({settings.rate} wpm)
+                    <input
+                        type="range"
+                        min={80}
+                        max={400}
+                        step={5}
+                        value={settings.rate}
+                        onChange={(e) => setSettings({ ...settings, rate: Number(e.target.value) })}
+                        className="accent-blue-500"
+                    />
+                </label>
+            </div>
+
+            <div className="flex items-center gap-4">
+                <button
+                    onClick={save}
+                    disabled={saving}
+                    className="px-4 py-2 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-white transition-all font-semibold text-sm border border-zinc-700"
+                >
+                    {saving ? "Saving..." : "Save Voice"}
+                </button>
+                {status && <span className="text-sm text-zinc-400">{status}</span>}
+            </div>
+        </div>
+    );
+}
@@ -0,0 +1,129 @@
+"use client";
+
+import { useCallback, useEffect, useRef, useState } from "react";
+import { Palette, Download, Upload } from "lucide-react";
+import { WidgetTheme } from "@/components/TipWidget";
+import { Widget, widgetRequest } from "@/components/WidgetsPanel";
+
+type ThemePreset = {
+    id: string;
+    name: string;
+    theme: WidgetTheme;
+};
+
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+// Theme of an alert widget: apply a preset, or export and import it as JSON
+export function ThemePanel() {
+    const [widgets, setWidgets] = useState<Widget[]>([]);
+    const [widgetId, setWidgetId] = useState<number>(0);
+    const [presets, setPresets] = useState<ThemePreset[]>([]);
+    const [status, setStatus] = useState("");
+    const fileRef = useRef<HTMLInputElement>(null);
+
+    const refresh = useCallback(async () => {
+        try {
+            const data = await widgetRequest("");
+            const alerts = (data?.widgets || []).filter((w: Widget) => w.type === "alert");
+            setWidgets(alerts);
+            setWidgetId(id => id || alerts[0]?.id || 0);
+        } catch (e: any) {
+            setStatus(e.message);
+        }
+    }, []);
+
+    useEffect(() => {
+        refresh();
+        fetch(`${API_URL}/api/widget-themes/presets`)
+            .then(res => res.json())
+            .then(data => setPresets(data.presets || []))
+            .catch(console.error);
+    }, [refresh]);
+
+    // The theme endpoint takes the exported JSON as is
+    const importTheme = async (json: string, done: string) => {
+        setStatus("");
+        const token = localStorage.getItem("user_token");
+        if (!token || !widgetId) return;
+
+        const res = await fetch(`${API_URL}/api/me/widgets/${widgetId}/theme`, {
+            method: "PUT",
+            headers: {
+                "Content-Type": "application/json",
+                "Authorization": `Bearer ${token}`
+            },
+            body: json
+        });
+        const data = await res.json();
+        if (!res.ok) {
+            setStatus(data.error || "Failed to import theme");
+            return;
+        }
+        await refresh();
+        setStatus(done);
+    };
+
+    const exportTheme = () => {
+        const widget = widgets.find(w => w.id === widgetId);
+        if (!widget?.config.theme) return;
+
+        const blob = new Blob([JSON.stringify(widget.config.theme, null, 2)], { type: "application/json" });
+        const link = document.createElement("a");
+        link.href = URL.createObjectURL(blob);
+        link.download = `${widget.name.toLowerCase().replace(/[^a-z0-9-]+/g, "-")}-theme.json`;
+        link.click();
+        URL.revokeObjectURL(link.href);
+    };
+
+    const onFile = async (file?: File) => {
+        if (!file) return;
+        await importTheme(await file.text(), "Theme imported!");
+        if (fileRef.current) fileRef.current.value = "";
+    };
+
+    return (
+        <div className="bg-zinc-900/40 backdrop-blur-xl border border-white/5 rounded-3xl p-6 shadow-2xl space-y-4">
+            <h2 className="text-lg font-bold flex items-center gap-2">
+                <Palette className="text-blue-400" /> Theme
+            </h2>
+
+            <div className="flex flex-wrap items-center gap-2 text-sm">
+                <select
+                    value={widgetId}
+                    onChange={(e) => setWidgetId(Number(e.target.value))}
+                    className="bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800"
+                >
+                    {widgets.map(w => <option key={w.id} value={w.id}>{w.name}</option>)}
+                </select>
+                <select
+                    value=""
+                    onChange={(e) => {
+                        const preset = presets.find(p => p.id === e.target.value);
+                        if (preset) importTheme(JSON.stringify(preset.theme), `${preset.name} theme applied!`);
+                    }}
+                    className="bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800"
+                >
+                    <option value="" disabled>Apply preset...</option>
+                    {presets.map(p => <option key={p.id} value={p.id}>{p.name}</option>)}
+                </select>
+                <button
+                    onClick={exportTheme}
+                    disabled={!widgetId}
+                    className="flex items-center gap-1 px-3 py-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 disabled:opacity-40 text-white font-semibold border border-zinc-700"
+                >
+                    <Download size={14} /> Export
+                </button>
+                <button
+                    onClick={() => fileRef.current?.click()}
+                    disabled={!widgetId}
+                    className="flex items-center gap-1 px-3 py-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 disabled:opacity-40 text-white font-semibold border border-zinc-700"
+                >
+                    <Upload size={14} /> Import
+                </button>
+                <input ref={fileRef} type="file" accept="application/json,.json" className="hidden" onChange={(e) => onFile(e.target.files?.[0])} />
+            </div>
+
+            {status && <p className="text-sm text-zinc-400 break-words">{status}</p>}
+        </div>
+    );
+}
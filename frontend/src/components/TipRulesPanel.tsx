@@ -0,0 +1,142 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import { Coins, Plus, Trash2 } from "lucide-react";
+
+type TipRules = {
+    min_amounts: Record<string, string>;
+    min_usd: string;
+    message_min_usd: string;
+    tts_min_usd: string;
+    media_min_usd: string;
+};
+
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+const USD_FIELDS: { key: keyof Omit<TipRules, "min_amounts">; label: string }[] = [
+    { key: "min_usd", label: "Minimum tip" },
+    { key: "message_min_usd", label: "Show message from" },
+    { key: "tts_min_usd", label: "Read message (TTS) from" },
+    { key: "media_min_usd", label: "Show media from" },
+];
+
+// Streamer minimums for tips and for what the overlay shows per tip
+export function TipRulesPanel() {
+    const [rules, setRules] = useState<TipRules | null>(null);
+    const [amounts, setAmounts] = useState<{ asset: string; amount: string }[]>([]);
+    const [saving, setSaving] = useState(false);
+    const [status, setStatus] = useState("");
+
+    useEffect(() => {
+        const token = localStorage.getItem("user_token");
+        if (!token) return;
+
+        fetch(`${API_URL}/api/me/tip-rules`, { headers: { "Authorization": `Bearer ${token}` } })
+            .then(res => res.json())
+            .then(data => {
+                if (data.error) return;
+                setRules(data);
+                setAmounts(Object.entries(data.min_amounts || {}).map(([asset, amount]) => ({ asset, amount: amount as string })));
+            })
+            .catch(console.error);
+    }, []);
+
+    const save = async () => {
+        if (!rules) return;
+        const token = localStorage.getItem("user_token");
+        if (!token) return;
+
+        setSaving(true);
+        setStatus("");
+        try {
+            const min_amounts = Object.fromEntries(
+                amounts.filter(a => a.asset.trim() && a.amount.trim()).map(a => [a.asset.trim().toUpperCase(), a.amount.trim()])
+            );
+            const res = await fetch(`${API_URL}/api/me/tip-rules`, {
+                method: "PUT",
+                headers: {
+                    "Content-Type": "application/json",
+                    "Authorization": `Bearer ${token}`
+                },
+                body: JSON.stringify({ ...rules, min_amounts })
+            });
+            const data = await res.json();
+            if (!res.ok) throw new Error(data.error || "Failed to save tip rules");
+            setStatus("Tip rules saved!");
+        } catch (e: any) {
+            setStatus(e.message);
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    if (!rules) return null;
+
+    return (
+        <div className="bg-zinc-900/40 backdrop-blur-xl border border-white/5 rounded-3xl p-6 shadow-2xl space-y-4">
+            <h2 className="text-lg font-bold flex items-center gap-2">
+                <Coins className="text-blue-400" /> Tip Rules
+            </h2>
+
+            <div className="grid grid-cols-1 sm:grid-cols-2 gap-4 text-sm text-zinc-300">
+                {USD_FIELDS.map(({ key, label }) => (
+                    <label key={key} className="flex items-center justify-between gap-2">
+                        {label}
+                        <span className="flex items-center gap-1">
+                            $
+                            <input
+                                type="text"
+                                inputMode="decimal"
+                                value={rules[key]}
+                                placeholder="none"
+                                onChange={(e) => setRules({ ...rules, [key]: e.target.value })}
+                                className="w-24 bg-zinc-950 px-2 py-1 rounded-lg border border-zinc-800"
+                            />
+                        </span>
+                    </label>
+                ))}
+            </div>
+            <p className="text-xs text-zinc-500">USD values use the price at confirmation. Leave empty for no minimum.</p>
+
+            <div className="space-y-2">
+                <label className="text-sm font-bold text-zinc-400 uppercase tracking-wider">Minimum per asset</label>
+                {amounts.map((row, i) => (
+                    <div key={i} className="flex items-center gap-2">
+                        <input
+                            value={row.amount}
+                            placeholder="0.001"
+                            onChange={(e) => setAmounts(amounts.map((a, j) => j === i ? { ...a, amount: e.target.value } : a))}
+                            className="w-32 bg-zinc-950 px-2 py-1 rounded-lg border border-zinc-800 text-sm"
+                        />
+                        <input
+                            value={row.asset}
+                            placeholder="ETH"
+                            onChange={(e) => setAmounts(amounts.map((a, j) => j === i ? { ...a, asset: e.target.value } : a))}
+                            className="w-24 bg-zinc-950 px-2 py-1 rounded-lg border border-zinc-800 text-sm uppercase"
+                        />
+                        <button onClick={() => setAmounts(amounts.filter((_, j) => j !== i))} title="Remove" className="p-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-zinc-300 border border-zinc-700">
+                            <Trash2 size={14} />
+                        </button>
+                    </div>
+                ))}
+                <button
+                    onClick={() => setAmounts([...amounts, { asset: "", amount: "" }])}
+                    className="flex items-center gap-1 text-sm text-zinc-400 hover:text-white"
+                >
+                    <Plus size={14} /> Add asset
+                </button>
+            </div>
+
+            <div className="flex items-center gap-4">
+                <button
+                    onClick={save}
+                    disabled={saving}
+                    className="px-4 py-2 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-white transition-all font-semibold text-sm border border-zinc-700"
+                >
+                    {saving ? "Saving..." : "Save Rules"}
+                </button>
+                {status && <span className="text-sm text-zinc-400">{status}</span>}
+            </div>
+        </div>
+    );
+}
@@ -11,10 +11,41 @@ export interface TipWidgetConfig {
     message_color: string;
 }
 
+// Widget theme as served by the backend, see /api/widget-themes/presets
+export interface WidgetTheme {
+    version: number;
+    colors: { background: string; user: string; amount: string; message: string };
+    font: { family: string; size: number; weight: number };
+    layout: { position: "top" | "center" | "bottom"; align: "left" | "center" | "right"; width: number };
+    animation: { in: string; out: string; duration_ms: number };
+    sound: { url: string; volume: number };
+    image_url: string;
+    text_template: string;
+}
+
+type AnimationState = { opacity: number; x?: number; y?: number; scale?: number };
+
+// framer-motion states of the theme animation names, "none" keeps the alert static
+const ANIMATIONS: Record<string, AnimationState> = {
+    none: { opacity: 1 },
+    fade: { opacity: 0 },
+    zoom: { opacity: 0, scale: 0.95 },
+    bounce: { opacity: 0, scale: 0.6 },
+    "slide-up": { opacity: 0, y: 60 },
+    "slide-down": { opacity: 0, y: -60 },
+    "slide-left": { opacity: 0, x: 80 },
+    "slide-right": { opacity: 0, x: -80 },
+};
+
+const ALIGN_CLASSES = { left: "mr-auto", center: "mx-auto", right: "ml-auto" };
+
 export interface TipData {
     sender: string;
     amount: string;
     message: string;
+    asset?: string;
+    usdValue?: string;
+    mediaUrl?: string; // Animation of the streamer's media tier
     actionText?: string;
     avatarUrl?: string;
     backgroundUrl?: string;
@@ -24,16 +55,50 @@ export interface TipData {
 interface TipWidgetProps {
     tip: TipData;
     config: TipWidgetConfig;
+    theme?: WidgetTheme; // Overrides config's colors
     isPreview?: boolean;
 }
 
-export const TipWidget = ({ tip, config, isPreview = false }: TipWidgetProps) => {
+// Headline from the theme's text template, placeholders keep their colors
+const renderTemplate = (template: string, tip: TipData, colors: WidgetTheme["colors"]) => {
+    const values: Record<string, string> = {
+        "{sender}": tip.sender,
+        "{amount}": tip.amount,
+        "{asset}": tip.asset || "",
+        "{usd}": tip.usdValue ? `$${tip.usdValue}` : "",
+        "{message}": tip.message,
+    };
+    // The translated verb replaces the default one
+    const text = tip.actionText ? template.replace(" tipped ", ` ${tip.actionText} `) : template;
+
+    return text.split(/(\{[a-z]+\})/).map((part, i) => {
+        if (part === "{sender}") return <span key={i} style={{ color: colors.user }}>{values[part]}</span>;
+        if (part === "{amount}" || part === "{usd}") return <span key={i} style={{ color: colors.amount }} className="drop-shadow-sm">{values[part]}</span>;
+        if (part in values) return <span key={i}>{values[part]}</span>;
+        return <span key={i} className="opacity-80 font-normal" style={{ color: colors.message }}>{part}</span>;
+    });
+};
+
+export const TipWidget = ({ tip, config, theme, isPreview = false }: TipWidgetProps) => {
     // User requested "girl by default always".
     // We maintain unique avatars based on sender name.
     const avatarSeed = tip.sender;
 
+    const colors = theme?.colors || {
+        background: config.background_color,
+        user: config.user_color,
+        amount: config.amount_color,
+        message: config.message_color,
+    };
+    const enter = ANIMATIONS[theme?.animation.in || "zoom"] || ANIMATIONS.zoom;
+    const exit = ANIMATIONS[theme?.animation.out || "zoom"] || ANIMATIONS.zoom;
+    const showsMessageInline = theme?.text_template.includes("{message}");
+
     return (
-        <div className="flex flex-col items-start w-full max-w-xl mx-auto">
+        <div
+            className={`flex flex-col items-start w-full max-w-xl ${ALIGN_CLASSES[theme?.layout.align || "center"]}`}
+            style={theme ? { maxWidth: theme.layout.width, fontFamily: theme.font.family, fontWeight: theme.font.weight } : undefined}
+        >
             {/* Twitter/X Badge */}
             {tip.twitterHandle && (
                 <motion.div
@@ -51,14 +116,25 @@ export const TipWidget = ({ tip, config, isPreview = false }: TipWidgetProps) =>
                 </motion.div>
             )}
 
+            {tip.mediaUrl && (
+                <motion.img
+                    src={tip.mediaUrl}
+                    alt=""
+                    initial={{ opacity: 0 }}
+                    animate={{ opacity: 1 }}
+                    exit={{ opacity: 0 }}
+                    className="self-center max-h-48 mb-2 object-contain"
+                />
+            )}
+
             <motion.div
-                initial={isPreview ? { opacity: 1, scale: 1 } : { opacity: 0, scale: 0.95 }}
-                animate={{ opacity: 1, scale: 1 }}
-                exit={{ opacity: 0, scale: 0.95 }}
-                transition={{ duration: 0.5, ease: "easeOut" }}
+                initial={isPreview ? { opacity: 1, x: 0, y: 0, scale: 1 } : enter}
+                animate={{ opacity: 1, x: 0, y: 0, scale: 1 }}
+                exit={exit}
+                transition={theme?.animation.in === "bounce" ? { type: "spring", bounce: 0.5 } : { duration: 0.5, ease: "easeOut" }}
                 className={`w-full rounded-xl p-6 shadow-2xl relative overflow-hidden ${isPreview ? "" : "border-2 border-white/10"}`}
                 style={{
-                    backgroundColor: config.background_color,
+                    backgroundColor: colors.background,
                 }}
             >
                 {/* Background Image Layer */}
@@ -77,17 +153,23 @@ export const TipWidget = ({ tip, config, isPreview = false }: TipWidgetProps) =>
                 <div className="relative z-10 flex items-start gap-4">
                     <div className="w-12 h-12 rounded-full overflow-hidden border-2 border-white/20 shadow-md shrink-0">
                         <img
-                            src={tip.avatarUrl || `https://api.dicebear.com/7.x/avataaars/svg?seed=${avatarSeed}&backgroundColor=b6e3f4,c0aede,d1d4f9`}
+                            src={theme?.image_url || tip.avatarUrl || `https://api.dicebear.com/7.x/avataaars/svg?seed=${avatarSeed}&backgroundColor=b6e3f4,c0aede,d1d4f9`}
                             alt="Avatar"
                             className="w-full h-full bg-zinc-800 object-cover"
                         />
                     </div>
                     <div className="flex-1">
-                        <div className="font-bold text-lg leading-tight mb-1" style={{ color: config.user_color }}>
-                            {tip.sender} <span className="opacity-80 font-normal" style={{ color: config.message_color }}>{tip.actionText || 'tipped'}</span> <span style={{ color: config.amount_color }} className="drop-shadow-sm">{tip.amount}</span>
-                        </div>
-                        {tip.message && (
-                            <p className="text-base opacity-90 leading-snug break-words" style={{ color: config.message_color }}>
+                        {theme ? (
+                            <div className="leading-tight mb-1 break-words" style={{ fontSize: theme.font.size, color: colors.message }}>
+                                {renderTemplate(theme.text_template, tip, colors)}
+                            </div>
+                        ) : (
+                            <div className="font-bold text-lg leading-tight mb-1" style={{ color: colors.user }}>
+                                {tip.sender} <span className="opacity-80 font-normal" style={{ color: colors.message }}>{tip.actionText || 'tipped'}</span> <span style={{ color: colors.amount }} className="drop-shadow-sm">{tip.amount}</span>
+                            </div>
+                        )}
+                        {tip.message && !showsMessageInline && (
+                            <p className="text-base opacity-90 leading-snug break-words" style={{ color: colors.message }}>
                                 {tip.message}
                             </p>
                         )}
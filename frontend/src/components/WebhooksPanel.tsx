@@ -0,0 +1,224 @@
+"use client";
+
+import { useCallback, useEffect, useState } from "react";
+import { Webhook as WebhookIcon, Plus, Trash2, Copy, Power, Send, RefreshCw, History, RotateCcw } from "lucide-react";
+
+type Webhook = {
+    id: number;
+    url: string;
+    secret: string;
+    events: string[];
+    enabled: boolean;
+    created_at: string;
+};
+
+type Delivery = {
+    id: number;
+    event_id: string;
+    event: string;
+    status: "pending" | "delivered" | "failed";
+    attempts: number;
+    response_status?: number;
+    error?: string;
+    duration_ms: number;
+    next_attempt_at?: string;
+    delivered_at?: string;
+    created_at: string;
+};
+
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+const STATUS_CLASSES: Record<Delivery["status"], string> = {
+    pending: "text-yellow-400",
+    delivered: "text-green-400",
+    failed: "text-red-400",
+};
+
+// Endpoints receiving signed tip events, with their delivery log
+export function WebhooksPanel() {
+    const [webhooks, setWebhooks] = useState<Webhook[]>([]);
+    const [events, setEvents] = useState<string[]>([]);
+    const [url, setUrl] = useState("");
+    const [selected, setSelected] = useState<number | null>(null);
+    const [deliveries, setDeliveries] = useState<Delivery[]>([]);
+    const [status, setStatus] = useState("");
+
+    const request = useCallback(async (path: string, method = "GET", body?: unknown) => {
+        const token = localStorage.getItem("user_token");
+        if (!token) return null;
+
+        const res = await fetch(`${API_URL}/api/me/webhooks${path}`, {
+            method,
+            headers: {
+                "Content-Type": "application/json",
+                "Authorization": `Bearer ${token}`
+            },
+            body: body ? JSON.stringify(body) : undefined
+        });
+        const data = await res.json();
+        if (!res.ok) throw new Error(data.error || "Webhook request failed");
+        return data;
+    }, []);
+
+    const refresh = useCallback(async () => {
+        try {
+            const data = await request("");
+            if (data) {
+                setWebhooks(data.webhooks || []);
+                setEvents(data.events || []);
+            }
+        } catch (e: any) {
+            setStatus(e.message);
+        }
+    }, [request]);
+
+    const loadDeliveries = useCallback(async (id: number) => {
+        try {
+            const data = await request(`/${id}/deliveries`);
+            if (data) setDeliveries(data.deliveries || []);
+        } catch (e: any) {
+            setStatus(e.message);
+        }
+    }, [request]);
+
+    useEffect(() => { refresh(); }, [refresh]);
+    useEffect(() => { if (selected !== null) loadDeliveries(selected); }, [selected, loadDeliveries]);
+
+    const act = async (path: string, method: string, body?: unknown) => {
+        setStatus("");
+        try {
+            const data = await request(path, method, body);
+            await refresh();
+            if (selected !== null) await loadDeliveries(selected);
+            return data;
+        } catch (e: any) {
+            setStatus(e.message);
+            return null;
+        }
+    };
+
+    const create = async () => {
+        if (await act("", "POST", { url })) setUrl("");
+    };
+
+    const toggleEvent = (webhook: Webhook, event: string) => {
+        const next = webhook.events.includes(event)
+            ? webhook.events.filter(e => e !== event)
+            : [...webhook.events, event];
+        act(`/${webhook.id}`, "PUT", { events: next });
+    };
+
+    const sendTest = async (webhook: Webhook) => {
+        const delivery: Delivery | null = await act(`/${webhook.id}/test`, "POST");
+        if (delivery) {
+            setStatus(delivery.status === "delivered"
+                ? `Test delivered (${delivery.response_status})`
+                : `Test failed: ${delivery.error}`);
+        }
+    };
+
+    return (
+        <div className="bg-zinc-900/40 backdrop-blur-xl border border-white/5 rounded-3xl p-6 shadow-2xl space-y-4">
+            <h2 className="text-lg font-bold flex items-center gap-2">
+                <WebhookIcon className="text-blue-400" /> Webhooks
+            </h2>
+
+            <div className="flex flex-wrap items-center gap-2 text-sm">
+                <input
+                    value={url}
+                    onChange={(e) => setUrl(e.target.value)}
+                    placeholder="https://example.com/tips"
+                    className="flex-1 min-w-60 bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800"
+                />
+                <button
+                    onClick={create}
+                    disabled={!url}
+                    className="flex items-center gap-1 px-3 py-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 disabled:opacity-40 text-white font-semibold border border-zinc-700"
+                >
+                    <Plus size={14} /> Add Webhook
+                </button>
+            </div>
+
+            {status && <p className="text-sm text-zinc-400">{status}</p>}
+
+            {webhooks.length === 0 ? (
+                <p className="text-sm text-zinc-500">No webhooks yet.</p>
+            ) : (
+                <ul className="space-y-2">
+                    {webhooks.map(webhook => (
+                        <li key={webhook.id} className="p-3 bg-black/20 rounded-xl border border-white/5 space-y-2">
+                            <div className="flex items-center justify-between gap-4">
+                                <span className={`min-w-0 truncate text-sm font-mono ${webhook.enabled ? "text-white" : "text-zinc-500"}`}>{webhook.url}</span>
+                                <div className="flex items-center gap-2 shrink-0">
+                                    <button onClick={() => sendTest(webhook)} title="Send test event" className="p-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-zinc-300 border border-zinc-700">
+                                        <Send size={14} />
+                                    </button>
+                                    <button onClick={() => setSelected(selected === webhook.id ? null : webhook.id)} title="Delivery log" className="p-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-zinc-300 border border-zinc-700">
+                                        <History size={14} />
+                                    </button>
+                                    <button onClick={() => act(`/${webhook.id}`, "PUT", { enabled: !webhook.enabled })} title={webhook.enabled ? "Disable" : "Enable"} className={`p-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 border border-zinc-700 ${webhook.enabled ? "text-green-400" : "text-zinc-500"}`}>
+                                        <Power size={14} />
+                                    </button>
+                                    <button onClick={() => act(`/${webhook.id}`, "DELETE")} title="Delete" className="p-1.5 rounded-lg bg-red-500/10 hover:bg-red-500/20 text-red-400 border border-red-500/20">
+                                        <Trash2 size={14} />
+                                    </button>
+                                </div>
+                            </div>
+
+                            <div className="flex flex-wrap items-center gap-3 text-xs text-zinc-300">
+                                {events.map(event => (
+                                    <label key={event} className="flex items-center gap-1">
+                                        <input
+                                            type="checkbox"
+                                            checked={webhook.events.includes(event)}
+                                            onChange={() => toggleEvent(webhook, event)}
+                                            className="accent-blue-500"
+                                        />
+                                        {event}
+                                    </label>
+                                ))}
+                            </div>
+
+                            <div className="flex items-center gap-2 text-xs text-zinc-500">
+                                <span>Secret</span>
+                                <code className="truncate bg-zinc-950 px-2 py-0.5 rounded border border-zinc-800">{webhook.secret}</code>
+                                <button onClick={() => navigator.clipboard.writeText(webhook.secret)} title="Copy secret" className="hover:text-zinc-300">
+                                    <Copy size={12} />
+                                </button>
+                                <button onClick={() => act(`/${webhook.id}/secret`, "POST")} title="Rotate secret" className="hover:text-zinc-300">
+                                    <RefreshCw size={12} />
+                                </button>
+                            </div>
+
+                            {selected === webhook.id && (
+                                deliveries.length === 0 ? (
+                                    <p className="text-xs text-zinc-500">No deliveries yet.</p>
+                                ) : (
+                                    <ul className="space-y-1 text-xs">
+                                        {deliveries.map(d => (
+                                            <li key={d.id} className="flex items-center justify-between gap-2 px-2 py-1 bg-zinc-950/60 rounded-lg">
+                                                <span className="min-w-0 truncate">
+                                                    <span className={STATUS_CLASSES[d.status]}>{d.status}</span>
+                                                    <span className="text-zinc-400"> · {d.event} · {new Date(d.created_at).toLocaleString()}</span>
+                                                    <span className="text-zinc-500"> · {d.attempts} {d.attempts === 1 ? "attempt" : "attempts"}</span>
+                                                    {d.response_status ? <span className="text-zinc-500"> · HTTP {d.response_status}</span> : null}
+                                                    {d.error && d.status !== "delivered" && <span className="text-zinc-500" title={d.error}> · {d.error}</span>}
+                                                    {d.next_attempt_at && <span className="text-zinc-500"> · retry {new Date(d.next_attempt_at).toLocaleTimeString()}</span>}
+                                                </span>
+                                                {d.status !== "pending" && d.event !== "webhook.test" && (
+                                                    <button onClick={() => act(`/${webhook.id}/deliveries/${d.id}/redeliver`, "POST")} title="Redeliver" className="shrink-0 text-zinc-400 hover:text-zinc-200">
+                                                        <RotateCcw size={12} />
+                                                    </button>
+                                                )}
+                                            </li>
+                                        ))}
+                                    </ul>
+                                )
+                            )}
+                        </li>
+                    ))}
+                </ul>
+            )}
+        </div>
+    );
+}
@@ -0,0 +1,152 @@
+"use client";
+
+import { useCallback, useEffect, useState } from "react";
+import { LayoutGrid, Plus, Trash2, Copy, RefreshCw, Power } from "lucide-react";
+import { WidgetTheme } from "@/components/TipWidget";
+
+export type Widget = {
+    id: number;
+    type: "alert" | "goal";
+    name: string;
+    token: string;
+    config: Record<string, unknown> & { goal_id?: number; theme?: WidgetTheme };
+    enabled: boolean;
+    subscriptions: string[];
+    created_at: string;
+};
+
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+const WIDGET_TYPES: { type: Widget["type"]; label: string }[] = [
+    { type: "alert", label: "Alert box" },
+    { type: "goal", label: "Goal bar" },
+];
+
+// Overlay URL of a widget, each type has its own page
+export function widgetUrl(widget: Widget) {
+    const base = `${window.location.origin}/widget/${widget.token}`;
+    return widget.type === "goal" ? `${base}/goal` : base;
+}
+
+export async function widgetRequest(path: string, method = "GET", body?: unknown) {
+    const token = localStorage.getItem("user_token");
+    if (!token) return null;
+
+    const res = await fetch(`${API_URL}/api/me/widgets${path}`, {
+        method,
+        headers: {
+            "Content-Type": "application/json",
+            "Authorization": `Bearer ${token}`
+        },
+        body: body ? JSON.stringify(body) : undefined
+    });
+    const data = await res.json();
+    if (!res.ok) throw new Error(data.error || "Widget request failed");
+    return data;
+}
+
+// All of the streamer's overlays, each with its own private URL
+export function WidgetsPanel() {
+    const [widgets, setWidgets] = useState<Widget[]>([]);
+    const [newType, setNewType] = useState<Widget["type"]>("alert");
+    const [newName, setNewName] = useState("");
+    const [status, setStatus] = useState("");
+
+    const refresh = useCallback(async () => {
+        try {
+            const data = await widgetRequest("");
+            if (data) setWidgets(data.widgets || []);
+        } catch (e: any) {
+            setStatus(e.message);
+        }
+    }, []);
+
+    useEffect(() => { refresh(); }, [refresh]);
+
+    const act = async (path: string, method: string, body?: unknown, done?: string) => {
+        setStatus("");
+        try {
+            await widgetRequest(path, method, body);
+            await refresh();
+            if (done) setStatus(done);
+        } catch (e: any) {
+            setStatus(e.message);
+        }
+    };
+
+    const updateSubscriptions = (widget: Widget, value: string) => {
+        const subscriptions = value.split(",").map(s => s.trim()).filter(Boolean);
+        act(`/${widget.id}`, "PUT", { subscriptions }, "Subscriptions saved!");
+    };
+
+    return (
+        <div className="bg-zinc-900/40 backdrop-blur-xl border border-white/5 rounded-3xl p-6 shadow-2xl space-y-4">
+            <h2 className="text-lg font-bold flex items-center gap-2">
+                <LayoutGrid className="text-blue-400" /> Widgets
+            </h2>
+
+            <div className="flex flex-wrap items-center gap-2 text-sm">
+                <select
+                    value={newType}
+                    onChange={(e) => setNewType(e.target.value as Widget["type"])}
+                    className="bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800"
+                >
+                    {WIDGET_TYPES.map(t => <option key={t.type} value={t.type}>{t.label}</option>)}
+                </select>
+                <input
+                    value={newName}
+                    onChange={(e) => setNewName(e.target.value)}
+                    placeholder="Name (optional)"
+                    className="flex-1 min-w-40 bg-zinc-950 px-2 py-1.5 rounded-lg border border-zinc-800"
+                />
+                <button
+                    onClick={() => act("", "POST", { type: newType, name: newName }).then(() => setNewName(""))}
+                    className="flex items-center gap-1 px-3 py-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-white font-semibold border border-zinc-700"
+                >
+                    <Plus size={14} /> Add Widget
+                </button>
+            </div>
+
+            {status && <p className="text-sm text-zinc-400">{status}</p>}
+
+            <ul className="space-y-2">
+                {widgets.map(widget => (
+                    <li key={widget.id} className="p-3 bg-black/20 rounded-xl border border-white/5 space-y-2">
+                        <div className="flex items-center justify-between gap-4">
+                            <div className="min-w-0 text-sm">
+                                <span className={`font-semibold ${widget.enabled ? "text-white" : "text-zinc-500"}`}>{widget.name}</span>
+                                <span className="text-zinc-500"> · {WIDGET_TYPES.find(t => t.type === widget.type)?.label || widget.type}</span>
+                            </div>
+                            <div className="flex items-center gap-2 shrink-0">
+                                <button onClick={() => { navigator.clipboard.writeText(widgetUrl(widget)); setStatus("URL Copied!"); }} title="Copy URL" className="p-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-zinc-300 border border-zinc-700">
+                                    <Copy size={14} />
+                                </button>
+                                <button onClick={() => act(`/${widget.id}/regenerate`, "POST", undefined, "Widget URL regenerated!")} title="Regenerate URL" className="p-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 text-zinc-300 border border-zinc-700">
+                                    <RefreshCw size={14} />
+                                </button>
+                                <button onClick={() => act(`/${widget.id}`, "PUT", { enabled: !widget.enabled })} title={widget.enabled ? "Disable" : "Enable"} className={`p-1.5 rounded-lg bg-zinc-800 hover:bg-zinc-700 border border-zinc-700 ${widget.enabled ? "text-green-400" : "text-zinc-500"}`}>
+                                    <Power size={14} />
+                                </button>
+                                <button onClick={() => act(`/${widget.id}`, "DELETE")} title="Delete" className="p-1.5 rounded-lg bg-red-500/10 hover:bg-red-500/20 text-red-400 border border-red-500/20">
+                                    <Trash2 size={14} />
+                                </button>
+                            </div>
+                        </div>
+                        <label className="flex items-center gap-2 text-xs text-zinc-500">
+                            Events
+                            <input
+                                key={widget.subscriptions.join(",")}
+                                defaultValue={widget.subscriptions.join(", ")}
+                                onBlur={(e) => {
+                                    if (e.target.value !== widget.subscriptions.join(", ")) updateSubscriptions(widget, e.target.value);
+                                }}
+                                placeholder="Type defaults"
+                                className="flex-1 bg-zinc-950 px-2 py-1 rounded-lg border border-zinc-800 font-mono text-zinc-300"
+                            />
+                        </label>
+                    </li>
+                ))}
+            </ul>
+        </div>
+    );
+}
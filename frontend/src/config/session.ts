@@ -0,0 +1,116 @@
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+type SessionTokens = { token: string; refresh_token?: string };
+
+// storeSession keeps the tokens a login, signup or refresh returned
+export function storeSession(data: SessionTokens) {
+    localStorage.setItem("user_token", data.token);
+    if (data.refresh_token) localStorage.setItem("refresh_token", data.refresh_token);
+}
+
+export function clearSession() {
+    localStorage.removeItem("user_token");
+    localStorage.removeItem("refresh_token");
+}
+
+// logout revokes the session on the server, then forgets it here
+export async function logout() {
+    const token = localStorage.getItem("user_token");
+    const refreshToken = localStorage.getItem("refresh_token");
+    clearSession();
+    if (!token && !refreshToken) return;
+    try {
+        await fetch(`${API_URL}/api/auth/logout`, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json",
+                ...(token ? { "Authorization": `Bearer ${token}` } : {})
+            },
+            body: JSON.stringify({ refresh_token: refreshToken || "" })
+        });
+    } catch (e) {
+        console.error("Logout failed:", e);
+    }
+}
+
+let refreshing: Promise<string | null> | null = null;
+
+// refreshSession swaps the refresh token for a new access token. Tabs take
+// turns through a lock, and a tab that finds the token already replaced by
+// another tab uses that instead of spending the refresh token twice.
+function refreshSession(staleToken: string, fetcher: typeof fetch): Promise<string | null> {
+    if (refreshing) return refreshing;
+
+    const run = async () => {
+        const current = localStorage.getItem("user_token");
+        if (current && current !== staleToken) return current;
+
+        const refreshToken = localStorage.getItem("refresh_token");
+        if (!refreshToken) return null;
+
+        const res = await fetcher(`${API_URL}/api/auth/refresh`, {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify({ refresh_token: refreshToken })
+        });
+        if (!res.ok) {
+            if (res.status === 401) clearSession();
+            return null;
+        }
+        const data = await res.json();
+        storeSession(data);
+        return data.token as string;
+    };
+
+    refreshing = (navigator.locks ? navigator.locks.request("session-refresh", run) : run())
+        .catch(e => {
+            console.error("Session refresh failed:", e);
+            return null;
+        })
+        .finally(() => { refreshing = null; });
+    return refreshing;
+}
+
+// withToken replaces the stale access token in the request's URL and
+// Authorization header
+function withToken(input: RequestInfo | URL, init: RequestInit | undefined, stale: string, token: string): [RequestInfo | URL, RequestInit | undefined] {
+    let url = input instanceof Request ? input.url : input.toString();
+    url = url.replace(`token=${stale}`, `token=${token}`);
+
+    const headers = new Headers(init?.headers ?? (input instanceof Request ? input.headers : undefined));
+    if (headers.get("Authorization") === `Bearer ${stale}`) {
+        headers.set("Authorization", `Bearer ${token}`);
+    }
+    if (input instanceof Request) {
+        return [new Request(url, input), { ...init, headers }];
+    }
+    return [url, { ...init, headers }];
+}
+
+// installSessionRefresh makes API requests that fail with 401 on an expired
+// access token refresh the session and retry once
+export function installSessionRefresh() {
+    if (typeof window === "undefined" || (window.fetch as any).sessionRefresh) return;
+    const original = window.fetch.bind(window);
+
+    const patched = async (input: RequestInfo | URL, init?: RequestInit) => {
+        const token = localStorage.getItem("user_token");
+        const url = input instanceof Request ? input.url : input.toString();
+        if (!token || !url.startsWith(API_URL) || url.startsWith(`${API_URL}/api/auth/`)) {
+            return original(input, init);
+        }
+
+        const headers = new Headers(init?.headers ?? (input instanceof Request ? input.headers : undefined));
+        const carriesToken = headers.get("Authorization") === `Bearer ${token}` || url.includes(`token=${token}`);
+        // A Request body can only be read once, keep a copy for the retry
+        const retryInput = carriesToken && input instanceof Request ? input.clone() : input;
+
+        const res = await original(input, init);
+        if (res.status !== 401 || !carriesToken) return res;
+
+        const fresh = await refreshSession(token, original);
+        if (!fresh) return res;
+        return original(...withToken(retryInput, init, token, fresh));
+    };
+    window.fetch = Object.assign(patched, { sessionRefresh: true });
+}
@@ -0,0 +1,34 @@
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
+
+export type SiweAccountType = "Ethereum" | "Solana" | "Bitcoin" | "Sui";
+
+// CAIP-2 reference of Bitcoin mainnet, the start of its genesis block hash
+export const BITCOIN_MAINNET = "000000000019d6689c085ae165831e93";
+
+const STATEMENT = "Sign in to Stream Tips.";
+const MESSAGE_TTL_MS = 10 * 60 * 1000; // Matches the server's nonce lifetime
+
+// createSiweMessage builds an EIP-4361 sign-in message for this site with a
+// nonce from the server. Solana, Bitcoin and Sui wallets sign the same format
+// for their account (CAIP-122), with the chain ID "mainnet" or BITCOIN_MAINNET.
+export async function createSiweMessage(address: string, accountType: SiweAccountType, chainId: number | string) {
+    const res = await fetch(`${API_URL}/api/auth/wallet/nonce`, { cache: "no-store" });
+    const data = await res.json();
+    if (!res.ok) throw new Error(data.error || "Failed to get sign-in nonce");
+
+    const issuedAt = new Date();
+    const expiresAt = new Date(issuedAt.getTime() + MESSAGE_TTL_MS);
+    return [
+        `${window.location.host} wants you to sign in with your ${accountType} account:`,
+        address,
+        "",
+        STATEMENT,
+        "",
+        `URI: ${window.location.origin}`,
+        "Version: 1",
+        `Chain ID: ${chainId}`,
+        `Nonce: ${data.nonce}`,
+        `Issued At: ${issuedAt.toISOString()}`,
+        `Expiration Time: ${expiresAt.toISOString()}`,
+    ].join("\n");
+}
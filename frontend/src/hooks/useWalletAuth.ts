@@ -1,10 +1,11 @@
 import { useState, useCallback } from 'react';
 import { useAccount, useSignMessage } from 'wagmi';
+import { createSiweMessage } from '@/config/siwe';
 
 const API_URL = process.env.NEXT_PUBLIC_API_URL || 'https://localhost:8080';
 
 export function useWalletAuth() {
-    const { address } = useAccount();
+    const { address, chainId } = useAccount();
     const { signMessageAsync } = useSignMessage();
     const [authToken, setAuthToken] = useState<string | null>(null);
     const [isAuthenticating, setIsAuthenticating] = useState(false);
@@ -27,10 +28,8 @@ export function useWalletAuth() {
 
         setIsAuthenticating(true);
         try {
-            // 2. Prepare Message
-            const timestamp = Math.floor(Date.now() / 1000);
-            // Deterministic JSON string to ensure backend match
-            const message = `{"address":"${address}","timestamp":${timestamp}}`;
+            // 2. Prepare Sign-In With Ethereum Message
+            const message = await createSiweMessage(address, "Ethereum", chainId ?? 1);
 
             // 3. Sign Message
             const signature = await signMessageAsync({ message });
@@ -40,8 +39,7 @@ export function useWalletAuth() {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({
-                    address,
-                    timestamp,
+                    message,
                     signature
                 })
             });
@@ -75,7 +73,7 @@ export function useWalletAuth() {
             setIsAuthenticating(false);
             throw err;
         }
-    }, [address, signMessageAsync]);
+    }, [address, chainId, signMessageAsync]);
 
     const logout = useCallback(() => {
         if (address) localStorage.removeItem(`wallet_token_${address}`);